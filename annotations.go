@@ -0,0 +1,418 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnnotationScanner builds an OpenAPI document from annotated doc comments
+// of Go functions. An operation is defined by a function whose doc comment
+// contains an @openapi line followed by optional annotations:
+//
+//	// GetPet returns a pet by id.
+//	//
+//	// @openapi GET /pets/{id} getPet
+//	// @param id path int64 The pet id
+//	// @param verbose query bool required Include details
+//	// @response 200 Pet The pet
+//	// @response 404 - Not found
+//	// @response default Error application/json
+//	// @security api_key
+//	func GetPet(w http.ResponseWriter, r *http.Request) {
+//
+// Annotations are:
+//
+//	@openapi METHOD PATH [OPERATION-ID]
+//	@summary TEXT
+//	@description TEXT
+//	@tags TAG...
+//	@deprecated
+//	@param NAME IN TYPE [required] [DESCRIPTION]
+//	@body TYPE [CONTENT-TYPE]
+//	@response CODE TYPE|- [CONTENT-TYPE] [DESCRIPTION]
+//	@security SCHEME [SCOPE...] | none
+//
+// Doc comment text outside annotations supplies the summary, its first
+// paragraph, and the description, the remainder. Lines starting with a word
+// prefixed by '@' that is not an annotation, such as @mentions, are text. Content types are
+// recognized by a '/' and default to "application/json".
+//
+// Types are Go type expressions such as "Pet", "*pets.Pet", "[]Pet" or
+// "map[string]Pet" whose named types are predeclared, time.Time or
+// registered using Register. Their schemas are generated by SchemaFromType.
+type AnnotationScanner struct {
+	b     *Builder
+	types map[string]reflect.Type
+	// ops are source positions of scanned operations keyed by method and
+	// path.
+	ops map[string]token.Position
+}
+
+// Returns a new AnnotationScanner of a document with the title and version.
+func NewAnnotationScanner(title, version string) *AnnotationScanner {
+	var s = &AnnotationScanner{
+		b:     New(title, version),
+		types: make(map[string]reflect.Type),
+		ops:   make(map[string]token.Position),
+	}
+	for _, v := range []interface{}{
+		false, "", 0, int8(0), int16(0), int32(0), int64(0),
+		uint(0), uint8(0), uint16(0), uint32(0), uint64(0),
+		float32(0), float64(0), time.Time{},
+	} {
+		s.Register(v)
+	}
+	s.types["byte"] = s.types["uint8"]
+	s.types["rune"] = s.types["int32"]
+	return s
+}
+
+// Returns the Builder of the document, for document level definitions such
+// as servers and security schemes.
+func (s *AnnotationScanner) Builder() *Builder {
+	return s.b
+}
+
+// Registers types of values so that annotations can refer to them by name,
+// e.g. "Pet", or by package qualified name, e.g. "pets.Pet". Values may be
+// nil pointers to the type but not nil.
+func (s *AnnotationScanner) Register(values ...interface{}) error {
+	for i, v := range values {
+		var t = reflect.TypeOf(v)
+		if t == nil {
+			return fmt.Errorf("register: value %d is nil", i)
+		}
+		if t.Kind() == reflect.Ptr && t.Name() == "" {
+			t = t.Elem()
+		}
+		if t.PkgPath() != "" {
+			s.types[path.Base(t.PkgPath())+"."+t.Name()] = t
+			if _, ok := s.types[t.Name()]; ok {
+				continue
+			}
+		}
+		s.types[t.Name()] = t
+	}
+	return nil
+}
+
+// Scans Go files in dir and its subdirectories, skipping test files and
+// "vendor", "testdata" and hidden directories.
+func (s *AnnotationScanner) ScanDir(dir string) error {
+	return filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		var base = info.Name()
+		if info.IsDir() {
+			if name != dir && (base == "vendor" || base == "testdata" ||
+				strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(base, ".go") || strings.HasSuffix(base, "_test.go") {
+			return nil
+		}
+		return s.ScanFile(name, nil)
+	})
+}
+
+// Scans a Go file. If src is not nil it holds the file source, see
+// parser.ParseFile. Defining an operation of a method and path that was
+// already scanned is an error.
+func (s *AnnotationScanner) ScanFile(filename string, src interface{}) error {
+	var (
+		fset      = token.NewFileSet()
+		file, err = parser.ParseFile(fset, filename, src, parser.ParseComments)
+	)
+	if err != nil {
+		return err
+	}
+	for _, decl := range file.Decls {
+		var fn, ok = decl.(*ast.FuncDecl)
+		if !ok || fn.Doc == nil {
+			continue
+		}
+		if err = s.scanComment(fset, fn.Doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the scanned document or the first error that occurred.
+func (s *AnnotationScanner) Document() (*OpenAPI, error) {
+	return s.b.Build()
+}
+
+// annotationNames are names of annotations.
+var annotationNames = map[string]bool{
+	"openapi": true, "summary": true, "description": true, "tags": true, "deprecated": true,
+	"param": true, "body": true, "response": true, "security": true,
+}
+
+// An annotation line of a doc comment.
+type annotation struct {
+	pos  token.Pos
+	name string
+	args []string
+}
+
+// Returns the i-th argument or an empty string.
+func (a *annotation) arg(i int) string {
+	if i < len(a.args) {
+		return a.args[i]
+	}
+	return ""
+}
+
+// Returns arguments from i on joined by a space.
+func (a *annotation) rest(i int) string {
+	if i < len(a.args) {
+		return strings.Join(a.args[i:], " ")
+	}
+	return ""
+}
+
+// Defines an operation from doc if it contains an @openapi annotation.
+func (s *AnnotationScanner) scanComment(fset *token.FileSet, doc *ast.CommentGroup) error {
+	var (
+		annotations []*annotation
+		text        []string
+		op          *annotation
+	)
+	for _, c := range doc.List {
+		var lines = []string{strings.TrimPrefix(c.Text, "//")}
+		if strings.HasPrefix(c.Text, "/*") {
+			lines = strings.Split(strings.TrimSuffix(c.Text[2:], "*/"), "\n")
+		}
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			var fields = strings.Fields(line)
+			if len(fields) == 0 || !strings.HasPrefix(fields[0], "@") || !annotationNames[fields[0][1:]] {
+				text = append(text, line)
+				continue
+			}
+			var a = &annotation{pos: c.Pos(), name: fields[0][1:], args: fields[1:]}
+			if a.name == "openapi" {
+				op = a
+			}
+			annotations = append(annotations, a)
+		}
+	}
+	if op == nil {
+		return nil
+	}
+	if len(op.args) < 2 || len(op.args) > 3 {
+		return fmt.Errorf("%s: invalid @openapi annotation, expected METHOD PATH [OPERATION-ID]", fset.Position(op.pos))
+	}
+	var (
+		method = strings.ToUpper(op.args[0])
+		key    = method + " " + op.args[1]
+		pos    = fset.Position(op.pos)
+	)
+	if first, ok := s.ops[key]; ok {
+		return fmt.Errorf("%s: duplicate operation %s, first defined at %s", pos, key, first)
+	}
+	s.ops[key] = pos
+	var err error
+	s.b.Path(op.args[1]).Method(method, func(ob *OperationBuilder) {
+		ob.ID(op.arg(2))
+		ob.Summary(docSummary(text)).Description(docDescription(text))
+		for _, a := range annotations {
+			if err = s.annotate(ob, a); err != nil {
+				err = fmt.Errorf("%s: @%s: %w", fset.Position(a.pos), a.name, err)
+				return
+			}
+		}
+	})
+	return err
+}
+
+// Applies a to the operation.
+func (s *AnnotationScanner) annotate(ob *OperationBuilder, a *annotation) error {
+	switch a.name {
+	case "openapi":
+	case "summary":
+		ob.Summary(a.rest(0))
+	case "description":
+		ob.Description(a.rest(0))
+	case "tags":
+		for _, tag := range a.args {
+			if tag = strings.Trim(tag, ","); tag != "" {
+				ob.Tags(tag)
+			}
+		}
+	case "deprecated":
+		ob.Deprecated()
+	case "param":
+		if len(a.args) < 3 {
+			return fmt.Errorf("expected NAME IN TYPE [required] [DESCRIPTION]")
+		}
+		var schema, err = s.schema(a.args[2])
+		if err != nil {
+			return err
+		}
+		if a.arg(3) == "required" {
+			ob.RequiredParam(a.args[0], a.args[1], schema, a.rest(4))
+		} else {
+			ob.Param(a.args[0], a.args[1], schema, a.rest(3))
+		}
+	case "body":
+		if len(a.args) < 1 || len(a.args) > 2 {
+			return fmt.Errorf("expected TYPE [CONTENT-TYPE]")
+		}
+		var schema, err = s.schema(a.args[0])
+		if err != nil {
+			return err
+		}
+		var contentType = a.arg(1)
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		ob.Body(contentType, schema, true)
+	case "response":
+		if len(a.args) < 2 {
+			return fmt.Errorf("expected CODE TYPE|- [CONTENT-TYPE] [DESCRIPTION]")
+		}
+		var code int
+		if a.args[0] != "default" {
+			var err error
+			if code, err = strconv.Atoi(a.args[0]); err != nil || code < 100 || code > 599 {
+				return fmt.Errorf("invalid status code '%s'", a.args[0])
+			}
+		}
+		var i, contentType = 2, "application/json"
+		if strings.Contains(a.arg(i), "/") {
+			contentType = a.arg(i)
+			i++
+		}
+		if a.args[1] == "-" {
+			ob.ResponseContent(code, a.rest(i), "", nil)
+			break
+		}
+		var schema, err = s.schema(a.args[1])
+		if err != nil {
+			return err
+		}
+		ob.ResponseContent(code, a.rest(i), contentType, schema)
+	case "security":
+		if len(a.args) == 0 {
+			return fmt.Errorf("expected SCHEME [SCOPE...] or none")
+		}
+		if a.args[0] == "none" {
+			ob.NoSecurity()
+		} else {
+			ob.Security(a.args[0], a.args[1:]...)
+		}
+	default:
+		return fmt.Errorf("unknown annotation")
+	}
+	return nil
+}
+
+// Returns the schema of the Go type expression expr.
+func (s *AnnotationScanner) schema(expr string) (*Schema, error) {
+	var x, err = parser.ParseExpr(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid type '%s'", expr)
+	}
+	t, err := s.typeOf(x)
+	if err != nil {
+		return nil, err
+	}
+	return SchemaFromType(t, s.b.schemas)
+}
+
+// Returns the type of the type expression x.
+func (s *AnnotationScanner) typeOf(x ast.Expr) (reflect.Type, error) {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return s.named(x.Name)
+	case *ast.SelectorExpr:
+		var pkg, ok = x.X.(*ast.Ident)
+		if !ok {
+			break
+		}
+		return s.named(pkg.Name + "." + x.Sel.Name)
+	case *ast.StarExpr:
+		var t, err = s.typeOf(x.X)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.PtrTo(t), nil
+	case *ast.ArrayType:
+		if x.Len != nil {
+			break
+		}
+		var t, err = s.typeOf(x.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(t), nil
+	case *ast.MapType:
+		var k, err = s.typeOf(x.Key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := s.typeOf(x.Value)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.MapOf(k, v), nil
+	case *ast.InterfaceType:
+		if x.Methods == nil || len(x.Methods.List) == 0 {
+			return reflect.TypeOf((*interface{})(nil)).Elem(), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported type expression")
+}
+
+// Returns the registered type name.
+func (s *AnnotationScanner) named(name string) (reflect.Type, error) {
+	if t, ok := s.types[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("unregistered type '%s'", name)
+}
+
+// Returns the first paragraph of doc comment lines.
+func docSummary(lines []string) string {
+	var summary []string
+	for _, line := range lines {
+		if line == "" {
+			if len(summary) > 0 {
+				break
+			}
+			continue
+		}
+		summary = append(summary, line)
+	}
+	return strings.Join(summary, " ")
+}
+
+// Returns doc comment lines following the first paragraph.
+func docDescription(lines []string) string {
+	var i = 0
+	for i < len(lines) && lines[i] == "" {
+		i++
+	}
+	for i < len(lines) && lines[i] != "" {
+		i++
+	}
+	return strings.TrimSpace(strings.Join(lines[i:], "\n"))
+}
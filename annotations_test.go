@@ -0,0 +1,113 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const annotatedSource = `package pets
+
+// ListPets lists pets.
+//
+// Results are paged.
+// @alice maintains this endpoint.
+//
+// @openapi GET /pets
+// @tags pets
+// @param limit query int32 Maximum number of results
+// @response 200 []annotatedPet A list of pets
+// @response default annotatedError
+func ListPets() {}
+
+// @openapi GET /pets/{id} getPet
+// @summary Returns a pet
+// @param id path int64 The pet id
+// @param X-Trace header string required
+// @response 200 *openapi.annotatedPet
+// @response 404 -
+// @security api_key
+func (h *Handler) GetPet() {}
+
+// Not an operation.
+func helper() {}
+`
+
+type annotatedPet struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type annotatedError struct {
+	Message string `json:"message"`
+}
+
+func TestAnnotationScanner(t *testing.T) {
+	var s = NewAnnotationScanner("Pets", "1.0")
+	if err := s.Register(annotatedPet{}, (*annotatedError)(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register(nil); err == nil {
+		t.Error("expected error registering nil")
+	}
+	if err := s.ScanFile("pets.go", annotatedSource); err != nil {
+		t.Fatal(err)
+	}
+	var doc, err = s.Document()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var list = doc.Paths.Items["/pets"].Get
+	if list.OperationID != "getPets" || list.Summary != "ListPets lists pets." ||
+		list.Description != "Results are paged.\n@alice maintains this endpoint." || len(list.Tags) != 1 {
+		t.Fatalf("list: %+v", list)
+	}
+	var (
+		resp   = list.Responses.Codes["200"].(*Response)
+		schema = resp.Content["application/json"].Schema.(*Schema)
+	)
+	if !schema.HasType(TypeArray) || refOf(schema.Items) != "#/components/schemas/annotatedPet" {
+		t.Fatalf("list response: %+v", schema)
+	}
+	if list.Responses.Default == nil {
+		t.Fatal("missing default response")
+	}
+
+	var get = doc.Paths.Items["/pets/{id}"].Get
+	if get.OperationID != "getPet" || get.Summary != "Returns a pet" || len(get.Security) != 1 {
+		t.Fatalf("get: %+v", get)
+	}
+	if p := get.Parameters[1].(*Parameter); p.In != "header" || !p.Required {
+		t.Fatalf("header parameter: %+v", p)
+	}
+	if r := get.Responses.Codes["404"].(*Response); r.Content != nil || r.Description != "Not Found" {
+		t.Fatalf("404 response: %+v", r)
+	}
+	b, _ := json.Marshal(doc.Components.Schemas["annotatedPet"])
+	if !strings.Contains(string(b), `"name"`) {
+		t.Fatalf("pet schema: %s", b)
+	}
+
+	err = NewAnnotationScanner("Pets", "1.0").ScanFile("bad.go", `package bad
+// @openapi GET /x
+// @response 200 Unknown
+func X() {}
+`)
+	if err == nil || !strings.Contains(err.Error(), "bad.go:3:1: @response: unregistered type 'Unknown'") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var scanner = NewAnnotationScanner("Pets", "1.0")
+	if err = scanner.ScanFile("a.go", "package a\n\n// @openapi GET /x\nfunc X() {}\n"); err != nil {
+		t.Fatal(err)
+	}
+	err = scanner.ScanFile("b.go", "package b\n\n// @openapi get /x\nfunc Y() {}\n")
+	if err == nil || err.Error() != "b.go:3:1: duplicate operation GET /x, first defined at a.go:3:1" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,373 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AsyncAPIVersion is the AsyncAPI version of documents generated by
+// GenerateAsyncAPI.
+const AsyncAPIVersion = "3.0.0"
+
+// GenerateAsyncAPI generates an AsyncAPI document in JSON describing the
+// webhooks of doc and the callbacks of its operations.
+//
+// Each webhook and each callback expression is a channel and each of its
+// operations is a send operation of the API on that channel. Channels have
+// no address as webhook and callback URLs are only known at runtime; the
+// runtime expression of a callback is given in the channel description.
+//
+// Each media type of an operation request body is a message of the channel
+// with the media type schema as its payload. Header parameters are message
+// headers. An operation without a request body has a single message without
+// a payload. Responses are not described.
+//
+// Payloads, headers and component schemas are OpenAPI 3.1 schemas in the
+// "application/vnd.oai.openapi;version=3.1.0" schema format. Component
+// schemas are copied to the AsyncAPI components and references to them are
+// rewritten to the copies, so that they remain valid.
+func GenerateAsyncAPI(doc *OpenAPI) ([]byte, error) {
+	var g = &asyncAPIGenerator{
+		doc: doc,
+		out: &asyncAPIDocument{
+			AsyncAPI:   AsyncAPIVersion,
+			Info:       &asyncAPIInfo{},
+			Channels:   make(map[string]*asyncAPIChannel),
+			Operations: make(map[string]*asyncAPIOperation),
+		},
+		channels:   make(map[string]bool),
+		operations: make(map[string]bool),
+	}
+	if doc.Info != nil {
+		g.out.Info.Title, g.out.Info.Version, g.out.Info.Description = doc.Info.Title, doc.Info.Version, doc.Info.Description
+	}
+	if doc.Components != nil && len(doc.Components.Schemas) > 0 {
+		g.out.Components = &asyncAPIComponents{Schemas: make(map[string]*asyncAPISchema)}
+		for name, schema := range doc.Components.Schemas {
+			var err error
+			if g.out.Components.Schemas[name], err = asyncAPIOpenAPISchema(schema); err != nil {
+				return nil, fmt.Errorf("schema '%s': %w", name, err)
+			}
+		}
+	}
+	if err := g.webhooks(); err != nil {
+		return nil, err
+	}
+	if err := g.callbacks(); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(g.out, "", "  ")
+}
+
+// asyncAPIDocument is a generated AsyncAPI document.
+type asyncAPIDocument struct {
+	AsyncAPI   string                        `json:"asyncapi"`
+	Info       *asyncAPIInfo                 `json:"info"`
+	Channels   map[string]*asyncAPIChannel   `json:"channels"`
+	Operations map[string]*asyncAPIOperation `json:"operations"`
+	Components *asyncAPIComponents           `json:"components,omitempty"`
+}
+
+// asyncAPIInfo is an AsyncAPI Info Object.
+type asyncAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// asyncAPIChannel is an AsyncAPI Channel Object.
+type asyncAPIChannel struct {
+	// Address is always null.
+	Address     *string                     `json:"address"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Messages    map[string]*asyncAPIMessage `json:"messages"`
+}
+
+// asyncAPIMessage is an AsyncAPI Message Object.
+type asyncAPIMessage struct {
+	Name        string          `json:"name"`
+	ContentType string          `json:"contentType,omitempty"`
+	Summary     string          `json:"summary,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Headers     *asyncAPISchema `json:"headers,omitempty"`
+	Payload     *asyncAPISchema `json:"payload,omitempty"`
+}
+
+// asyncAPIOperation is an AsyncAPI Operation Object.
+type asyncAPIOperation struct {
+	Action      string         `json:"action"`
+	Channel     *asyncAPIRef   `json:"channel"`
+	Summary     string         `json:"summary,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Tags        []*asyncAPITag `json:"tags,omitempty"`
+	Messages    []*asyncAPIRef `json:"messages"`
+}
+
+// asyncAPIRef is an AsyncAPI Reference Object.
+type asyncAPIRef struct {
+	Ref string `json:"$ref"`
+}
+
+// asyncAPITag is an AsyncAPI Tag Object.
+type asyncAPITag struct {
+	Name string `json:"name"`
+}
+
+// asyncAPIComponents is an AsyncAPI Components Object.
+type asyncAPIComponents struct {
+	Schemas map[string]*asyncAPISchema `json:"schemas,omitempty"`
+}
+
+// asyncAPISchema is an AsyncAPI Multi Format Schema Object.
+type asyncAPISchema struct {
+	SchemaFormat string      `json:"schemaFormat"`
+	Schema       interface{} `json:"schema"`
+}
+
+// asyncAPISchemaFormat is the AsyncAPI schema format of OpenAPI schemas.
+const asyncAPISchemaFormat = "application/vnd.oai.openapi;version=" + Version
+
+// Returns schema v in the OpenAPI schema format with references to
+// component schemas rewritten to the schemas of their copies.
+func asyncAPIOpenAPISchema(v interface{}) (*asyncAPISchema, error) {
+	var data, err = json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return &asyncAPISchema{SchemaFormat: asyncAPISchemaFormat, Schema: asyncAPIRefs(raw)}, nil
+}
+
+// Rewrites references to component schemas in raw value v to the schema of
+// the Multi Format Schema Object of the component.
+func asyncAPIRefs(v interface{}) interface{} {
+	const prefix = componentsPrefix + "schemas/"
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if ref, ok := val.(string); ok && key == "$ref" && strings.HasPrefix(ref, prefix) {
+				var name, rest = ref[len(prefix):], ""
+				if i := strings.Index(name, "/"); i >= 0 {
+					name, rest = name[:i], name[i:]
+				}
+				v[key] = prefix + name + "/schema" + rest
+				continue
+			}
+			v[key] = asyncAPIRefs(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = asyncAPIRefs(val)
+		}
+	}
+	return v
+}
+
+// asyncAPIGenerator generates an AsyncAPI document of a document.
+type asyncAPIGenerator struct {
+	doc *OpenAPI
+	out *asyncAPIDocument
+	// channels and operations are ids in use.
+	channels, operations map[string]bool
+}
+
+// Returns a unique id derived from name that is not in taken and adds it.
+func asyncAPIID(name string, taken map[string]bool) string {
+	var result = uniqueName(lowerIdent(name), taken)
+	taken[result] = true
+	return result
+}
+
+// Adds channels of webhooks in name order.
+func (g *asyncAPIGenerator) webhooks() error {
+	var names = make([]string, 0, len(g.doc.WebHooks))
+	for name := range g.doc.WebHooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var item *PathItem
+		if err := g.doc.resolve(g.doc.WebHooks[name], &item); err != nil {
+			return fmt.Errorf("webhook '%s': %w", name, err)
+		}
+		if item == nil {
+			continue
+		}
+		if err := g.channel(name, "", item); err != nil {
+			return fmt.Errorf("webhook '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Adds channels of callbacks of operations in path and Methods order.
+func (g *asyncAPIGenerator) callbacks() error {
+	if g.doc.Paths == nil {
+		return nil
+	}
+	for _, path := range g.doc.Paths.Keys() {
+		var item = g.doc.Paths.Items[path]
+		for _, method := range Methods {
+			var op = item.Operation(method)
+			if op == nil || len(op.Callbacks) == 0 {
+				continue
+			}
+			var parent = op.OperationID
+			if parent == "" {
+				parent = operationName(method, path)
+			}
+			var names = make([]string, 0, len(op.Callbacks))
+			for name := range op.Callbacks {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				var cb *Callback
+				if err := g.doc.resolve(op.Callbacks[name], &cb); err != nil {
+					return fmt.Errorf("%s %s: callback '%s': %w", method, path, name, err)
+				}
+				if cb == nil {
+					continue
+				}
+				for _, expr := range cb.Keys() {
+					if err := g.channel(parent+" "+name, expr, cb.Expressions[expr]); err != nil {
+						return fmt.Errorf("%s %s: callback '%s': %w", method, path, name, err)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Adds a channel named name of path item with operations of its methods.
+// expr is the runtime expression of a callback channel.
+func (g *asyncAPIGenerator) channel(name, expr string, item *PathItem) error {
+	var (
+		id = asyncAPIID(name, g.channels)
+		ch = &asyncAPIChannel{
+			Summary:     item.Summary,
+			Description: item.Description,
+			Messages:    make(map[string]*asyncAPIMessage),
+		}
+	)
+	if expr != "" {
+		var text = fmt.Sprintf("Address is the value of runtime expression `%s`.", expr)
+		if ch.Description != "" {
+			text = ch.Description + "\n\n" + text
+		}
+		ch.Description = text
+	}
+	g.out.Channels[id] = ch
+	for _, method := range Methods {
+		var op = item.Operation(method)
+		if op == nil {
+			continue
+		}
+		var opName = op.OperationID
+		if opName == "" {
+			opName = name + " " + strings.ToLower(method)
+		}
+		var aop = &asyncAPIOperation{
+			Action:      "send",
+			Channel:     &asyncAPIRef{Ref: "#/channels/" + escapePointer(id)},
+			Summary:     op.Summary,
+			Description: op.Description,
+			Messages:    []*asyncAPIRef{},
+		}
+		for _, tag := range op.Tags {
+			aop.Tags = append(aop.Tags, &asyncAPITag{Name: tag})
+		}
+		var messages, err = g.messages(item, op)
+		if err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+		var opID = asyncAPIID(opName, g.operations)
+		for _, msg := range messages {
+			var key = uniqueName(opID, messageKeys(ch.Messages))
+			msg.Name = key
+			ch.Messages[key] = msg
+			aop.Messages = append(aop.Messages, &asyncAPIRef{Ref: aop.Channel.Ref + "/messages/" + escapePointer(key)})
+		}
+		g.out.Operations[opID] = aop
+	}
+	return nil
+}
+
+// Returns names of messages as a set.
+func messageKeys(messages map[string]*asyncAPIMessage) map[string]bool {
+	var result = make(map[string]bool, len(messages))
+	for key := range messages {
+		result[key] = true
+	}
+	return result
+}
+
+// Returns messages of op of item, one per request body media type.
+func (g *asyncAPIGenerator) messages(item *PathItem, op *Operation) ([]*asyncAPIMessage, error) {
+	var params, err = g.doc.operationParameters(item, op)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		properties = make(map[string]interface{})
+		required   []string
+		headers    *asyncAPISchema
+	)
+	for _, p := range params {
+		if p.In != "header" {
+			continue
+		}
+		var schema = p.Schema
+		if schema == nil {
+			schema = &Schema{Type: TypeString}
+		}
+		properties[p.Name] = schema
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	if len(properties) > 0 {
+		var h = map[string]interface{}{"type": TypeObject, "properties": properties}
+		if len(required) > 0 {
+			h["required"] = required
+		}
+		if headers, err = asyncAPIOpenAPISchema(h); err != nil {
+			return nil, err
+		}
+	}
+	var rb *RequestBody
+	if err := g.doc.resolve(op.RequestBody, &rb); err != nil {
+		return nil, err
+	}
+	if rb == nil || len(rb.Content) == 0 {
+		return []*asyncAPIMessage{{Summary: op.Summary, Headers: headers}}, nil
+	}
+	var result []*asyncAPIMessage
+	for _, ct := range sortedContentKeys(rb.Content) {
+		var msg = &asyncAPIMessage{
+			ContentType: ct,
+			Summary:     op.Summary,
+			Description: rb.Description,
+			Headers:     headers,
+		}
+		if mt := rb.Content[ct]; mt != nil && mt.Schema != nil {
+			var err error
+			if msg.Payload, err = asyncAPIOpenAPISchema(mt.Schema); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, msg)
+	}
+	return result, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGenerateAsyncAPI(t *testing.T) {
+	var doc, err = FromJSON([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Pets", "version": "1.0"},
+		"paths": {
+			"/subscriptions": {
+				"post": {
+					"operationId": "subscribe",
+					"responses": {"201": {"description": "Subscribed"}},
+					"callbacks": {
+						"onEvent": {
+							"{$request.body#/callbackUrl}": {
+								"post": {
+									"parameters": [{"name": "X-Signature", "in": "header", "required": true, "schema": {"type": "string"}}],
+									"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Event"}}}},
+									"responses": {"200": {"description": "OK"}}
+								}
+							}
+						}
+					}
+				}
+			}
+		},
+		"webhooks": {
+			"newPet": {
+				"post": {
+					"operationId": "petCreated",
+					"tags": ["pets"],
+					"requestBody": {"content": {"application/json": {"schema": {"type": "object"}}, "text/plain": {}}},
+					"responses": {"200": {"description": "OK"}}
+				}
+			}
+		},
+		"components": {"schemas": {"Event": {"type": "object"}}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := GenerateAsyncAPI(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err = json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	var checks = []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"channels", out["channels"], `{` +
+			`"newPet":{"address":null,"messages":{` +
+			`"petCreated":{"contentType":"application/json","name":"petCreated","payload":{"schema":{"type":"object"},"schemaFormat":"application/vnd.oai.openapi;version=3.1.0"}},` +
+			`"petCreated2":{"contentType":"text/plain","name":"petCreated2"}}},` +
+			`"subscribeOnEvent":{"address":null,` +
+			`"description":"Address is the value of runtime expression ` + "`{$request.body#/callbackUrl}`" + `.",` +
+			`"messages":{"subscribeOnEventPost":{"contentType":"application/json",` +
+			`"headers":{"schema":{"properties":{"X-Signature":{"type":"string"}},"required":["X-Signature"],"type":"object"},"schemaFormat":"application/vnd.oai.openapi;version=3.1.0"},` +
+			`"name":"subscribeOnEventPost","payload":{"schema":{"$ref":"#/components/schemas/Event/schema"},"schemaFormat":"application/vnd.oai.openapi;version=3.1.0"}}}}}`},
+		{"operations", out["operations"], `{` +
+			`"petCreated":{"action":"send","channel":{"$ref":"#/channels/newPet"},"messages":[` +
+			`{"$ref":"#/channels/newPet/messages/petCreated"},{"$ref":"#/channels/newPet/messages/petCreated2"}],` +
+			`"tags":[{"name":"pets"}]},` +
+			`"subscribeOnEventPost":{"action":"send","channel":{"$ref":"#/channels/subscribeOnEvent"},` +
+			`"messages":[{"$ref":"#/channels/subscribeOnEvent/messages/subscribeOnEventPost"}]}}`},
+		{"components", out["components"], `{"schemas":{"Event":{"schema":{"type":"object"},"schemaFormat":"application/vnd.oai.openapi;version=3.1.0"}}}`},
+	}
+	for _, check := range checks {
+		if got := jsonString(t, check.v); got != check.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", check.name, got, check.want)
+		}
+	}
+}
@@ -0,0 +1,748 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ImportBlueprint imports an API Blueprint 1A description as an OpenAPI 3.1
+// document.
+//
+// The HOST metadata is a server and the API name and overview are the
+// document title and description. Resource groups are tags, resources are
+// path items and actions are operations. Parameters are path parameters if
+// they appear as template expressions of the URI template and query
+// parameters otherwise. Requests and responses are request bodies and
+// responses with a media type of each payload: Body is an example, Schema is
+// a JSON schema, Attributes are a schema described in MSON and Headers are
+// header parameters or response headers. Named types of the Data Structures
+// section are component schemas.
+//
+// As API Blueprint is Markdown, notes of unsupported constructs such as
+// relations, resource models and reserved expansions have a Pointer of the
+// form "/line/<n>" to the line they were found on. The document is returned
+// with a note for each of them.
+func ImportBlueprint(data []byte) (*OpenAPI, []*ConversionNote, error) {
+	var text = strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(strings.TrimSpace(text), "FORMAT: 1A") {
+		return nil, nil, fmt.Errorf("blueprint: missing FORMAT: 1A metadata")
+	}
+	var c = &blueprintConverter{
+		lines:   strings.Split(text, "\n"),
+		paths:   make(map[string]interface{}),
+		schemas: make(map[string]interface{}),
+	}
+	c.parse()
+	var out = map[string]interface{}{
+		"openapi": Version,
+		"info":    map[string]interface{}{"title": c.title, "version": ""},
+		"paths":   c.paths,
+	}
+	if c.description != "" {
+		out["info"].(map[string]interface{})["description"] = c.description
+	}
+	if c.host != "" {
+		out["servers"] = []interface{}{map[string]interface{}{"url": c.host}}
+	}
+	if len(c.tags) > 0 {
+		out["tags"] = c.tags
+	}
+	if len(c.schemas) > 0 {
+		out["components"] = map[string]interface{}{"schemas": c.schemas}
+	}
+	var data2, err = json.Marshal(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	var doc *OpenAPI
+	if doc, err = FromJSON(data2); err != nil {
+		return nil, nil, err
+	}
+	return doc, c.notes, nil
+}
+
+// blueprintConverter converts an API Blueprint.
+type blueprintConverter struct {
+	converter
+	lines              []string
+	title, description string
+	host               string
+	tags               []interface{}
+	paths              map[string]interface{}
+	schemas            map[string]interface{}
+	// group is the current resource group and resource the current
+	// resource.
+	group    string
+	resource *blueprintResource
+}
+
+// blueprintResource is a resource of a blueprint.
+type blueprintResource struct {
+	uri    string
+	params []*blueprintNode
+}
+
+// blueprintNode is a list item of a blueprint section with its nested items
+// and content lines.
+type blueprintNode struct {
+	text     string
+	indent   int
+	line     int
+	children []*blueprintNode
+	content  []string
+}
+
+var (
+	blueprintHeading         = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)\s*#*\s*$`)
+	blueprintItem            = regexp.MustCompile(`^(\s*)[+*-]\s+(.*)$`)
+	blueprintResourceHeading = regexp.MustCompile(`^(.*?)\s*\[(/[^\]]*)\]$`)
+	blueprintAction          = regexp.MustCompile(`^(?:(.*?)\s*\[([A-Z]+)(?:\s+(/[^\]]*))?\]|([A-Z]+)(?:\s+(/\S*))?)$`)
+	blueprintPayload         = regexp.MustCompile(`^(Request|Response)\b\s*(.*?)\s*(?:\(([^)]*)\))?$`)
+)
+
+// Records a note about line n.
+func (c *blueprintConverter) noteLine(n int, format string, args ...interface{}) {
+	c.note(fmt.Sprintf("/line/%d", n+1), format, args...)
+}
+
+// Parses the blueprint into the document.
+func (c *blueprintConverter) parse() {
+	var (
+		start     int
+		dataLevel int
+	)
+	for start < len(c.lines) && !blueprintHeading.MatchString(c.lines[start]) {
+		var line = strings.TrimSpace(c.lines[start])
+		if i := strings.Index(line, ":"); i > 0 && strings.ToUpper(line[:i]) == line[:i] {
+			if line[:i] == "HOST" {
+				c.host = strings.TrimSpace(line[i+1:])
+			}
+		}
+		start++
+	}
+	for i := start; i < len(c.lines); {
+		var m = blueprintHeading.FindStringSubmatch(c.lines[i])
+		var end = i + 1
+		var fenced bool
+		for end < len(c.lines) {
+			if strings.HasPrefix(strings.TrimSpace(c.lines[end]), "```") {
+				fenced = !fenced
+			}
+			if !fenced && blueprintHeading.MatchString(c.lines[end]) {
+				break
+			}
+			end++
+		}
+		var (
+			level            = len(m[1])
+			heading          = m[2]
+			description, top = c.section(i+1, end)
+		)
+		switch {
+		case dataLevel > 0 && level > dataLevel:
+			c.dataStructure(i, heading, top)
+		case c.title == "":
+			c.title, c.description = heading, description
+		case heading == "Data Structures":
+			dataLevel = level
+		case strings.HasPrefix(heading, "Group "):
+			dataLevel = 0
+			c.group, c.resource = strings.TrimSpace(strings.TrimPrefix(heading, "Group ")), nil
+			var tag = map[string]interface{}{"name": c.group}
+			if description != "" {
+				tag["description"] = description
+			}
+			c.tags = append(c.tags, tag)
+		case blueprintAction.MatchString(heading) && c.isAction(heading):
+			dataLevel = 0
+			c.action(i, heading, description, top)
+		case blueprintResourceHeading.MatchString(heading) || strings.HasPrefix(heading, "/"):
+			dataLevel = 0
+			var uri = heading
+			if rm := blueprintResourceHeading.FindStringSubmatch(heading); rm != nil {
+				uri = rm[2]
+			}
+			c.resource = &blueprintResource{uri: uri}
+			for _, node := range top {
+				switch {
+				case node.text == "Parameters":
+					c.resource.params = append(c.resource.params, node.children...)
+				default:
+					c.noteLine(node.line, "resource section '%s' not supported; dropped", node.text)
+				}
+			}
+		default:
+			c.noteLine(i, "section '%s' not supported; dropped", heading)
+		}
+		i = end
+	}
+}
+
+// Returns true if heading names an action of an HTTP method.
+func (c *blueprintConverter) isAction(heading string) bool {
+	var m = blueprintAction.FindStringSubmatch(heading)
+	for _, method := range Methods {
+		if m[2] == method || m[4] == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the description and list items of section lines from start to
+// end.
+func (c *blueprintConverter) section(start, end int) (string, []*blueprintNode) {
+	var (
+		description []string
+		top         []*blueprintNode
+		stack       []*blueprintNode
+	)
+	for n := start; n < end; n++ {
+		var line = c.lines[n]
+		var indent = len(line) - len(strings.TrimLeft(line, " \t"))
+		if m := blueprintItem.FindStringSubmatch(line); m != nil {
+			var node = &blueprintNode{text: strings.TrimSpace(m[2]), indent: len(m[1]), line: n}
+			for len(stack) > 0 && stack[len(stack)-1].indent >= node.indent {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == 0 {
+				top = append(top, node)
+			} else {
+				var parent = stack[len(stack)-1]
+				if node.indent-parent.indent >= 8 && len(parent.children) == 0 {
+					// An indented code block of the parent.
+					parent.content = append(parent.content, line)
+					continue
+				}
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			if len(stack) > 0 {
+				stack[len(stack)-1].content = append(stack[len(stack)-1].content, "")
+			} else if len(top) == 0 {
+				description = append(description, "")
+			}
+			continue
+		}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			if len(top) == 0 {
+				description = append(description, strings.TrimSpace(line))
+			}
+			continue
+		}
+		stack[len(stack)-1].content = append(stack[len(stack)-1].content, line)
+	}
+	return strings.TrimSpace(strings.Join(description, "\n")), top
+}
+
+// Returns content lines of node with common indentation and fences removed.
+func (node *blueprintNode) body() string {
+	var (
+		lines  []string
+		indent = -1
+	)
+	for _, line := range node.content {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			continue
+		}
+		lines = append(lines, line)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := len(line) - len(strings.TrimLeft(line, " \t")); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[i] = line[indent:]
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Converts action at line n with heading, description and list items top.
+func (c *blueprintConverter) action(n int, heading, description string, top []*blueprintNode) {
+	var (
+		m      = blueprintAction.FindStringSubmatch(heading)
+		name   = m[1]
+		method = m[2] + m[4]
+		uri    = m[3] + m[5]
+		params []*blueprintNode
+	)
+	if c.resource != nil {
+		params = append(params, c.resource.params...)
+		if uri == "" {
+			uri = c.resource.uri
+		}
+	}
+	if uri == "" {
+		c.noteLine(n, "action without a resource dropped")
+		return
+	}
+	var path, query = c.uriTemplate(n, uri)
+	var op = map[string]interface{}{}
+	if name != "" {
+		op["summary"] = name
+	}
+	if description != "" {
+		op["description"] = description
+	}
+	if c.group != "" {
+		op["tags"] = []interface{}{c.group}
+	}
+	var (
+		responses  = make(map[string]interface{})
+		content    = make(map[string]interface{})
+		parameters []interface{}
+		headers    = make(map[string]bool)
+	)
+	for _, node := range top {
+		if node.text == "Parameters" {
+			params = append(params, node.children...)
+		}
+	}
+	var byName = make(map[string]map[string]interface{})
+	var order []string
+	for _, node := range params {
+		var p = c.parameter(node, path, query)
+		if _, exists := byName[p["name"].(string)]; !exists {
+			order = append(order, p["name"].(string))
+		}
+		byName[p["name"].(string)] = p
+	}
+	for _, name := range query {
+		if _, exists := byName[name]; !exists {
+			order = append(order, name)
+			byName[name] = map[string]interface{}{"name": name, "in": "query", "schema": map[string]interface{}{"type": TypeString}}
+		}
+	}
+	for _, name := range order {
+		parameters = append(parameters, byName[name])
+	}
+	for _, node := range top {
+		var pm = blueprintPayload.FindStringSubmatch(node.text)
+		switch {
+		case node.text == "Parameters":
+		case pm != nil && pm[1] == "Request":
+			var ct, mt, hs = c.payload(node, pm[3])
+			for _, h := range hs {
+				if !headers[h["name"].(string)] {
+					headers[h["name"].(string)] = true
+					parameters = append(parameters, h)
+				}
+			}
+			if _, exists := content[ct]; exists {
+				c.noteLine(node.line, "additional request of %s dropped", ct)
+			} else if mt != nil {
+				content[ct] = mt
+			}
+		case pm != nil && pm[1] == "Response":
+			var code = strings.Fields(pm[2] + " 200")[0]
+			var resp = rawObject(responses[code])
+			if resp == nil {
+				resp = map[string]interface{}{"description": "Response"}
+				if status, err := strconv.Atoi(code); err == nil && http.StatusText(status) != "" {
+					resp["description"] = http.StatusText(status)
+				}
+				responses[code] = resp
+			}
+			var ct, mt, hs = c.payload(node, pm[3])
+			if len(hs) > 0 {
+				var rh = make(map[string]interface{})
+				for _, h := range hs {
+					rh[h["name"].(string)] = map[string]interface{}{"schema": h["schema"]}
+				}
+				resp["headers"] = rh
+			}
+			if mt == nil {
+				continue
+			}
+			var rc = rawObject(resp["content"])
+			if rc == nil {
+				rc = make(map[string]interface{})
+				resp["content"] = rc
+			}
+			if _, exists := rc[ct]; exists {
+				c.noteLine(node.line, "additional response %s of %s dropped", code, ct)
+				continue
+			}
+			rc[ct] = mt
+		default:
+			c.noteLine(node.line, "action section '%s' not supported; dropped", node.text)
+		}
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+	if len(content) > 0 {
+		op["requestBody"] = map[string]interface{}{"content": content}
+	}
+	if len(responses) == 0 {
+		responses["default"] = map[string]interface{}{"description": "Default response"}
+	}
+	op["responses"] = responses
+	var item = rawObject(c.paths[path])
+	if item == nil {
+		item = make(map[string]interface{})
+		c.paths[path] = item
+	}
+	var key = strings.ToLower(method)
+	if _, exists := item[key]; exists {
+		c.noteLine(n, "duplicate action %s %s dropped", method, path)
+		return
+	}
+	item[key] = op
+}
+
+// Returns the path of uri template at line n and names of its query
+// parameters.
+func (c *blueprintConverter) uriTemplate(n int, uri string) (string, []string) {
+	var (
+		path  strings.Builder
+		query []string
+	)
+	for _, part := range regexp.MustCompile(`\{[^}]*\}|[^{]+`).FindAllString(uri, -1) {
+		if !strings.HasPrefix(part, "{") {
+			path.WriteString(part)
+			continue
+		}
+		var expr = strings.Trim(part, "{}")
+		switch {
+		case strings.HasPrefix(expr, "?") || strings.HasPrefix(expr, "&"):
+			for _, name := range strings.Split(expr[1:], ",") {
+				query = append(query, strings.TrimSuffix(strings.TrimSpace(name), "*"))
+			}
+		case strings.HasPrefix(expr, "+") || strings.HasPrefix(expr, "#"):
+			c.noteLine(n, "expansion %s rewritten as a simple expression", part)
+			path.WriteString("{" + expr[1:] + "}")
+		default:
+			path.WriteString(part)
+		}
+	}
+	return path.String(), query
+}
+
+// Returns the parameter described by node of an action with path and query
+// parameter names.
+func (c *blueprintConverter) parameter(node *blueprintNode, path string, query []string) map[string]interface{} {
+	var (
+		a      = parseMSON(node.text)
+		schema = c.msonSchema(node, a)
+		p      = map[string]interface{}{"name": a.name, "in": "query", "schema": schema}
+	)
+	// Description and example are parameter fields.
+	delete(rawObject(schema), "description")
+	delete(rawObject(schema), "examples")
+	if strings.Contains(path, "{"+a.name+"}") {
+		p["in"] = "path"
+	}
+	if p["in"] == "path" || !a.has("optional") {
+		p["required"] = true
+	}
+	if a.description != "" {
+		p["description"] = a.description
+	}
+	if a.value != "" {
+		p["example"] = msonValue(a.value, rawObject(schema)["type"])
+	}
+	return p
+}
+
+// msonAttribute is an MSON property or parameter declaration of the form
+// "name: value (type, attributes) - description".
+type msonAttribute struct {
+	name, value, description string
+	attributes               []string
+}
+
+// Returns true if a has attribute name.
+func (a *msonAttribute) has(name string) bool {
+	for _, attr := range a.attributes {
+		if attr == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the declared type of a or an empty string.
+func (a *msonAttribute) typeName() string {
+	for _, attr := range a.attributes {
+		switch attr {
+		case "required", "optional", "fixed", "fixed-type", "nullable", "sample", "default":
+		default:
+			return attr
+		}
+	}
+	return ""
+}
+
+// Parses MSON declaration text.
+func parseMSON(text string) *msonAttribute {
+	var a = &msonAttribute{}
+	if i := strings.Index(text, " - "); i >= 0 {
+		text, a.description = text[:i], strings.TrimSpace(text[i+3:])
+	}
+	text = strings.TrimSpace(text)
+	if strings.HasSuffix(text, ")") {
+		if i := strings.LastIndex(text, "("); i >= 0 {
+			for _, attr := range strings.Split(text[i+1:len(text)-1], ",") {
+				a.attributes = append(a.attributes, strings.TrimSpace(attr))
+			}
+			text = strings.TrimSpace(text[:i])
+		}
+	}
+	var name = text
+	if i := strings.Index(text, ":"); i >= 0 && !strings.HasPrefix(text, "`") || strings.HasPrefix(text, "`") && strings.Contains(text, "`:") {
+		if strings.HasPrefix(text, "`") {
+			i = strings.Index(text, "`:") + 1
+		}
+		name, a.value = text[:i], strings.Trim(strings.TrimSpace(text[i+1:]), "`")
+	}
+	a.name = strings.Trim(strings.TrimSpace(name), "`*")
+	return a
+}
+
+// msonTypes are JSON Schema types of MSON base types.
+var msonTypes = map[string]string{
+	"string": TypeString, "number": TypeNumber, "boolean": TypeBoolean,
+	"object": TypeObject, "array": TypeArray, "enum": "",
+}
+
+// Returns sample value v of an MSON declaration converted to JSON type t.
+func msonValue(v string, t interface{}) interface{} {
+	switch t {
+	case TypeNumber:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	case TypeBoolean:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// Returns the schema of MSON type name.
+func msonTypeSchema(name string) map[string]interface{} {
+	if strings.HasSuffix(name, "]") {
+		if i := strings.Index(name, "["); i > 0 {
+			var (
+				base  = name[:i]
+				inner = name[i+1 : len(name)-1]
+			)
+			switch base {
+			case "array":
+				var s = map[string]interface{}{"type": TypeArray}
+				if inner != "" {
+					s["items"] = msonTypeSchema(inner)
+				}
+				return s
+			case "enum":
+				return msonTypeSchema(inner)
+			}
+		}
+	}
+	if t, ok := msonTypes[name]; ok {
+		if t == "" {
+			return map[string]interface{}{}
+		}
+		return map[string]interface{}{"type": t}
+	}
+	return map[string]interface{}{"$ref": componentsPrefix + "schemas/" + escapePointer(name)}
+}
+
+// Returns the schema of MSON declaration a of node with its nested members.
+func (c *blueprintConverter) msonSchema(node *blueprintNode, a *msonAttribute) interface{} {
+	var typeName = a.typeName()
+	if typeName == "" {
+		typeName = "string"
+		if len(node.children) > 0 && a.value == "" {
+			typeName = "object"
+		}
+	}
+	var (
+		s    = msonTypeSchema(typeName)
+		base = strings.SplitN(typeName, "[", 2)[0]
+	)
+	if a.value != "" {
+		switch base {
+		case "array":
+			var items []interface{}
+			for _, item := range strings.Split(a.value, ",") {
+				items = append(items, strings.TrimSpace(item))
+			}
+			s["examples"] = []interface{}{items}
+		default:
+			s["examples"] = []interface{}{msonValue(a.value, s["type"])}
+		}
+	}
+	if a.description != "" {
+		s["description"] = a.description
+	}
+	c.msonMembers(node.children, base, s)
+	if a.has("nullable") && s["type"] != nil {
+		s["type"] = []interface{}{s["type"], TypeNull}
+	}
+	if ref, ok := s["$ref"]; ok && len(s) > 1 {
+		delete(s, "$ref")
+		return map[string]interface{}{"allOf": []interface{}{map[string]interface{}{"$ref": ref}, s}}
+	}
+	return s
+}
+
+// Adds members described by nodes to schema s of MSON base type base.
+func (c *blueprintConverter) msonMembers(nodes []*blueprintNode, base string, s map[string]interface{}) {
+	var (
+		properties = rawObject(s["properties"])
+		required   = rawList(s["required"])
+		enum       []interface{}
+		items      []interface{}
+	)
+	for _, node := range nodes {
+		switch {
+		case node.text == "Members" || node.text == "Items" || node.text == "Properties":
+			c.msonMembers(node.children, base, s)
+			properties, required = rawObject(s["properties"]), rawList(s["required"])
+			continue
+		case strings.HasPrefix(node.text, "Default:"):
+			s["default"] = msonValue(strings.Trim(strings.TrimSpace(strings.TrimPrefix(node.text, "Default:")), "`"), s["type"])
+			continue
+		case strings.HasPrefix(node.text, "Sample:"):
+			s["examples"] = []interface{}{msonValue(strings.Trim(strings.TrimSpace(strings.TrimPrefix(node.text, "Sample:")), "`"), s["type"])}
+			continue
+		case strings.HasPrefix(node.text, "Include "):
+			c.noteLine(node.line, "mixin '%s' not supported; dropped", node.text)
+			continue
+		}
+		var a = parseMSON(node.text)
+		switch base {
+		case "enum":
+			enum = append(enum, msonValue(a.name, s["type"]))
+		case "array":
+			items = append(items, c.msonSchema(node, a))
+		default:
+			if properties == nil {
+				properties = make(map[string]interface{})
+			}
+			properties[a.name] = c.msonSchema(node, a)
+			if a.has("required") {
+				required = append(required, a.name)
+			}
+		}
+	}
+	if len(enum) > 0 {
+		s["enum"] = enum
+	}
+	switch len(items) {
+	case 0:
+	case 1:
+		s["items"] = items[0]
+	default:
+		s["items"] = map[string]interface{}{"anyOf": items}
+	}
+	if len(properties) > 0 {
+		s["properties"] = properties
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+}
+
+// Converts named type heading of the Data Structures section at line n
+// with members top.
+func (c *blueprintConverter) dataStructure(n int, heading string, top []*blueprintNode) {
+	var (
+		a    = parseMSON(heading)
+		node = &blueprintNode{text: heading, line: n, children: top}
+	)
+	if a.name == "" {
+		c.noteLine(n, "data structure without a name dropped")
+		return
+	}
+	if a.typeName() == "" {
+		a.attributes = append(a.attributes, "object")
+	}
+	c.schemas[a.name] = c.msonSchema(node, a)
+}
+
+// Returns the media type, Media Type Object and header parameters of
+// request or response node with media type ct.
+func (c *blueprintConverter) payload(node *blueprintNode, ct string) (string, map[string]interface{}, []map[string]interface{}) {
+	var (
+		mt      = make(map[string]interface{})
+		headers []map[string]interface{}
+		body    string
+	)
+	for _, child := range node.children {
+		switch {
+		case child.text == "Headers":
+			for _, line := range strings.Split(child.body(), "\n") {
+				var i = strings.Index(line, ":")
+				if i <= 0 {
+					continue
+				}
+				var name, value = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+				if http.CanonicalHeaderKey(name) == "Content-Type" {
+					if ct == "" {
+						ct = value
+					}
+					continue
+				}
+				headers = append(headers, map[string]interface{}{
+					"name": name, "in": "header", "example": value,
+					"schema": map[string]interface{}{"type": TypeString},
+				})
+			}
+		case child.text == "Body":
+			body = child.body()
+		case child.text == "Schema":
+			var schema map[string]interface{}
+			if err := json.Unmarshal([]byte(child.body()), &schema); err != nil {
+				c.noteLine(child.line, "invalid JSON schema dropped: %v", err)
+				continue
+			}
+			delete(schema, "$schema")
+			mt["schema"] = schema
+		case strings.HasPrefix(child.text, "Attributes"):
+			var a = parseMSON(child.text)
+			mt["schema"] = c.msonSchema(child, a)
+		default:
+			c.noteLine(child.line, "payload section '%s' not supported; dropped", child.text)
+		}
+	}
+	if len(node.children) == 0 {
+		body = node.body()
+	}
+	if ct == "" {
+		ct = "application/json"
+		if body == "" && mt["schema"] == nil {
+			return ct, nil, headers
+		}
+	}
+	if body != "" {
+		var v interface{}
+		if isJSONMediaType(ct) && json.Unmarshal([]byte(body), &v) == nil {
+			mt["example"] = v
+		} else {
+			mt["example"] = body
+		}
+	}
+	return ct, mt, headers
+}
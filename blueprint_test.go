@@ -0,0 +1,103 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import "testing"
+
+func TestImportBlueprint(t *testing.T) {
+	var doc, warnings, err = ImportBlueprint([]byte(`FORMAT: 1A
+HOST: https://api.example.com/v1
+
+# Pets
+
+A pet store.
+
+# Group Pets
+
+Pet resources.
+
+## Pet Collection [/pets{?limit}]
+
++ Parameters
+    + limit: ` + "`10`" + ` (number, optional) - Page size
+        + Default: ` + "`20`" + `
+
+### List Pets [GET]
+
++ Response 200 (application/json)
+    + Attributes (array[Pet])
+
+### Create a Pet [POST]
+
++ Request (application/json)
+    + Headers
+
+            X-Request-Id: abc
+
+    + Body
+
+            {"name": "Rex"}
+
++ Response 201
+
++ Relation: create
+
+## Pet [/pets/{petId}]
+
++ Parameters
+    + petId: ` + "`1`" + ` (number) - Pet id
+
+### Delete a Pet [DELETE]
+
++ Response 204
+
+# Data Structures
+
+## Pet (object)
+
++ name: Rex (string, required) - Pet name
++ kind (enum[string])
+    + Members
+        + cat
+        + dog
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var checks = []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"info", doc.Info, `{"title":"Pets","description":"A pet store."}`},
+		{"servers", doc.Servers, `[{"url":"https://api.example.com/v1"}]`},
+		{"tags", doc.Tags, `[{"name":"Pets","description":"Pet resources."}]`},
+		{"list", doc.Paths.Items["/pets"].Get, `{"tags":["Pets"],"summary":"List Pets",` +
+			`"parameters":[{"description":"Page size","example":10,"in":"query","name":"limit","schema":{"default":20,"type":"number"}}],` +
+			`"responses":{"200":{"content":{"application/json":{"schema":{"items":{"$ref":"#/components/schemas/Pet"},"type":"array"}}},"description":"OK"}}}`},
+		{"create", doc.Paths.Items["/pets"].Post, `{"tags":["Pets"],"summary":"Create a Pet",` +
+			`"parameters":[{"description":"Page size","example":10,"in":"query","name":"limit","schema":{"default":20,"type":"number"}},` +
+			`{"example":"abc","in":"header","name":"X-Request-Id","schema":{"type":"string"}}],` +
+			`"requestBody":{"content":{"application/json":{"example":{"name":"Rex"}}}},"responses":{"201":{"description":"Created"}}}`},
+		{"delete", doc.Paths.Items["/pets/{petId}"].Delete, `{"tags":["Pets"],"summary":"Delete a Pet",` +
+			`"parameters":[{"description":"Pet id","example":1,"in":"path","name":"petId","required":true,"schema":{"type":"number"}}],` +
+			`"responses":{"204":{"description":"No Content"}}}`},
+		{"Pet", doc.Components.Schemas["Pet"], `{"properties":{"kind":{"enum":["cat","dog"],"type":"string"},` +
+			`"name":{"description":"Pet name","examples":["Rex"],"type":"string"}},"required":["name"],"type":"object"}`},
+	}
+	for _, check := range checks {
+		if got := jsonString(t, check.v); got != check.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", check.name, got, check.want)
+		}
+	}
+	var report []string
+	for _, note := range warnings {
+		report = append(report, note.String())
+	}
+	var want = `["#/line/36: action section 'Relation: create' not supported; dropped"]`
+	if got := jsonString(t, report); got != want {
+		t.Errorf("report:\ngot  %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,610 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Version is the OpenAPI Specification version of documents produced by
+// Builder.
+const Version = "3.1.0"
+
+// ErrDuplicateOperationID is returned by Builder when an operation id is
+// used more than once.
+var ErrDuplicateOperationID = errors.New("duplicate operation id")
+
+// Builder builds an OpenAPI document using a fluent API:
+//
+//	doc, err := openapi.New("Pet Store", "1.0").
+//		Server("https://example.com/v1", "Production").
+//		Path("/pets/{id}").
+//		Get(func(op *openapi.OperationBuilder) {
+//			op.Summary("Returns a pet").Response(200, "A pet", Pet{})
+//		}).
+//		Build()
+//
+// Schemas of Go values are generated using SchemaFromType and registered
+// once in Components. Path parameters are created for template expressions
+// in paths that are not defined explicitly and a unique operation id is
+// assigned to each operation that does not define one.
+type Builder struct {
+	doc     *OpenAPI
+	schemas *SchemaOptions
+	err     error
+}
+
+// Returns a new Builder of a document with the title and version.
+func New(title, version string) *Builder {
+	var c = &Components{}
+	return &Builder{
+		doc: &OpenAPI{
+			OpenAPI:    Version,
+			Info:       &Info{Title: title, Version: version},
+			Paths:      &Paths{Items: make(map[string]*PathItem)},
+			Components: c,
+		},
+		schemas: &SchemaOptions{Components: c},
+	}
+}
+
+// Records the first error.
+func (b *Builder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Sets the API summary.
+func (b *Builder) Summary(summary string) *Builder {
+	b.doc.Info.Summary = summary
+	return b
+}
+
+// Sets the API description.
+func (b *Builder) Description(description string) *Builder {
+	b.doc.Info.Description = description
+	return b
+}
+
+// Sets the API contact.
+func (b *Builder) Contact(name, url, email string) *Builder {
+	b.doc.Info.Contact = &Contact{Name: name, URL: url, EMail: email}
+	return b
+}
+
+// Sets the API license.
+func (b *Builder) License(name, url string) *Builder {
+	b.doc.Info.License = &License{Name: name, URL: url}
+	return b
+}
+
+// Adds a server with optional variables in name, default pairs.
+func (b *Builder) Server(url, description string, variables ...string) *Builder {
+	var server = &Server{URL: url, Description: description}
+	if len(variables)%2 != 0 {
+		b.fail(fmt.Errorf("server '%s': odd number of variable arguments", url))
+		return b
+	}
+	for i := 0; i < len(variables); i += 2 {
+		if server.Variables == nil {
+			server.Variables = make(map[string]*ServerVariable)
+		}
+		server.Variables[variables[i]] = &ServerVariable{Default: variables[i+1]}
+	}
+	b.doc.Servers = append(b.doc.Servers, server)
+	return b
+}
+
+// Adds a tag.
+func (b *Builder) Tag(name, description string) *Builder {
+	b.doc.Tags = append(b.doc.Tags, &Tag{Name: name, Description: description})
+	return b
+}
+
+// Defines a security scheme in Components.
+func (b *Builder) SecurityScheme(name string, scheme *SecurityScheme) *Builder {
+	var c = b.schemas.Components
+	if c.SecuritySchemes == nil {
+		c.SecuritySchemes = make(map[string]interface{})
+	}
+	c.SecuritySchemes[name] = scheme
+	return b
+}
+
+// Adds a document level security requirement of a single scheme.
+func (b *Builder) Security(scheme string, scopes ...string) *Builder {
+	b.doc.Security = append(b.doc.Security, securityRequirement(scheme, scopes))
+	return b
+}
+
+// Returns a requirement of a single scheme.
+func securityRequirement(scheme string, scopes []string) *SecurityRequirement {
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return &SecurityRequirement{Schemes: map[string][]string{scheme: scopes}}
+}
+
+// Registers a schema in Components under name. v may be a *Schema or a Go
+// value whose type is converted using SchemaFromType.
+func (b *Builder) Schema(name string, v interface{}) *Builder {
+	var c = b.schemas.Components
+	if c.Schemas == nil {
+		c.Schemas = make(map[string]interface{})
+	}
+	if s, ok := v.(*Schema); ok {
+		c.Schemas[name] = s
+		return b
+	}
+	var t = reflect.TypeOf(v)
+	if t == nil {
+		b.fail(fmt.Errorf("schema '%s': nil value", name))
+		return b
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if _, exists := b.schemas.types[t]; exists {
+		b.fail(fmt.Errorf("schema '%s': type %s already registered", name, t))
+		return b
+	}
+	var namer = b.schemas.Namer
+	b.schemas.Namer = func(rt reflect.Type) string {
+		if rt == t {
+			return name
+		}
+		if namer != nil {
+			return namer(rt)
+		}
+		return rt.Name()
+	}
+	defer func() { b.schemas.Namer = namer }()
+	var s, err = SchemaFromType(t, b.schemas)
+	if err != nil {
+		b.fail(err)
+		return b
+	}
+	if s.Ref == "" {
+		c.Schemas[name] = s
+	}
+	return b
+}
+
+// Returns a schema for v which may be nil, a *Schema or a Go value.
+func (b *Builder) schemaOf(v interface{}) *Schema {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case *Schema:
+		return t
+	}
+	var s, err = SchemaFromType(reflect.TypeOf(v), b.schemas)
+	if err != nil {
+		b.fail(err)
+	}
+	return s
+}
+
+// Returns a PathBuilder for path, creating the PathItem if it does not exist.
+func (b *Builder) Path(path string) *PathBuilder {
+	var item, ok = b.doc.Paths.Items[path]
+	if !ok {
+		item = &PathItem{}
+		b.doc.Paths.Items[path] = item
+	}
+	if !strings.HasPrefix(path, "/") {
+		b.fail(fmt.Errorf("path '%s' must begin with a '/'", path))
+	}
+	return &PathBuilder{b: b, path: path, item: item}
+}
+
+// Returns the built document or the first error that occurred.
+func (b *Builder) Build() (*OpenAPI, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.doc.Info.Title == "" || b.doc.Info.Version == "" {
+		return nil, errors.New("title and version are required")
+	}
+	var ids = make(map[string]bool)
+	for _, path := range b.doc.Paths.Keys() {
+		var item = b.doc.Paths.Items[path]
+		for _, method := range Methods {
+			var op = item.Operation(method)
+			if op == nil || op.OperationID == "" {
+				continue
+			}
+			if ids[op.OperationID] {
+				return nil, fmt.Errorf("%w: '%s'", ErrDuplicateOperationID, op.OperationID)
+			}
+			ids[op.OperationID] = true
+		}
+	}
+	for _, path := range b.doc.Paths.Keys() {
+		var item = b.doc.Paths.Items[path]
+		for _, method := range Methods {
+			var op = item.Operation(method)
+			if op == nil {
+				continue
+			}
+			if op.OperationID == "" {
+				op.OperationID = uniqueName(operationName(method, path), ids)
+				ids[op.OperationID] = true
+			}
+			if op.Responses == nil || (len(op.Responses.Codes) == 0 && op.Responses.Default == nil) {
+				op.Responses = &Responses{Default: &Response{Description: "Default response"}}
+			}
+		}
+		addPathParameters(path, item)
+	}
+	b.doc.Components = b.schemas.Components
+	if reflect.DeepEqual(*b.doc.Components, Components{}) {
+		b.doc.Components = nil
+	}
+	return b.doc, nil
+}
+
+// Returns the built document and panics on error.
+func (b *Builder) MustBuild() *OpenAPI {
+	var doc, err = b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return doc
+}
+
+// Adds path parameters for template expressions of path that are not
+// defined by the path item or all of its operations.
+func addPathParameters(path string, item *PathItem) {
+	for _, m := range templateExpression.FindAllStringSubmatch(path, -1) {
+		var name = m[1]
+		if hasParameter(item.Parameters, name, "path") {
+			continue
+		}
+		var ops = operationsOf(item)
+		var defined = len(ops) > 0
+		for _, op := range ops {
+			if !hasParameter(op.Parameters, name, "path") {
+				defined = false
+			}
+		}
+		if defined {
+			continue
+		}
+		item.Parameters = append(item.Parameters, &Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: TypeString},
+		})
+	}
+}
+
+// Returns defined operations of item in Methods order.
+func operationsOf(item *PathItem) (result []*Operation) {
+	for _, method := range Methods {
+		if op := item.Operation(method); op != nil {
+			result = append(result, op)
+		}
+	}
+	return
+}
+
+// Returns true if params holds a *Parameter with name and location.
+func hasParameter(params []interface{}, name, in string) bool {
+	for _, p := range params {
+		if param, ok := p.(*Parameter); ok && param.Name == name && param.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns an operation name for method and path, e.g. "getPetsById" for
+// GET /pets/{id}.
+func operationName(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	var segments = strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	if len(segments) == 0 {
+		sb.WriteString("Root")
+	}
+	for _, segment := range segments {
+		if m := templateExpression.FindStringSubmatch(segment); m != nil && m[0] == segment {
+			sb.WriteString("By")
+			segment = m[1]
+		}
+		sb.WriteString(exportName(segment))
+	}
+	return sb.String()
+}
+
+// Converts s to an exported Go style identifier, i.e. "pet-id" to "PetId".
+func exportName(s string) string {
+	var (
+		sb    strings.Builder
+		upper = true
+	)
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// Returns name or name suffixed with a number so that it is not in taken.
+func uniqueName(name string, taken map[string]bool) string {
+	if !taken[name] {
+		return name
+	}
+	for i := 2; ; i++ {
+		if n := name + strconv.Itoa(i); !taken[n] {
+			return n
+		}
+	}
+}
+
+// PathBuilder builds a PathItem.
+type PathBuilder struct {
+	b    *Builder
+	path string
+	item *PathItem
+}
+
+// Sets the path summary.
+func (pb *PathBuilder) Summary(summary string) *PathBuilder {
+	pb.item.Summary = summary
+	return pb
+}
+
+// Sets the path description.
+func (pb *PathBuilder) Description(description string) *PathBuilder {
+	pb.item.Description = description
+	return pb
+}
+
+// Adds a parameter applicable to all operations of the path.
+// See OperationBuilder.Param.
+func (pb *PathBuilder) Param(name, in string, v interface{}, description string) *PathBuilder {
+	pb.item.Parameters = append(pb.item.Parameters, pb.b.parameter(name, in, v, description))
+	return pb
+}
+
+// Defines an operation for method built by f.
+func (pb *PathBuilder) Method(method string, f func(op *OperationBuilder)) *PathBuilder {
+	var op = &Operation{}
+	pb.item.SetOperation(method, op)
+	if pb.item.Operation(method) == nil {
+		pb.b.fail(fmt.Errorf("path '%s': unsupported method '%s'", pb.path, method))
+		return pb
+	}
+	if f != nil {
+		f(&OperationBuilder{b: pb.b, op: op})
+	}
+	return pb
+}
+
+// Defines a GET operation built by f.
+func (pb *PathBuilder) Get(f func(op *OperationBuilder)) *PathBuilder {
+	return pb.Method(http.MethodGet, f)
+}
+
+// Defines a PUT operation built by f.
+func (pb *PathBuilder) Put(f func(op *OperationBuilder)) *PathBuilder {
+	return pb.Method(http.MethodPut, f)
+}
+
+// Defines a POST operation built by f.
+func (pb *PathBuilder) Post(f func(op *OperationBuilder)) *PathBuilder {
+	return pb.Method(http.MethodPost, f)
+}
+
+// Defines a DELETE operation built by f.
+func (pb *PathBuilder) Delete(f func(op *OperationBuilder)) *PathBuilder {
+	return pb.Method(http.MethodDelete, f)
+}
+
+// Defines an OPTIONS operation built by f.
+func (pb *PathBuilder) Options(f func(op *OperationBuilder)) *PathBuilder {
+	return pb.Method(http.MethodOptions, f)
+}
+
+// Defines a HEAD operation built by f.
+func (pb *PathBuilder) Head(f func(op *OperationBuilder)) *PathBuilder {
+	return pb.Method(http.MethodHead, f)
+}
+
+// Defines a PATCH operation built by f.
+func (pb *PathBuilder) Patch(f func(op *OperationBuilder)) *PathBuilder {
+	return pb.Method(http.MethodPatch, f)
+}
+
+// Defines a TRACE operation built by f.
+func (pb *PathBuilder) Trace(f func(op *OperationBuilder)) *PathBuilder {
+	return pb.Method(http.MethodTrace, f)
+}
+
+// Returns a PathBuilder for another path. See Builder.Path.
+func (pb *PathBuilder) Path(path string) *PathBuilder {
+	return pb.b.Path(path)
+}
+
+// Returns the document Builder.
+func (pb *PathBuilder) Done() *Builder {
+	return pb.b
+}
+
+// Builds the document. See Builder.Build.
+func (pb *PathBuilder) Build() (*OpenAPI, error) {
+	return pb.b.Build()
+}
+
+// OperationBuilder builds an Operation.
+type OperationBuilder struct {
+	b  *Builder
+	op *Operation
+}
+
+// Returns the Operation being built, for modifications not covered by the
+// builder.
+func (ob *OperationBuilder) Operation() *Operation {
+	return ob.op
+}
+
+// Sets the operation id.
+func (ob *OperationBuilder) ID(id string) *OperationBuilder {
+	ob.op.OperationID = id
+	return ob
+}
+
+// Sets the operation summary.
+func (ob *OperationBuilder) Summary(summary string) *OperationBuilder {
+	ob.op.Summary = summary
+	return ob
+}
+
+// Sets the operation description.
+func (ob *OperationBuilder) Description(description string) *OperationBuilder {
+	ob.op.Description = description
+	return ob
+}
+
+// Adds tags to the operation.
+func (ob *OperationBuilder) Tags(tags ...string) *OperationBuilder {
+	ob.op.Tags = append(ob.op.Tags, tags...)
+	return ob
+}
+
+// Marks the operation deprecated.
+func (ob *OperationBuilder) Deprecated() *OperationBuilder {
+	ob.op.Deprecated = true
+	return ob
+}
+
+// Adds a parameter. in is one of "path", "query", "header" or "cookie". v
+// may be a *Schema or a Go value the schema is generated from. Path
+// parameters are always required.
+func (ob *OperationBuilder) Param(name, in string, v interface{}, description string) *OperationBuilder {
+	ob.op.Parameters = append(ob.op.Parameters, ob.b.parameter(name, in, v, description))
+	return ob
+}
+
+// Adds a required parameter. See Param.
+func (ob *OperationBuilder) RequiredParam(name, in string, v interface{}, description string) *OperationBuilder {
+	var p = ob.b.parameter(name, in, v, description)
+	p.Required = true
+	ob.op.Parameters = append(ob.op.Parameters, p)
+	return ob
+}
+
+// Returns a new parameter.
+func (b *Builder) parameter(name, in string, v interface{}, description string) *Parameter {
+	switch in {
+	case "path", "query", "header", "cookie":
+	default:
+		b.fail(fmt.Errorf("parameter '%s': invalid location '%s'", name, in))
+	}
+	var s = b.schemaOf(v)
+	if s == nil {
+		s = &Schema{Type: TypeString}
+	}
+	return &Parameter{
+		Name:        name,
+		In:          in,
+		Description: description,
+		Required:    in == "path",
+		Schema:      s,
+	}
+}
+
+// Sets the request body of contentType whose schema is v. v may be a *Schema
+// or a Go value the schema is generated from. Multiple calls add content
+// types.
+func (ob *OperationBuilder) Body(contentType string, v interface{}, required bool) *OperationBuilder {
+	var body, _ = ob.op.RequestBody.(*RequestBody)
+	if body == nil {
+		body = &RequestBody{Content: make(map[string]*MediaType)}
+		ob.op.RequestBody = body
+	}
+	body.Required = body.Required || required
+	body.Content[contentType] = &MediaType{Schema: ob.b.schemaOf(v)}
+	return ob
+}
+
+// Sets a required "application/json" request body. See Body.
+func (ob *OperationBuilder) JSONBody(v interface{}) *OperationBuilder {
+	return ob.Body("application/json", v, true)
+}
+
+// Adds a response for status code with an "application/json" body whose
+// schema is v, or no body if v is nil. A code of 0 defines the default
+// response.
+func (ob *OperationBuilder) Response(code int, description string, v interface{}) *OperationBuilder {
+	if v == nil {
+		return ob.ResponseContent(code, description, "", nil)
+	}
+	return ob.ResponseContent(code, description, "application/json", v)
+}
+
+// Adds a response for status code with a body of contentType whose schema is
+// v. If contentType is empty the response has no body. Multiple calls for
+// the same code add content types. A code of 0 defines the default response.
+func (ob *OperationBuilder) ResponseContent(code int, description, contentType string, v interface{}) *OperationBuilder {
+	if ob.op.Responses == nil {
+		ob.op.Responses = &Responses{Codes: make(map[string]interface{})}
+	}
+	var (
+		key     = strconv.Itoa(code)
+		resp, _ = ob.op.Responses.Codes[key].(*Response)
+	)
+	if code == 0 {
+		resp, _ = ob.op.Responses.Default.(*Response)
+	}
+	if resp == nil {
+		resp = &Response{}
+		if code == 0 {
+			ob.op.Responses.Default = resp
+		} else {
+			ob.op.Responses.Codes[key] = resp
+		}
+	}
+	if description == "" {
+		description = http.StatusText(code)
+	}
+	resp.Description = description
+	if contentType != "" {
+		if resp.Content == nil {
+			resp.Content = make(map[string]*MediaType)
+		}
+		resp.Content[contentType] = &MediaType{Schema: ob.b.schemaOf(v)}
+	}
+	return ob
+}
+
+// Adds a security requirement of a single scheme to the operation.
+func (ob *OperationBuilder) Security(scheme string, scopes ...string) *OperationBuilder {
+	ob.op.Security = append(ob.op.Security, securityRequirement(scheme, scopes))
+	return ob
+}
+
+// Removes document level security from the operation.
+func (ob *OperationBuilder) NoSecurity() *OperationBuilder {
+	ob.op.Security = []*SecurityRequirement{}
+	return ob
+}
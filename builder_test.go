@@ -0,0 +1,63 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	type Pet struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	var doc, err = New("Pet Store", "1.0").
+		Server("https://example.com/v1", "Production").
+		Path("/pets").
+		Get(func(op *OperationBuilder) {
+			op.Response(200, "Pets", []Pet{})
+		}).
+		Post(func(op *OperationBuilder) {
+			op.JSONBody(Pet{}).Response(201, "", Pet{})
+		}).
+		Path("/pets/{id}").
+		Get(func(op *OperationBuilder) {
+			op.Response(200, "A pet", Pet{})
+		}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Components.Schemas) != 1 {
+		t.Fatalf("expected one component schema, got %d", len(doc.Components.Schemas))
+	}
+	var ids = map[string]string{}
+	for _, path := range doc.Paths.Keys() {
+		for _, method := range Methods {
+			if op := doc.Paths.Items[path].Operation(method); op != nil {
+				ids[method+" "+path] = op.OperationID
+			}
+		}
+	}
+	if ids["GET /pets"] != "getPets" || ids["POST /pets"] != "postPets" || ids["GET /pets/{id}"] != "getPetsById" {
+		t.Fatalf("unexpected operation ids: %v", ids)
+	}
+	var params = doc.Paths.Items["/pets/{id}"].Parameters
+	if len(params) != 1 || params[0].(*Parameter).Name != "id" || !params[0].(*Parameter).Required {
+		t.Fatalf("expected path parameter 'id', got %v", params)
+	}
+	if _, _, err := NewRouter(doc).FindRoute("GET", "/v1/pets/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = New("Pet Store", "1.0").
+		Path("/a").Get(func(op *OperationBuilder) { op.ID("op") }).
+		Path("/b").Get(func(op *OperationBuilder) { op.ID("op") }).
+		Build()
+	if !errors.Is(err, ErrDuplicateOperationID) {
+		t.Fatalf("expected duplicate operation id error, got %v", err)
+	}
+}
@@ -4,6 +4,12 @@
 
 package openapi
 
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
 // Callback Object
 // A map of possible out-of band callbacks related to the parent operation.
 // Each value in the map is a Path Item Object that describes a set of requests
@@ -13,11 +19,52 @@ package openapi
 type Callback struct {
 	// A Path Item Object used to define a callback request and expected 
 	// responses. A complete example is available.
+	//
 	// {expression} *PathItem
+	Expressions map[string]*PathItem `json:"-"`
 
 	// This object MAY be extended with Specification Extensions.
 }
 
+// Returns the expressions sorted lexically.
+func (c *Callback) Keys() (result []string) {
+	result = make([]string, 0, len(c.Expressions))
+	for key := range c.Expressions {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c *Callback) MarshalJSON() ([]byte, error) {
+	if c.Expressions == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(c.Expressions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// Specification Extensions are skipped.
+func (c *Callback) UnmarshalJSON(data []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	c.Expressions = make(map[string]*PathItem, len(m))
+	for key, raw := range m {
+		if strings.HasPrefix(key, "x-") {
+			continue
+		}
+		var item = &PathItem{}
+		if err := json.Unmarshal(raw, item); err != nil {
+			return err
+		}
+		c.Expressions[key] = item
+	}
+	return nil
+}
+
 // Key Expression
 // The key that identifies the Path Item Object is a runtime expression that can be evaluated in the context of a runtime HTTP request/response to identify the URL to be used for the callback request. A simple example might be $request.body#/url. However, using a runtime expression the complete HTTP message can be accessed. This includes accessing any part of a body that a JSON Pointer RFC6901 can reference.
 
@@ -0,0 +1,684 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GenerateClient generates the source of a Go package named pkg that
+// implements a client of operations defined in doc.
+//
+// The package declares:
+//
+//   - Types for component schemas and inline request and response schemas.
+//   - A <Operation>Params struct for each operation with parameters.
+//   - A Client with a method for each operation named from its OperationID
+//     that serializes parameters according to their style and explode
+//     values, sends the request and returns a <Operation>Response holding
+//     the response body decoded into a field of the type defined for the
+//     response status code.
+//   - Servers defined by the document and ServerURL which expands server
+//     variables.
+//   - Client options that select the server, set the http client and set
+//     credentials for each security scheme in Components. Credentials are
+//     applied to requests according to operation security requirements.
+func GenerateClient(doc *OpenAPI, pkg string) ([]byte, error) {
+	var g, err = newGoGenerator(doc, pkg,
+		"Client", "NewClient", "ClientOption", "HTTPDoer", "RequestEditor",
+		"Server", "ServerVariable", "Servers", "ServerURL", "WithServer",
+		"WithServerURL", "WithHTTPClient", "WithRequestEditor")
+	if err != nil {
+		return nil, err
+	}
+	if err = g.declareComponents(); err != nil {
+		return nil, err
+	}
+	ops, err := g.operations()
+	if err != nil {
+		return nil, err
+	}
+	var params = make(map[*goOperation]string)
+	for _, op := range ops {
+		if len(op.Params) > 0 {
+			params[op] = g.declareParams(op)
+		}
+	}
+	g.declareServers()
+	if err = g.declareCredentials(); err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		g.declareClientOperation(op, params[op])
+	}
+	for _, path := range []string{
+		"bytes", "context", "encoding/json", "errors", "fmt", "io",
+		"io/ioutil", "net/http", "net/url", "sort", "strings",
+	} {
+		g.use(path)
+	}
+	g.printf("%s", clientRuntime)
+	return g.source()
+}
+
+// Declares Servers of the document.
+func (g *goGenerator) declareServers() {
+	g.printf("// Servers are servers of the API.\nvar Servers = []Server{\n")
+	for _, server := range g.doc.Servers {
+		g.printf("\t{\n\t\tURL: %q,\n", server.URL)
+		if server.Description != "" {
+			g.printf("\t\tDescription: %q,\n", server.Description)
+		}
+		if len(server.Variables) > 0 {
+			var names = make([]string, 0, len(server.Variables))
+			for name := range server.Variables {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			g.printf("\t\tVariables: map[string]ServerVariable{\n")
+			for _, name := range names {
+				var v = server.Variables[name]
+				g.printf("\t\t\t%q: {Default: %q", name, v.Default)
+				if len(v.Enum) > 0 {
+					g.printf(", Enum: %#v", v.Enum)
+				}
+				g.printf("},\n")
+			}
+			g.printf("\t\t},\n")
+		}
+		g.printf("\t},\n")
+	}
+	g.printf("}\n\n")
+}
+
+// Declares client options that set credentials of security schemes.
+func (g *goGenerator) declareCredentials() error {
+	if g.doc.Components == nil {
+		return nil
+	}
+	var names = make([]string, 0, len(g.doc.Components.SecuritySchemes))
+	for name := range g.doc.Components.SecuritySchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var scheme *SecurityScheme
+		if err := g.doc.resolve(g.doc.Components.SecuritySchemes[name], &scheme); err != nil {
+			return fmt.Errorf("security scheme '%s': %w", name, err)
+		}
+		var (
+			option = g.ident("With" + goIdent(name))
+			args   string
+			apply  string
+			text   = fmt.Sprintf("%s sets credentials of the '%s' security scheme.\n\n", option, name)
+		)
+		switch scheme.Type {
+		case SecurityTypeAPIKey:
+			args = "key string"
+			text += fmt.Sprintf("The API key is sent in the '%s' %s.", scheme.Name, scheme.In)
+			switch scheme.In {
+			case "header":
+				apply = fmt.Sprintf("req.Header.Set(%q, key)", scheme.Name)
+			case "query":
+				apply = fmt.Sprintf("var query = req.URL.Query()\nquery.Set(%q, key)\nreq.URL.RawQuery = query.Encode()", scheme.Name)
+			case "cookie":
+				apply = fmt.Sprintf("req.AddCookie(&http.Cookie{Name: %q, Value: key})", scheme.Name)
+			default:
+				return fmt.Errorf("security scheme '%s': invalid api key location '%s'", name, scheme.In)
+			}
+		case SecurityTypeHTTP:
+			if strings.EqualFold(scheme.Scheme, "basic") {
+				args = "username, password string"
+				text += "The username and password are sent using HTTP basic authentication."
+				apply = "req.SetBasicAuth(username, password)"
+				break
+			}
+			var authScheme = scheme.Scheme
+			if strings.EqualFold(authScheme, "bearer") || authScheme == "" {
+				authScheme = "Bearer"
+			}
+			args = "token string"
+			text += fmt.Sprintf("The token is sent in the Authorization header using the %s scheme.", authScheme)
+			apply = fmt.Sprintf("req.Header.Set(\"Authorization\", %q+token)", authScheme+" ")
+		case SecurityTypeOAuth2, SecurityTypeOpenIDConnect:
+			args = "token string"
+			text += "The access token is sent as a bearer token."
+			apply = "req.Header.Set(\"Authorization\", \"Bearer \"+token)"
+		default:
+			// Mutual TLS is configured in the http client.
+			continue
+		}
+		if scheme.Description != "" {
+			text += "\n\n" + scheme.Description
+		}
+		g.printf("%sfunc %s(%s) ClientOption {\n", goComment(text, ""), option, args)
+		g.printf("\treturn func(c *Client) error {\n")
+		g.printf("\t\tc.credentials[%q] = func(ctx context.Context, req *http.Request) error {\n", name)
+		g.printf("\t\t\t%s\n\t\t\treturn nil\n\t\t}\n\t\treturn nil\n\t}\n}\n\n", apply)
+	}
+	return nil
+}
+
+// Declares the response type and client method of op.
+func (g *goGenerator) declareClientOperation(op *goOperation, params string) {
+	var (
+		respName = g.ident(op.Name + "Response")
+		fields   = make(map[*goResponse]string)
+		accept   []string
+	)
+	g.printf("// %s is a response of %s.\ntype %s struct {\n", respName, op.Name, respName)
+	g.printf("\t// HTTPResponse is the response. Its body is read and closed.\n")
+	g.printf("\tHTTPResponse *http.Response\n")
+	g.printf("\t// Body is the response body.\n\tBody []byte\n")
+	for _, resp := range op.Responses {
+		if resp.ContentType == "" {
+			continue
+		}
+		if !containsString(accept, resp.ContentType) {
+			accept = append(accept, resp.ContentType)
+		}
+		if resp.Type == "[]byte" {
+			continue
+		}
+		var field = "JSON" + codeIdent(resp.Code)
+		fields[resp] = field
+		var text = fmt.Sprintf("%s is the decoded body of a %s response.", field, resp.Code)
+		if resp.Description != "" {
+			text += "\n\n" + resp.Description
+		}
+		g.printf("%s\t%s %s\n", goComment(text, "\t"), field, pointerType(resp.Type))
+	}
+	g.printf("}\n\n")
+
+	var args = []string{"ctx context.Context"}
+	if params != "" {
+		args = append(args, "params *"+params)
+	}
+	if op.Body != nil {
+		if op.Body.Type != "" {
+			args = append(args, "body "+pointerType(op.Body.Type))
+		} else {
+			args = append(args, "body io.Reader")
+		}
+	}
+	g.printf("%sfunc (c *Client) %s(%s) (*%s, error) {\n",
+		operationDoc(op, "sends", ""), op.Name, strings.Join(args, ", "), respName)
+	g.printf("\tvar (\n\t\tpath = %q\n\t\tquery = url.Values{}\n\t\theader = http.Header{}\n", op.Path)
+	g.printf("\t\tcookies []*http.Cookie\n\t\treader io.Reader\n\t)\n")
+	if len(accept) > 0 {
+		g.printf("\theader.Set(\"Accept\", %q)\n", strings.Join(accept, ", "))
+	}
+	if params != "" {
+		g.printf("\tif params == nil {\n\t\tparams = &%s{}\n\t}\n", params)
+		for _, p := range op.Params {
+			var add string
+			switch p.In {
+			case "path":
+				add = "setPathParam(&path"
+			case "query":
+				add = "addQueryParam(query"
+			case "header":
+				add = "addHeaderParam(header"
+			case "cookie":
+				add = "addCookieParam(&cookies"
+			default:
+				continue
+			}
+			var call = fmt.Sprintf("%s, %q, params.%s, %q, %t); err != nil {\n", add, p.Name, p.Field, p.Style, p.Explode)
+			if isNilable(p.Type) && p.In != "path" {
+				g.printf("\tif params.%s != nil {\n\t\tif err := %s\t\t\treturn nil, err\n\t\t}\n\t}\n", p.Field, call)
+			} else {
+				g.printf("\tif err := %s\t\treturn nil, err\n\t}\n", call)
+			}
+		}
+	}
+	if op.Body != nil {
+		var set string
+		if op.Body.Type != "" {
+			set = "\t\tvar data, err = json.Marshal(body)\n\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n" +
+				"\t\treader = bytes.NewReader(data)\n"
+		} else {
+			set = "\t\treader = body\n"
+		}
+		g.printf("\tif body != nil {\n%s", set)
+		g.printf("\t\theader.Set(\"Content-Type\", %q)\n", op.Body.ContentType)
+		if op.Body.Required {
+			g.printf("\t} else {\n\t\treturn nil, errors.New(\"missing required request body\")\n")
+		}
+		g.printf("\t}\n")
+	}
+	g.printf("\tvar resp, data, err = c.do(ctx, %q, path, query, header, cookies, reader, %s)\n",
+		op.Method, securityLiteral(g.doc.OperationSecurity(op.Operation)))
+	g.printf("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	g.printf("\tvar result = &%s{HTTPResponse: resp, Body: data}\n", respName)
+	if len(fields) > 0 {
+		g.printf("\tswitch {\n")
+		for _, resp := range clientResponseOrder(op.Responses) {
+			switch {
+			case resp.Code == "default":
+				g.printf("\tdefault:\n")
+			case strings.HasSuffix(resp.Code, "XX"):
+				g.printf("\tcase resp.StatusCode/100 == %s:\n", resp.Code[:1])
+			default:
+				g.printf("\tcase resp.StatusCode == %s:\n", resp.Code)
+			}
+			if field, ok := fields[resp]; ok {
+				g.printf("\t\tif err := json.Unmarshal(data, &result.%s); err != nil {\n", field)
+				g.printf("\t\t\treturn result, fmt.Errorf(\"decode %%d response: %%w\", resp.StatusCode, err)\n\t\t}\n")
+			}
+		}
+		g.printf("\t}\n")
+	}
+	g.printf("\treturn result, nil\n}\n\n")
+}
+
+// Returns responses ordered by precedence when matching a status code: codes,
+// ranges and default.
+func clientResponseOrder(responses []*goResponse) []*goResponse {
+	var result = append([]*goResponse{}, responses...)
+	var rank = func(code string) int {
+		switch {
+		case code == "default":
+			return 2
+		case strings.HasSuffix(code, "XX"):
+			return 1
+		}
+		return 0
+	}
+	sort.SliceStable(result, func(i, j int) bool { return rank(result[i].Code) < rank(result[j].Code) })
+	return result
+}
+
+// Returns a Go literal of security requirements as lists of scheme names.
+func securityLiteral(requirements []*SecurityRequirement) string {
+	if requirements == nil {
+		return "nil"
+	}
+	var items []string
+	for _, req := range requirements {
+		var names []string
+		if req != nil {
+			for name := range req.Schemes {
+				names = append(names, strconv.Quote(name))
+			}
+		}
+		sort.Strings(names)
+		items = append(items, "{"+strings.Join(names, ", ")+"}")
+	}
+	return "[][]string{" + strings.Join(items, ", ") + "}"
+}
+
+// clientRuntime is the part of a generated client that does not depend on
+// the document.
+const clientRuntime = `// Server is a server of the API.
+type Server struct {
+	// URL is the server URL, possibly holding variables in curly braces.
+	URL string
+	// Description describes the server.
+	Description string
+	// Variables are variables of the server URL.
+	Variables map[string]ServerVariable
+}
+
+// ServerVariable is a variable of a server URL.
+type ServerVariable struct {
+	// Default is the value used if no value is given.
+	Default string
+	// Enum, if not empty, holds the allowed values.
+	Enum []string
+}
+
+// ServerURL returns the URL of Servers[index] with variables substituted by
+// values in variables or their defaults.
+func ServerURL(index int, variables map[string]string) (string, error) {
+	if index < 0 || index >= len(Servers) {
+		return "", fmt.Errorf("server index %d out of range", index)
+	}
+	var server = Servers[index]
+	var result = server.URL
+	for name, v := range server.Variables {
+		var value, ok = variables[name]
+		if !ok {
+			value = v.Default
+		}
+		if len(v.Enum) > 0 {
+			var valid bool
+			for _, e := range v.Enum {
+				valid = valid || e == value
+			}
+			if !valid {
+				return "", fmt.Errorf("invalid value '%s' of server variable '%s'", value, name)
+			}
+		}
+		result = strings.ReplaceAll(result, "{"+name+"}", value)
+	}
+	for name := range variables {
+		if _, ok := server.Variables[name]; !ok {
+			return "", fmt.Errorf("unknown server variable '%s'", name)
+		}
+	}
+	return result, nil
+}
+
+// HTTPDoer sends HTTP requests. It is implemented by *http.Client.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// RequestEditor modifies a request before it is sent.
+type RequestEditor func(ctx context.Context, req *http.Request) error
+
+// ClientOption configures a Client.
+type ClientOption func(c *Client) error
+
+// Client is a client of the API.
+type Client struct {
+	// Server is the URL of the server requests are sent to.
+	Server string
+	// HTTPClient sends requests. If nil http.DefaultClient is used.
+	HTTPClient HTTPDoer
+	// RequestEditors are applied to requests before they are sent.
+	RequestEditors []RequestEditor
+
+	credentials map[string]RequestEditor
+}
+
+// NewClient returns a new Client configured by options. Requests are sent
+// to the first of Servers unless another server is selected.
+func NewClient(options ...ClientOption) (*Client, error) {
+	var c = &Client{credentials: make(map[string]RequestEditor)}
+	if len(Servers) > 0 {
+		var err error
+		if c.Server, err = ServerURL(0, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, option := range options {
+		if err := option(c); err != nil {
+			return nil, err
+		}
+	}
+	if c.Server == "" {
+		return nil, errors.New("no server")
+	}
+	return c, nil
+}
+
+// WithServer selects the server at index of Servers with variables.
+func WithServer(index int, variables map[string]string) ClientOption {
+	return func(c *Client) (err error) {
+		c.Server, err = ServerURL(index, variables)
+		return
+	}
+}
+
+// WithServerURL sets the URL of the server requests are sent to.
+func WithServerURL(serverURL string) ClientOption {
+	return func(c *Client) error {
+		c.Server = serverURL
+		return nil
+	}
+}
+
+// WithHTTPClient sets the client that sends requests.
+func WithHTTPClient(doer HTTPDoer) ClientOption {
+	return func(c *Client) error {
+		c.HTTPClient = doer
+		return nil
+	}
+}
+
+// WithRequestEditor adds a RequestEditor.
+func WithRequestEditor(editor RequestEditor) ClientOption {
+	return func(c *Client) error {
+		c.RequestEditors = append(c.RequestEditors, editor)
+		return nil
+	}
+}
+
+// Sends a request and returns the response and its body.
+//
+// Credentials of the first security requirement whose schemes all have
+// credentials set are applied.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, cookies []*http.Cookie, body io.Reader, security [][]string) (*http.Response, []byte, error) {
+	var u = strings.TrimSuffix(c.Server, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var req, err = http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	for _, requirement := range security {
+		var satisfied = true
+		for _, name := range requirement {
+			if _, ok := c.credentials[name]; !ok {
+				satisfied = false
+			}
+		}
+		if !satisfied {
+			continue
+		}
+		for _, name := range requirement {
+			if err = c.credentials[name](ctx, req); err != nil {
+				return nil, nil, err
+			}
+		}
+		break
+	}
+	for _, editor := range c.RequestEditors {
+		if err = editor(ctx, req); err != nil {
+			return nil, nil, err
+		}
+	}
+	var doer = c.HTTPClient
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, data, nil
+}
+
+// Returns v converted to values decoded from JSON with numbers as
+// json.Number.
+func plainValue(v interface{}) (interface{}, error) {
+	var data, err = json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var dec = json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var result interface{}
+	if err = dec.Decode(&result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Formats a primitive value decoded by plainValue.
+func formatPlain(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case bool:
+		if t {
+			return "true"
+		}
+		return "false"
+	}
+	var data, _ = json.Marshal(v)
+	return string(data)
+}
+
+// Serializes parameter name with value v in style and returns name, value
+// pairs. Values are escaped using escape.
+func serializeParam(name string, v interface{}, style string, explode bool, escape func(string) string) ([][2]string, error) {
+	if style == "content" {
+		var data, err = json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return [][2]string{{name, escape(string(data))}}, nil
+	}
+	var value, err = plainValue(v)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		items  []string
+		keys   []string
+		object bool
+	)
+	switch t := value.(type) {
+	case []interface{}:
+		for _, item := range t {
+			items = append(items, escape(formatPlain(item)))
+		}
+	case map[string]interface{}:
+		object = true
+		for key := range t {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			items = append(items, escape(key), escape(formatPlain(t[key])))
+		}
+	default:
+		var s = escape(formatPlain(value))
+		switch style {
+		case "label":
+			s = "." + s
+		case "matrix":
+			s = ";" + name + "=" + s
+		}
+		return [][2]string{{name, s}}, nil
+	}
+	var pairs = func(sep, prefix string) string {
+		var a []string
+		for i := 0; i < len(items); i += 2 {
+			a = append(a, prefix+items[i]+sep+items[i+1])
+		}
+		return strings.Join(a, "")
+	}
+	switch style {
+	case "simple":
+		if object && explode {
+			return [][2]string{{name, strings.TrimPrefix(pairs("=", ","), ",")}}, nil
+		}
+		return [][2]string{{name, strings.Join(items, ",")}}, nil
+	case "label":
+		switch {
+		case object && explode:
+			return [][2]string{{name, pairs("=", ".")}}, nil
+		case explode:
+			return [][2]string{{name, "." + strings.Join(items, ".")}}, nil
+		}
+		return [][2]string{{name, "." + strings.Join(items, ",")}}, nil
+	case "matrix":
+		switch {
+		case object && explode:
+			return [][2]string{{name, pairs("=", ";")}}, nil
+		case explode:
+			return [][2]string{{name, ";" + name + "=" + strings.Join(items, ";"+name+"=")}}, nil
+		}
+		return [][2]string{{name, ";" + name + "=" + strings.Join(items, ",")}}, nil
+	case "deepObject":
+		if !object {
+			return nil, fmt.Errorf("parameter '%s': deepObject style requires an object", name)
+		}
+		var result [][2]string
+		for i := 0; i < len(items); i += 2 {
+			result = append(result, [2]string{name + "[" + items[i] + "]", items[i+1]})
+		}
+		return result, nil
+	}
+	if explode {
+		var result [][2]string
+		for i := 0; i < len(items); i++ {
+			if object {
+				result = append(result, [2]string{items[i], items[i+1]})
+				i++
+				continue
+			}
+			result = append(result, [2]string{name, items[i]})
+		}
+		return result, nil
+	}
+	var sep = ","
+	switch style {
+	case "spaceDelimited":
+		sep = " "
+	case "pipeDelimited":
+		sep = "|"
+	}
+	return [][2]string{{name, strings.Join(items, sep)}}, nil
+}
+
+// Returns s unmodified.
+func noEscape(s string) string { return s }
+
+// Substitutes path parameter name in path.
+func setPathParam(path *string, name string, v interface{}, style string, explode bool) error {
+	var pairs, err = serializeParam(name, v, style, explode, url.PathEscape)
+	if err != nil {
+		return err
+	}
+	*path = strings.ReplaceAll(*path, "{"+name+"}", pairs[0][1])
+	return nil
+}
+
+// Adds query parameter name to query.
+func addQueryParam(query url.Values, name string, v interface{}, style string, explode bool) error {
+	var pairs, err = serializeParam(name, v, style, explode, noEscape)
+	if err != nil {
+		return err
+	}
+	for _, pair := range pairs {
+		query.Add(pair[0], pair[1])
+	}
+	return nil
+}
+
+// Adds header parameter name to header.
+func addHeaderParam(header http.Header, name string, v interface{}, style string, explode bool) error {
+	var pairs, err = serializeParam(name, v, style, explode, noEscape)
+	if err != nil {
+		return err
+	}
+	header.Set(name, pairs[0][1])
+	return nil
+}
+
+// Adds cookie parameter name to cookies.
+func addCookieParam(cookies *[]*http.Cookie, name string, v interface{}, style string, explode bool) error {
+	var pairs, err = serializeParam(name, v, style, false, noEscape)
+	if err != nil {
+		return err
+	}
+	*cookies = append(*cookies, &http.Cookie{Name: name, Value: pairs[0][1]})
+	return nil
+}
+`
@@ -0,0 +1,108 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import "testing"
+
+func TestGenerateClient(t *testing.T) {
+	var doc, err = FromJSON([]byte(petStore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := GenerateClient(doc, "generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goTestFiles(t, map[string][]byte{
+		"client.go": src,
+		"client_test.go": []byte(`package generated
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	var mux = http.NewServeMux()
+	mux.HandleFunc("/v1/pets", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			if r.URL.RawQuery != "ids=1%2C2&limit=5&tags=a&tags=b" {
+				t.Errorf("unexpected query: %s", r.URL.RawQuery)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(` + "`" + `[{"id":1,"name":"rex"}]` + "`" + `))
+		case "POST":
+			var pet Pet
+			if err := json.NewDecoder(r.Body).Decode(&pet); err != nil || r.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("invalid body: %v", err)
+			}
+			pet.ID = 2
+			w.WriteHeader(201)
+			json.NewEncoder(w).Encode(pet)
+		}
+	})
+	mux.HandleFunc("/v1/pets/7", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "secret" {
+			w.WriteHeader(401)
+			json.NewEncoder(w).Encode(Error{Message: "unauthorized"})
+			return
+		}
+		json.NewEncoder(w).Encode(Pet{ID: 7, Name: r.Header.Get("X-Request-ID")})
+	})
+	var s = httptest.NewServer(mux)
+	defer s.Close()
+
+	if u, err := ServerURL(0, map[string]string{"env": "staging"}); err != nil || u != "https://staging.example.com/v1" {
+		t.Fatalf("unexpected server url %s: %v", u, err)
+	}
+	if _, err := ServerURL(0, map[string]string{"env": "prod"}); err == nil {
+		t.Fatal("expected invalid server variable error")
+	}
+
+	var c, err = NewClient(WithServerURL(s.URL+"/v1"), WithHTTPClient(s.Client()), WithAPIKey("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var limit int32 = 5
+	list, err := c.ListPets(context.Background(), &ListPetsParams{Limit: &limit, Tags: []string{"a", "b"}, IDs: []int{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.JSON200) != 1 || list.JSON200[0].Name != "rex" {
+		t.Fatalf("unexpected response: %s", list.Body)
+	}
+	var tag = "dog"
+	created, err := c.CreatePet(context.Background(), &NewPet{Name: "fido", Tag: &tag})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.JSON201 == nil || created.JSON201.ID != 2 || *created.JSON201.Tag != "dog" {
+		t.Fatalf("unexpected response: %s", created.Body)
+	}
+	var rid = "rid"
+	pet, err := c.GetPet(context.Background(), &GetPetParams{PetID: 7, XRequestID: &rid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pet.JSON200 == nil || pet.JSON200.ID != 7 || pet.JSON200.Name != "rid" {
+		t.Fatalf("unexpected response: %s", pet.Body)
+	}
+
+	c, _ = NewClient(WithServerURL(s.URL + "/v1"))
+	pet, err = c.GetPet(context.Background(), &GetPetParams{PetID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pet.HTTPResponse.StatusCode != 401 || pet.JSON200 != nil {
+		t.Fatalf("expected unauthorized response, got %d", pet.HTTPResponse.StatusCode)
+	}
+}
+`),
+	})
+}
@@ -48,7 +48,7 @@ type Components struct {
 	// Holds Map[string, Path Item Object | Reference Object]
 	//
 	// An object to hold reusable Path Item Object.
-	PathItems map[string]interface{}
+	PathItems map[string]interface{} `json:"pathItems,omitempty"`
 
 	// This object MAY be extended with Specification Extensions.
 }
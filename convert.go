@@ -0,0 +1,859 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ConversionNote describes a construct of a document affected by a
+// conversion.
+type ConversionNote struct {
+	// Pointer is the JSON Pointer of the construct in the source document.
+	Pointer string
+	Message string
+}
+
+// Returns the note as "#<pointer>: <message>".
+func (n *ConversionNote) String() string {
+	return "#" + n.Pointer + ": " + n.Message
+}
+
+// converter holds notes of a conversion of a raw document.
+type converter struct {
+	notes []*ConversionNote
+}
+
+// Records a note about the construct at pointer.
+func (c *converter) note(pointer, format string, args ...interface{}) {
+	c.notes = append(c.notes, &ConversionNote{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+// Returns copy of object m at pointer without specification extensions,
+// noting each dropped extension.
+func (c *converter) dropExtensions(pointer string, m map[string]interface{}) map[string]interface{} {
+	var result = make(map[string]interface{}, len(m))
+	for _, key := range sortedKeys(m) {
+		if strings.HasPrefix(key, "x-") {
+			c.note(pointer+"/"+escapePointer(key), "specification extension dropped")
+			continue
+		}
+		result[key] = m[key]
+	}
+	return result
+}
+
+// Decodes JSON or YAML data into a raw document of JSON compatible values.
+func decodeRaw(data []byte) (map[string]interface{}, error) {
+	var v interface{}
+	if json.Valid(data) {
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+	} else if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	var m, ok = jsonValue(v).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("document is not an object")
+	}
+	return m, nil
+}
+
+// Converts maps of YAML values in v to maps with string keys.
+func jsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		var m = make(map[string]interface{}, len(t))
+		for key, value := range t {
+			m[fmt.Sprint(key)] = jsonValue(value)
+		}
+		return m
+	case map[string]interface{}:
+		for key, value := range t {
+			t[key] = jsonValue(value)
+		}
+	case []interface{}:
+		for i, value := range t {
+			t[i] = jsonValue(value)
+		}
+	}
+	return v
+}
+
+// Returns keys of m sorted lexically.
+func sortedKeys(m map[string]interface{}) []string {
+	var result = make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Returns v as an object or nil.
+func rawObject(v interface{}) map[string]interface{} {
+	var m, _ = v.(map[string]interface{})
+	return m
+}
+
+// Returns list v or nil.
+func rawList(v interface{}) []interface{} {
+	var a, _ = v.([]interface{})
+	return a
+}
+
+// Returns v as a list of strings, skipping other values.
+func rawStrings(v interface{}) (result []string) {
+	var a, _ = v.([]interface{})
+	for _, item := range a {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return
+}
+
+// ConvertV2 converts a Swagger 2.0 document in JSON or YAML to an OpenAPI
+// 3.1 document.
+//
+// host, basePath and schemes are servers, definitions are component schemas
+// and securityDefinitions are security schemes. body and formData
+// parameters are request bodies with a media type for each type the
+// operation consumes, formData parameters as properties of an object with
+// their collectionFormat as Encoding. Responses have a media type for each
+// type the operation produces. References to definitions, parameters and
+// responses are rewritten to their components.
+//
+// Schemas are rewritten to JSON Schema 2020-12: x-nullable adds "null" to
+// the type, example is an examples list, boolean exclusiveMinimum and
+// exclusiveMaximum are numbers and file types are binary strings.
+//
+// Constructs that have no exact equivalent, such as the tsv collectionFormat
+// or specification extensions of objects other than schemas, are converted
+// approximately or dropped and the document is returned with a note for
+// each of them.
+func ConvertV2(data []byte) (*OpenAPI, []*ConversionNote, error) {
+	var src, err = decodeRaw(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version := fmt.Sprint(src["swagger"]); version != "2.0" {
+		return nil, nil, fmt.Errorf("unsupported swagger version '%s'", version)
+	}
+	var c = &v2Converter{
+		src:      src,
+		consumes: rawStrings(src["consumes"]),
+		produces: rawStrings(src["produces"]),
+	}
+	if data, err = json.Marshal(c.document()); err != nil {
+		return nil, nil, err
+	}
+	var doc *OpenAPI
+	if doc, err = FromJSON(data); err != nil {
+		return nil, nil, err
+	}
+	return doc, c.notes, nil
+}
+
+// v2Converter converts a raw Swagger 2.0 document.
+type v2Converter struct {
+	converter
+	src map[string]interface{}
+	// consumes and produces are document defaults.
+	consumes, produces []string
+}
+
+// v2Refs maps Swagger 2.0 reference prefixes to component prefixes.
+var v2Refs = [][2]string{
+	{"#/definitions/", componentsPrefix + "schemas/"},
+	{"#/parameters/", componentsPrefix + "parameters/"},
+	{"#/responses/", componentsPrefix + "responses/"},
+}
+
+// Returns ref rewritten to reference a component.
+func v2Ref(ref string) string {
+	for _, prefix := range v2Refs {
+		if strings.HasPrefix(ref, prefix[0]) {
+			return prefix[1] + ref[len(prefix[0]):]
+		}
+	}
+	return ref
+}
+
+// Returns the converted document.
+func (c *v2Converter) document() map[string]interface{} {
+	var out = map[string]interface{}{"openapi": Version}
+	for _, key := range sortedKeys(c.src) {
+		var value, pointer = c.src[key], "/" + escapePointer(key)
+		switch key {
+		case "info", "externalDocs":
+			out[key] = c.dropExtensions(pointer, rawObject(value))
+		case "tags":
+			var tags []interface{}
+			for i, tag := range rawList(value) {
+				tags = append(tags, c.dropExtensions(fmt.Sprintf("%s/%d", pointer, i), rawObject(tag)))
+			}
+			out[key] = tags
+		case "security":
+			out[key] = value
+		case "paths":
+			out[key] = c.paths(rawObject(value))
+		case "swagger", "host", "basePath", "schemes", "consumes", "produces",
+			"definitions", "parameters", "responses", "securityDefinitions":
+		default:
+			if strings.HasPrefix(key, "x-") {
+				c.note(pointer, "specification extension dropped")
+			}
+		}
+	}
+	if servers := c.servers(rawStrings(c.src["schemes"])); servers != nil {
+		out["servers"] = servers
+	}
+	var components = make(map[string]interface{})
+	if defs := rawObject(c.src["definitions"]); len(defs) > 0 {
+		var schemas = make(map[string]interface{})
+		for name, s := range defs {
+			schemas[name] = c.schema("/definitions/"+escapePointer(name), s)
+		}
+		components["schemas"] = schemas
+	}
+	if params := rawObject(c.src["parameters"]); len(params) > 0 {
+		var result = make(map[string]interface{})
+		for _, name := range sortedKeys(params) {
+			var pointer = "/parameters/" + escapePointer(name)
+			var p = rawObject(params[name])
+			if in := p["in"]; in == "body" || in == "formData" {
+				// Inlined into request bodies where referenced.
+				continue
+			}
+			result[name] = c.parameter(pointer, p)
+		}
+		if len(result) > 0 {
+			components["parameters"] = result
+		}
+	}
+	if responses := rawObject(c.src["responses"]); len(responses) > 0 {
+		var result = make(map[string]interface{})
+		for _, name := range sortedKeys(responses) {
+			var pointer = "/responses/" + escapePointer(name)
+			result[name] = c.response(pointer, rawObject(responses[name]), c.produces)
+		}
+		components["responses"] = result
+	}
+	if defs := rawObject(c.src["securityDefinitions"]); len(defs) > 0 {
+		var schemes = make(map[string]interface{})
+		for _, name := range sortedKeys(defs) {
+			schemes[name] = c.securityScheme("/securityDefinitions/"+escapePointer(name), rawObject(defs[name]))
+		}
+		components["securitySchemes"] = schemes
+	}
+	if len(components) > 0 {
+		out["components"] = components
+	}
+	return out
+}
+
+// Returns servers of host and basePath of the document for schemes, or nil
+// if neither host nor basePath are defined.
+func (c *v2Converter) servers(schemes []string) []interface{} {
+	var host, _ = c.src["host"].(string)
+	var basePath, _ = c.src["basePath"].(string)
+	if host == "" && basePath == "" && len(schemes) == 0 {
+		return nil
+	}
+	if host == "" {
+		if basePath == "" {
+			basePath = "/"
+		}
+		return []interface{}{map[string]interface{}{"url": basePath}}
+	}
+	if len(schemes) == 0 {
+		// Relative to the scheme the document was retrieved with.
+		return []interface{}{map[string]interface{}{"url": "//" + host + basePath}}
+	}
+	var result []interface{}
+	for _, scheme := range schemes {
+		result = append(result, map[string]interface{}{"url": scheme + "://" + host + basePath})
+	}
+	return result
+}
+
+// Returns the converted Paths Object.
+func (c *v2Converter) paths(paths map[string]interface{}) map[string]interface{} {
+	var out = make(map[string]interface{})
+	for _, path := range sortedKeys(paths) {
+		var pointer = "/paths/" + escapePointer(path)
+		if strings.HasPrefix(path, "x-") {
+			c.note(pointer, "specification extension dropped")
+			continue
+		}
+		var (
+			item   = rawObject(paths[path])
+			result = make(map[string]interface{})
+			shared = rawList(item["parameters"])
+		)
+		for _, key := range sortedKeys(item) {
+			var value = item[key]
+			switch key {
+			case "$ref":
+				result[key] = value
+			case "parameters":
+				var params []interface{}
+				for i, p := range shared {
+					if in := c.parameterIn(p); in != "body" && in != "formData" {
+						params = append(params, c.parameterRef(fmt.Sprintf("%s/parameters/%d", pointer, i), p))
+					}
+				}
+				if len(params) > 0 {
+					result[key] = params
+				}
+			case "get", "put", "post", "delete", "options", "head", "patch":
+				result[key] = c.operation(pointer+"/"+key, rawObject(value), shared)
+			default:
+				if strings.HasPrefix(key, "x-") {
+					c.note(pointer+"/"+escapePointer(key), "specification extension dropped")
+				}
+			}
+		}
+		out[path] = result
+	}
+	return out
+}
+
+// Returns the parameter v refers to if it is a reference, or v.
+func (c *v2Converter) resolveParameter(v interface{}) map[string]interface{} {
+	var p = rawObject(v)
+	if ref, ok := p["$ref"].(string); ok && strings.HasPrefix(ref, "#/parameters/") {
+		var name = unescapePointer(strings.TrimPrefix(ref, "#/parameters/"))
+		return rawObject(rawObject(c.src["parameters"])[name])
+	}
+	return p
+}
+
+// Returns the location of parameter v which may be a reference.
+func (c *v2Converter) parameterIn(v interface{}) string {
+	var in, _ = c.resolveParameter(v)["in"].(string)
+	return in
+}
+
+// Returns the converted parameter or reference v.
+func (c *v2Converter) parameterRef(pointer string, v interface{}) interface{} {
+	var p = rawObject(v)
+	if ref, ok := p["$ref"].(string); ok {
+		return map[string]interface{}{"$ref": v2Ref(ref)}
+	}
+	return c.parameter(pointer, p)
+}
+
+// Returns the converted operation. shared are parameters of the path item.
+func (c *v2Converter) operation(pointer string, op map[string]interface{}, shared []interface{}) map[string]interface{} {
+	var (
+		out      = make(map[string]interface{})
+		consumes = c.consumes
+		produces = c.produces
+	)
+	if v, ok := op["consumes"]; ok {
+		consumes = rawStrings(v)
+	}
+	if v, ok := op["produces"]; ok {
+		produces = rawStrings(v)
+	}
+	for _, key := range sortedKeys(op) {
+		var value = op[key]
+		switch key {
+		case "tags", "summary", "description", "operationId", "deprecated", "security":
+			out[key] = value
+		case "externalDocs":
+			out[key] = c.dropExtensions(pointer+"/externalDocs", rawObject(value))
+		case "schemes":
+			out["servers"] = c.servers(rawStrings(value))
+		case "responses":
+			var responses = make(map[string]interface{})
+			for _, code := range sortedKeys(rawObject(value)) {
+				var rp = pointer + "/responses/" + escapePointer(code)
+				if strings.HasPrefix(code, "x-") {
+					c.note(rp, "specification extension dropped")
+					continue
+				}
+				var r = rawObject(rawObject(value)[code])
+				if ref, ok := r["$ref"].(string); ok {
+					responses[code] = map[string]interface{}{"$ref": v2Ref(ref)}
+					continue
+				}
+				responses[code] = c.response(rp, r, produces)
+			}
+			out[key] = responses
+		case "consumes", "produces", "parameters":
+		default:
+			if strings.HasPrefix(key, "x-") {
+				c.note(pointer+"/"+escapePointer(key), "specification extension dropped")
+			}
+		}
+	}
+
+	// Operation parameters override path item parameters of the same
+	// name and location.
+	var (
+		own     = rawList(op["parameters"])
+		params  []interface{}
+		body    map[string]interface{}
+		form    []map[string]interface{}
+		formPtr string
+		seen    = make(map[string]bool)
+	)
+	for _, v := range own {
+		var p = c.resolveParameter(v)
+		seen[fmt.Sprint(p["in"], "/", p["name"])] = true
+	}
+	var visit = func(pointer string, v interface{}) {
+		var p = c.resolveParameter(v)
+		switch p["in"] {
+		case "body":
+			body = p
+		case "formData":
+			form, formPtr = append(form, p), pointer
+		default:
+			params = append(params, c.parameterRef(pointer, v))
+		}
+	}
+	for i, v := range shared {
+		var p = c.resolveParameter(v)
+		if in := p["in"]; (in == "body" || in == "formData") && !seen[fmt.Sprint(in, "/", p["name"])] {
+			visit(fmt.Sprintf("%s/parameters/%d", pointer[:strings.LastIndex(pointer, "/")], i), v)
+		}
+	}
+	for i, v := range own {
+		visit(fmt.Sprintf("%s/parameters/%d", pointer, i), v)
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+	switch {
+	case body != nil:
+		out["requestBody"] = c.bodyRequest(pointer+"/parameters", body, consumes)
+		if len(form) > 0 {
+			c.note(formPtr, "formData parameters ignored in favor of a body parameter")
+		}
+	case len(form) > 0:
+		out["requestBody"] = c.formRequest(pointer+"/parameters", form, consumes)
+	}
+	return out
+}
+
+// Returns the converted query, header or path parameter p.
+func (c *v2Converter) parameter(pointer string, p map[string]interface{}) map[string]interface{} {
+	var out = make(map[string]interface{})
+	for _, key := range []string{"name", "in", "description", "required", "allowEmptyValue"} {
+		if value, ok := p[key]; ok {
+			out[key] = value
+		}
+	}
+	if in, _ := p["in"].(string); in == "path" {
+		out["required"] = true
+	}
+	out["schema"] = c.itemsSchema(pointer, p)
+	if p["type"] == "array" {
+		var in, _ = out["in"].(string)
+		var style, explode, ok = v2CollectionStyle(in, p["collectionFormat"])
+		if !ok {
+			c.note(pointer, "collectionFormat '%v' of a %s parameter has no equivalent; using %s", p["collectionFormat"], in, style)
+		}
+		out["style"], out["explode"] = style, explode
+	}
+	for _, key := range sortedKeys(p) {
+		if strings.HasPrefix(key, "x-") {
+			c.note(pointer+"/"+escapePointer(key), "specification extension dropped")
+		}
+	}
+	return out
+}
+
+// Returns the style and explode values of a parameter in location in with
+// collectionFormat format and false if there is no exact equivalent.
+func v2CollectionStyle(in string, format interface{}) (string, bool, bool) {
+	var query = in == "query" || in == "formData"
+	switch format {
+	case nil, "csv":
+		if query {
+			return "form", false, true
+		}
+		return "simple", false, true
+	case "multi":
+		if query {
+			return "form", true, true
+		}
+	case "ssv":
+		if query {
+			return "spaceDelimited", false, true
+		}
+	case "pipes":
+		if query {
+			return "pipeDelimited", false, true
+		}
+	}
+	if query {
+		return "form", false, false
+	}
+	return "simple", false, false
+}
+
+// v2SchemaKeys are keywords of parameters, headers and items that are
+// keywords of their schema.
+var v2SchemaKeys = []string{
+	"type", "format", "default", "maximum", "exclusiveMaximum", "minimum",
+	"exclusiveMinimum", "maxLength", "minLength", "pattern", "maxItems",
+	"minItems", "uniqueItems", "enum", "multipleOf",
+}
+
+// Returns the schema of a parameter, header or items object p which is not
+// a body parameter.
+func (c *v2Converter) itemsSchema(pointer string, p map[string]interface{}) interface{} {
+	var s = make(map[string]interface{})
+	for _, key := range v2SchemaKeys {
+		if value, ok := p[key]; ok {
+			s[key] = value
+		}
+	}
+	if items := rawObject(p["items"]); items != nil {
+		s["items"] = c.itemsSchema(pointer+"/items", items)
+		if items["type"] == "array" {
+			c.note(pointer+"/items", "serialization of nested arrays has no equivalent")
+		}
+	}
+	return c.schema(pointer, s)
+}
+
+// Returns the request body of body parameter p for consumes.
+func (c *v2Converter) bodyRequest(pointer string, p map[string]interface{}, consumes []string) map[string]interface{} {
+	if len(consumes) == 0 {
+		consumes = []string{"application/json"}
+	}
+	var (
+		out     = make(map[string]interface{})
+		content = make(map[string]interface{})
+		schema  = c.schema(pointer+"/schema", p["schema"])
+	)
+	for _, ct := range consumes {
+		if ct == "application/x-www-form-urlencoded" || strings.HasPrefix(ct, "multipart/") {
+			c.note(pointer, "body parameter consumed as '%s'", ct)
+		}
+		content[ct] = map[string]interface{}{"schema": schema}
+	}
+	out["content"] = content
+	if value, ok := p["description"]; ok {
+		out["description"] = value
+	}
+	if value, ok := p["required"]; ok {
+		out["required"] = value
+	}
+	return out
+}
+
+// Returns the request body of formData parameters form for consumes.
+func (c *v2Converter) formRequest(pointer string, form []map[string]interface{}, consumes []string) map[string]interface{} {
+	var (
+		props    = make(map[string]interface{})
+		required []interface{}
+		encoding = make(map[string]interface{})
+		arrays   []string
+		file     bool
+	)
+	for _, p := range form {
+		var name, _ = p["name"].(string)
+		var prop = c.itemsSchema(pointer, p)
+		if s := rawObject(prop); s != nil {
+			if d, ok := p["description"]; ok {
+				s["description"] = d
+			}
+		}
+		props[name] = prop
+		if p["required"] == true {
+			required = append(required, name)
+		}
+		if p["type"] == "file" {
+			file = true
+		}
+		if p["type"] == "array" {
+			var style, explode, ok = v2CollectionStyle("formData", p["collectionFormat"])
+			if !ok {
+				c.note(pointer, "collectionFormat '%v' of formData parameter '%s' has no equivalent; using %s", p["collectionFormat"], name, style)
+			}
+			if !explode {
+				arrays = append(arrays, name)
+			}
+			encoding[name] = map[string]interface{}{"style": style, "explode": explode}
+		}
+	}
+	var types []string
+	for _, ct := range consumes {
+		if ct == "application/x-www-form-urlencoded" || strings.HasPrefix(ct, "multipart/") {
+			types = append(types, ct)
+		}
+	}
+	if len(types) == 0 {
+		if len(consumes) > 0 {
+			c.note(pointer, "formData parameters consumed as %s; using a form media type", strings.Join(consumes, ", "))
+		}
+		types = []string{"application/x-www-form-urlencoded"}
+		if file {
+			types = []string{"multipart/form-data"}
+		}
+	}
+	var schema = map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	var content = make(map[string]interface{})
+	for _, ct := range types {
+		var mt = map[string]interface{}{"schema": schema}
+		if ct == "application/x-www-form-urlencoded" {
+			if file {
+				c.note(pointer, "file parameters consumed as '%s'", ct)
+			}
+			if len(encoding) > 0 {
+				mt["encoding"] = encoding
+			}
+		} else {
+			for _, name := range arrays {
+				c.note(pointer, "array formData parameter '%s' is sent as separate parts in '%s'", name, ct)
+			}
+		}
+		content[ct] = mt
+	}
+	return map[string]interface{}{"content": content}
+}
+
+// Returns the converted response r for produces.
+func (c *v2Converter) response(pointer string, r map[string]interface{}, produces []string) map[string]interface{} {
+	var out = map[string]interface{}{"description": r["description"]}
+	if _, ok := r["description"].(string); !ok {
+		out["description"] = ""
+	}
+	var examples = rawObject(r["examples"])
+	if schema, ok := r["schema"]; ok || len(examples) > 0 {
+		if len(produces) == 0 {
+			produces = []string{"application/json"}
+		}
+		var (
+			content = make(map[string]interface{})
+			s       interface{}
+		)
+		if ok {
+			s = c.schema(pointer+"/schema", schema)
+		}
+		for _, ct := range produces {
+			content[ct] = map[string]interface{}{"schema": s}
+		}
+		for _, ct := range sortedKeys(examples) {
+			var mt = rawObject(content[ct])
+			if mt == nil {
+				c.note(pointer+"/examples/"+escapePointer(ct), "example of a type that is not produced")
+				mt = map[string]interface{}{"schema": s}
+				content[ct] = mt
+			}
+			mt["example"] = examples[ct]
+		}
+		for _, mt := range content {
+			if m := rawObject(mt); m["schema"] == nil {
+				delete(m, "schema")
+			}
+		}
+		out["content"] = content
+	}
+	if headers := rawObject(r["headers"]); len(headers) > 0 {
+		var result = make(map[string]interface{})
+		for _, name := range sortedKeys(headers) {
+			var hp = pointer + "/headers/" + escapePointer(name)
+			var h = rawObject(headers[name])
+			var header = map[string]interface{}{"schema": c.itemsSchema(hp, h)}
+			if d, ok := h["description"]; ok {
+				header["description"] = d
+			}
+			if h["type"] == "array" {
+				if _, _, ok := v2CollectionStyle("header", h["collectionFormat"]); !ok {
+					c.note(hp, "collectionFormat '%v' of a header has no equivalent", h["collectionFormat"])
+				}
+			}
+			result[name] = header
+		}
+		out["headers"] = result
+	}
+	for _, key := range sortedKeys(r) {
+		if strings.HasPrefix(key, "x-") {
+			c.note(pointer+"/"+escapePointer(key), "specification extension dropped")
+		}
+	}
+	return out
+}
+
+// Returns the converted security scheme s.
+func (c *v2Converter) securityScheme(pointer string, s map[string]interface{}) map[string]interface{} {
+	var out = make(map[string]interface{})
+	if d, ok := s["description"]; ok {
+		out["description"] = d
+	}
+	switch s["type"] {
+	case "basic":
+		out["type"], out["scheme"] = "http", "basic"
+	case "apiKey":
+		out["type"], out["name"], out["in"] = "apiKey", s["name"], s["in"]
+	case "oauth2":
+		var (
+			flow   = make(map[string]interface{})
+			scopes = rawObject(s["scopes"])
+			name   string
+		)
+		if scopes == nil {
+			scopes = make(map[string]interface{})
+		}
+		flow["scopes"] = scopes
+		switch s["flow"] {
+		case "implicit":
+			name, flow["authorizationUrl"] = "implicit", s["authorizationUrl"]
+		case "password":
+			name, flow["tokenUrl"] = "password", s["tokenUrl"]
+		case "application":
+			name, flow["tokenUrl"] = "clientCredentials", s["tokenUrl"]
+		case "accessCode":
+			name = "authorizationCode"
+			flow["authorizationUrl"], flow["tokenUrl"] = s["authorizationUrl"], s["tokenUrl"]
+		default:
+			c.note(pointer, "unknown oauth2 flow '%v'", s["flow"])
+		}
+		out["type"] = "oauth2"
+		if name != "" {
+			out["flows"] = map[string]interface{}{name: flow}
+		}
+	default:
+		c.note(pointer, "unknown security scheme type '%v'", s["type"])
+		out["type"] = s["type"]
+	}
+	for _, key := range sortedKeys(s) {
+		if strings.HasPrefix(key, "x-") {
+			c.note(pointer+"/"+escapePointer(key), "specification extension dropped")
+		}
+	}
+	return out
+}
+
+// Returns Swagger 2.0 schema v rewritten to JSON Schema 2020-12.
+func (c *v2Converter) schema(pointer string, v interface{}) interface{} {
+	var s = rawObject(v)
+	if s == nil {
+		return v
+	}
+	var out = make(map[string]interface{}, len(s))
+	for key, value := range s {
+		var vp = pointer + "/" + escapePointer(key)
+		switch key {
+		case "$ref":
+			if ref, ok := value.(string); ok {
+				value = v2Ref(ref)
+			}
+		case "properties", "patternProperties", "definitions":
+			var props = make(map[string]interface{})
+			for name, prop := range rawObject(value) {
+				props[name] = c.schema(vp+"/"+escapePointer(name), prop)
+			}
+			value = props
+		case "allOf", "anyOf", "oneOf":
+			var list []interface{}
+			for i, sub := range rawList(value) {
+				list = append(list, c.schema(fmt.Sprintf("%s/%d", vp, i), sub))
+			}
+			value = list
+		case "items", "additionalProperties", "not":
+			value = c.schema(vp, value)
+		case "discriminator":
+			if name, ok := value.(string); ok {
+				value = map[string]interface{}{"propertyName": name}
+			}
+		case "x-nullable":
+			continue
+		}
+		out[key] = value
+	}
+	if out["type"] == "file" {
+		out["type"] = "string"
+		out["contentMediaType"] = "application/octet-stream"
+	}
+	upgradeSchemaKeywords(out)
+	if s["x-nullable"] == true {
+		return nullableSchema(out)
+	}
+	return out
+}
+
+// Rewrites draft 4 keywords of raw schema s, boolean exclusiveMinimum and
+// exclusiveMaximum and example, to their JSON Schema 2020-12 equivalents.
+// Returns descriptions of the rewrites.
+func upgradeSchemaKeywords(s map[string]interface{}) (changes []string) {
+	for _, bound := range [][2]string{{"exclusiveMinimum", "minimum"}, {"exclusiveMaximum", "maximum"}} {
+		switch s[bound[0]] {
+		case true:
+			if limit, ok := s[bound[1]]; ok {
+				s[bound[0]] = limit
+				delete(s, bound[1])
+				changes = append(changes, fmt.Sprintf("%s true and %s %v rewritten as %s %[3]v", bound[0], bound[1], limit, bound[0]))
+			} else {
+				delete(s, bound[0])
+				changes = append(changes, fmt.Sprintf("%s true without %s removed", bound[0], bound[1]))
+			}
+		case false:
+			delete(s, bound[0])
+			changes = append(changes, fmt.Sprintf("%s false removed", bound[0]))
+		}
+	}
+	if example, ok := s["example"]; ok {
+		if _, exists := s["examples"]; !exists {
+			s["examples"] = []interface{}{example}
+			changes = append(changes, "example rewritten as examples")
+		} else {
+			changes = append(changes, "example removed in favor of examples")
+		}
+		delete(s, "example")
+	}
+	return
+}
+
+// Returns raw schema s allowing null values.
+func nullableSchema(s map[string]interface{}) map[string]interface{} {
+	var _, ref = s["$ref"]
+	var _, typed = s["type"]
+	if ref || !typed && (s["allOf"] != nil || s["anyOf"] != nil || s["oneOf"] != nil) {
+		return map[string]interface{}{
+			"anyOf": []interface{}{s, map[string]interface{}{"type": "null"}},
+		}
+	}
+	switch t := s["type"].(type) {
+	case string:
+		if t != "null" {
+			s["type"] = []interface{}{t, "null"}
+		}
+	case []interface{}:
+		for _, item := range t {
+			if item == "null" {
+				return s
+			}
+		}
+		s["type"] = append(t, "null")
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		var found bool
+		for _, item := range enum {
+			found = found || item == nil
+		}
+		if !found {
+			s["enum"] = append(enum, nil)
+		}
+	}
+	return s
+}
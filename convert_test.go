@@ -0,0 +1,140 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"testing"
+)
+
+const testSwagger = `
+swagger: "2.0"
+info:
+  title: Pets
+  version: "1.0"
+  x-logo: pets.png
+host: api.example.com
+basePath: /v1
+schemes: [https, http]
+consumes: [application/json]
+produces: [application/json]
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - {name: tags, in: query, type: array, items: {type: string}, collectionFormat: multi}
+        - {name: fields, in: query, type: array, items: {type: string}, collectionFormat: tsv}
+      responses:
+        "200":
+          description: Pets
+          schema: {type: array, items: {$ref: "#/definitions/Pet"}}
+          headers:
+            X-Total: {type: integer}
+    post:
+      operationId: createPet
+      parameters:
+        - {$ref: "#/parameters/PetBody"}
+      responses:
+        default: {$ref: "#/responses/Error"}
+  /pets/{petId}/photo:
+    parameters:
+      - {name: petId, in: path, type: integer, format: int64}
+    put:
+      operationId: uploadPhoto
+      consumes: [multipart/form-data]
+      parameters:
+        - {name: file, in: formData, type: file, required: true}
+        - {name: labels, in: formData, type: array, items: {type: string}, collectionFormat: multi}
+      responses:
+        "204": {description: Uploaded}
+parameters:
+  PetBody:
+    name: pet
+    in: body
+    required: true
+    schema: {$ref: "#/definitions/Pet"}
+responses:
+  Error:
+    description: An error
+    schema:
+      type: object
+      properties:
+        message: {type: string}
+securityDefinitions:
+  oauth:
+    type: oauth2
+    flow: accessCode
+    authorizationUrl: https://example.com/auth
+    tokenUrl: https://example.com/token
+    scopes: {read: Read access}
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    discriminator: kind
+    properties:
+      name: {type: string, example: Rex}
+      kind: {type: string}
+      age: {type: integer, minimum: 0, exclusiveMinimum: true}
+      owner: {$ref: "#/definitions/Owner", x-nullable: true}
+      nickname: {type: string, x-nullable: true}
+  Owner:
+    type: object
+    properties:
+      name: {type: string}
+`
+
+func TestConvertV2(t *testing.T) {
+	var doc, warnings, err = ConvertV2([]byte(testSwagger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notes []string
+	for _, note := range warnings {
+		notes = append(notes, note.String())
+	}
+	if got, want := jsonString(t, notes), `["#/info/x-logo: specification extension dropped",`+
+		`"#/paths/~1pets/get/parameters/1: collectionFormat 'tsv' of a query parameter has no equivalent; using form"]`; got != want {
+		t.Errorf("warnings:\ngot  %s\nwant %s", got, want)
+	}
+
+	var checks = []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"servers", doc.Servers, `[{"url":"https://api.example.com/v1"},{"url":"http://api.example.com/v1"}]`},
+		{"list parameter", doc.Paths.Items["/pets"].Get.Parameters[0],
+			`{"explode":true,"in":"query","name":"tags","schema":{"items":{"type":"string"},"type":"array"},"style":"form"}`},
+		{"list response", doc.Paths.Items["/pets"].Get.Responses.Codes["200"],
+			`{"content":{"application/json":{"schema":{"items":{"$ref":"#/components/schemas/Pet"},"type":"array"}}},` +
+				`"description":"Pets","headers":{"X-Total":{"schema":{"type":"integer"}}}}`},
+		{"create body", doc.Paths.Items["/pets"].Post.RequestBody,
+			`{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/Pet"}}},"required":true}`},
+		{"create response", doc.Paths.Items["/pets"].Post.Responses.Default, `{"$ref":"#/components/responses/Error"}`},
+		{"upload parameters", doc.Paths.Items["/pets/{petId}/photo"].Parameters,
+			`[{"in":"path","name":"petId","required":true,"schema":{"format":"int64","type":"integer"}}]`},
+		{"upload body", doc.Paths.Items["/pets/{petId}/photo"].Put.RequestBody,
+			`{"content":{"multipart/form-data":{"schema":{"properties":{` +
+				`"file":{"contentMediaType":"application/octet-stream","type":"string"},` +
+				`"labels":{"items":{"type":"string"},"type":"array"}},"required":["file"],"type":"object"}}}}`},
+		{"pet", doc.Components.Schemas["Pet"],
+			`{"discriminator":{"propertyName":"kind"},"properties":{` +
+				`"age":{"exclusiveMinimum":0,"type":"integer"},` +
+				`"kind":{"type":"string"},` +
+				`"name":{"examples":["Rex"],"type":"string"},` +
+				`"nickname":{"type":["string","null"]},` +
+				`"owner":{"anyOf":[{"$ref":"#/components/schemas/Owner"},{"type":"null"}]}},` +
+				`"required":["name"],"type":"object"}`},
+		{"security", doc.Components.SecuritySchemes["oauth"],
+			`{"flows":{"authorizationCode":{"authorizationUrl":"https://example.com/auth",` +
+				`"scopes":{"read":"Read access"},"tokenUrl":"https://example.com/token"}},"type":"oauth2"}`},
+	}
+	for _, check := range checks {
+		if got := jsonString(t, check.v); got != check.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", check.name, got, check.want)
+		}
+	}
+}
@@ -0,0 +1,458 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// VersionV30 is the OpenAPI version of documents written by DowngradeV30.
+const VersionV30 = "3.0.3"
+
+// DowngradeV30 writes doc as an OpenAPI 3.0 document in JSON for consumers
+// that do not support 3.1 and returns it with a report of constructs that
+// could not be represented exactly.
+//
+// Schemas are rewritten for OpenAPI 3.0:
+//
+//   - A "null" type is nullable and multiple types are an anyOf of types.
+//     anyOf and oneOf with a null schema are nullable if the schema or
+//     each of the other schemas has a type, as nullable has no effect
+//     without one; otherwise the null schema is dropped.
+//   - examples is example, const is a single value enum and numeric
+//     exclusiveMinimum and exclusiveMaximum are booleans.
+//   - contentEncoding base64 is format byte and contentMediaType is format
+//     binary.
+//   - $ref with sibling keywords is an allOf with the reference.
+//   - Keywords that 3.0 does not support, such as $defs, if and
+//     patternProperties, are dropped.
+//
+// webhooks, Info.Summary, License.Identifier and Components.PathItems are
+// written as x-webhooks, x-summary, x-identifier and x-pathItems and path
+// items referencing Components.PathItems are inlined. jsonSchemaDialect and
+// mutualTLS security schemes, with requirements referencing them, are
+// dropped.
+func DowngradeV30(doc *OpenAPI) ([]byte, []*ConversionNote, error) {
+	var data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	var src map[string]interface{}
+	if err = json.Unmarshal(data, &src); err != nil {
+		return nil, nil, err
+	}
+	var c = &converter{}
+	c.downgradeV31(src)
+	if data, err = json.MarshalIndent(src, "", "  "); err != nil {
+		return nil, nil, err
+	}
+	return data, c.notes, nil
+}
+
+// v31Keywords are schema keywords that have no OpenAPI 3.0 equivalent.
+var v31Keywords = []string{
+	"$anchor", "$comment", "$defs", "$dynamicAnchor", "$dynamicRef", "$id",
+	"$schema", "$vocabulary", "contains", "contentSchema", "dependentRequired",
+	"dependentSchemas", "else", "if", "maxContains", "minContains",
+	"patternProperties", "prefixItems", "propertyNames", "then",
+	"unevaluatedItems", "unevaluatedProperties",
+}
+
+// Downgrades raw 3.1 document doc in place.
+func (c *converter) downgradeV31(doc map[string]interface{}) {
+	doc["openapi"] = VersionV30
+	if _, ok := doc["jsonSchemaDialect"]; ok {
+		delete(doc, "jsonSchemaDialect")
+		c.note("/jsonSchemaDialect", "jsonSchemaDialect cannot be represented; dropped")
+	}
+	var components = rawObject(doc["components"])
+	if items := rawObject(components["pathItems"]); items != nil {
+		doc["paths"] = inlinePathItems(doc["paths"], items)
+		doc["webhooks"] = inlinePathItems(doc["webhooks"], items)
+	}
+	walkSchemas(doc, c.downgradeSchema, downgradeMediaType)
+	c.prefix("", doc, "webhooks")
+	c.prefix("/info", rawObject(doc["info"]), "summary")
+	c.prefix("/info/license", rawObject(rawObject(doc["info"])["license"]), "identifier")
+	c.prefix("/components", components, "pathItems")
+	if doc["paths"] == nil {
+		doc["paths"] = map[string]interface{}{}
+	}
+	c.dropMutualTLS(doc)
+}
+
+// Renames key of object m at pointer with an "x-" prefix.
+func (c *converter) prefix(pointer string, m map[string]interface{}, key string) {
+	if value, ok := m[key]; ok && value != nil {
+		m["x-"+key] = value
+		c.note(pointer+"/"+key, "%s cannot be represented; written as x-%[1]s", key)
+	}
+	delete(m, key)
+}
+
+// Returns v, a map of path items, with references to items inlined.
+func inlinePathItems(v interface{}, items map[string]interface{}) interface{} {
+	var m = rawObject(v)
+	for key, value := range m {
+		var item = rawObject(value)
+		var ref, _ = item["$ref"].(string)
+		if !strings.HasPrefix(ref, componentsPrefix+"pathItems/") {
+			continue
+		}
+		var target = rawObject(rawCopy(items[unescapePointer(strings.TrimPrefix(ref, componentsPrefix+"pathItems/"))]))
+		if target == nil {
+			continue
+		}
+		for k, v := range item {
+			if k != "$ref" {
+				target[k] = v
+			}
+		}
+		m[key] = target
+	}
+	return v
+}
+
+// Returns a deep copy of raw value v.
+func rawCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		var m = make(map[string]interface{}, len(t))
+		for key, value := range t {
+			m[key] = rawCopy(value)
+		}
+		return m
+	case []interface{}:
+		var a = make([]interface{}, len(t))
+		for i, value := range t {
+			a[i] = rawCopy(value)
+		}
+		return a
+	}
+	return v
+}
+
+// Drops mutualTLS security schemes of doc and security requirements that
+// reference them.
+func (c *converter) dropMutualTLS(doc map[string]interface{}) {
+	var (
+		schemes = rawObject(rawObject(doc["components"])["securitySchemes"])
+		dropped = make(map[string]bool)
+	)
+	for _, name := range sortedKeys(schemes) {
+		if rawObject(schemes[name])["type"] == "mutualTLS" {
+			delete(schemes, name)
+			dropped[name] = true
+			c.note("/components/securitySchemes/"+escapePointer(name), "mutualTLS security scheme cannot be represented; dropped")
+		}
+	}
+	if len(dropped) == 0 {
+		return
+	}
+	c.filterSecurity("", doc, dropped)
+	var components = rawObject(doc["components"])
+	for _, section := range []struct {
+		pointer string
+		items   map[string]interface{}
+	}{
+		{"/paths", rawObject(doc["paths"])},
+		{"/x-webhooks", rawObject(doc["x-webhooks"])},
+		{"/components/x-pathItems", rawObject(components["x-pathItems"])},
+	} {
+		for _, key := range sortedKeys(section.items) {
+			c.filterPathItem(section.pointer+"/"+escapePointer(key), section.items[key], dropped)
+		}
+	}
+	var callbacks = rawObject(components["callbacks"])
+	for _, name := range sortedKeys(callbacks) {
+		c.filterCallback("/components/callbacks/"+escapePointer(name), callbacks[name], dropped)
+	}
+}
+
+// Drops security requirements of operations of raw path item v at pointer
+// and of their callbacks that reference dropped schemes.
+func (c *converter) filterPathItem(pointer string, v interface{}, dropped map[string]bool) {
+	var item = rawObject(v)
+	for _, method := range Methods {
+		var key = strings.ToLower(method)
+		var op = rawObject(item[key])
+		if op == nil {
+			continue
+		}
+		c.filterSecurity(pointer+"/"+key, op, dropped)
+		var callbacks = rawObject(op["callbacks"])
+		for _, name := range sortedKeys(callbacks) {
+			c.filterCallback(pointer+"/"+key+"/callbacks/"+escapePointer(name), callbacks[name], dropped)
+		}
+	}
+}
+
+// Drops security requirements of path items of raw callback v at pointer
+// that reference dropped schemes.
+func (c *converter) filterCallback(pointer string, v interface{}, dropped map[string]bool) {
+	var cb = rawObject(v)
+	for _, expr := range sortedKeys(cb) {
+		if !strings.HasPrefix(expr, "x-") {
+			c.filterPathItem(pointer+"/"+escapePointer(expr), cb[expr], dropped)
+		}
+	}
+}
+
+// Drops security requirements of object m at pointer that reference dropped
+// schemes.
+func (c *converter) filterSecurity(pointer string, m map[string]interface{}, dropped map[string]bool) {
+	var reqs, ok = m["security"].([]interface{})
+	if !ok {
+		return
+	}
+	var kept = []interface{}{}
+	for i, req := range reqs {
+		var uses bool
+		for name := range rawObject(req) {
+			uses = uses || dropped[name]
+		}
+		if uses {
+			c.note(fmt.Sprintf("%s/security/%d", pointer, i), "security requirement of a mutualTLS scheme dropped")
+			continue
+		}
+		kept = append(kept, req)
+	}
+	m["security"] = kept
+}
+
+// Adds a binary schema to a Media Type Object without a schema for a
+// non-JSON, non-form content type.
+func downgradeMediaType(pointer, contentType string, mt map[string]interface{}) {
+	if _, ok := mt["schema"]; !ok && !isJSONMediaType(contentType) &&
+		contentType != "application/x-www-form-urlencoded" && !strings.HasPrefix(contentType, "multipart/") {
+		mt["schema"] = map[string]interface{}{"type": "string", "format": "binary"}
+	}
+}
+
+// Returns true if raw schema v is a schema of the null type only.
+func isNullSchema(v interface{}) bool {
+	var s = rawObject(v)
+	if len(s) != 1 {
+		return false
+	}
+	switch t := s["type"].(type) {
+	case string:
+		return t == TypeNull
+	case []interface{}:
+		return len(t) == 1 && t[0] == TypeNull
+	}
+	return false
+}
+
+// Returns raw 3.1 schema v at pointer downgraded to an OpenAPI 3.0 schema.
+func (c *converter) downgradeSchema(pointer string, v interface{}) interface{} {
+	switch v {
+	case true:
+		return map[string]interface{}{}
+	case false:
+		return map[string]interface{}{"not": map[string]interface{}{}}
+	}
+	var s = rawObject(v)
+	if s == nil {
+		return v
+	}
+	var nullables []string
+	for _, key := range []string{"anyOf", "oneOf"} {
+		var (
+			list = rawList(s[key])
+			rest []interface{}
+		)
+		for _, sub := range list {
+			if !isNullSchema(sub) {
+				rest = append(rest, sub)
+			}
+		}
+		if len(list) == len(rest) || len(rest) == 0 {
+			continue
+		}
+		if isTypedSchemas(rest) {
+			for _, sub := range rest {
+				rawObject(sub)["nullable"] = true
+			}
+		} else {
+			nullables = append(nullables, key)
+		}
+		if len(rest) == 1 {
+			delete(s, key)
+			s["allOf"] = append(rawList(s["allOf"]), rest[0])
+		} else {
+			s[key] = rest
+		}
+	}
+	for _, key := range v31Keywords {
+		if _, ok := s[key]; ok {
+			delete(s, key)
+			c.note(pointer+"/"+escapePointer(key), "%s cannot be represented; dropped", key)
+		}
+	}
+	if ref, ok := s["$ref"]; ok && len(s) > 1 {
+		delete(s, "$ref")
+		s["allOf"] = append([]interface{}{map[string]interface{}{"$ref": ref}}, rawList(s["allOf"])...)
+	}
+	for _, key := range sortedKeys(s) {
+		var kp = pointer + "/" + escapePointer(key)
+		switch key {
+		case "properties":
+			var props = rawObject(s[key])
+			for _, name := range sortedKeys(props) {
+				props[name] = c.downgradeSchema(kp+"/"+escapePointer(name), props[name])
+			}
+		case "allOf", "anyOf", "oneOf":
+			for i, sub := range rawList(s[key]) {
+				rawList(s[key])[i] = c.downgradeSchema(fmt.Sprintf("%s/%d", kp, i), sub)
+			}
+		case "additionalProperties":
+			if _, ok := s[key].(bool); !ok {
+				s[key] = c.downgradeSchema(kp, s[key])
+			}
+		case "items", "not":
+			s[key] = c.downgradeSchema(kp, s[key])
+		}
+	}
+	c.downgradeType(pointer, s)
+	for _, key := range nullables {
+		if _, ok := s["type"].(string); ok {
+			s["nullable"] = true
+		} else {
+			c.note(pointer+"/"+key, "null schema of %s cannot be represented without a type; dropped", key)
+		}
+	}
+	if value, ok := s["const"]; ok {
+		if _, exists := s["enum"]; exists {
+			c.note(pointer+"/const", "const next to enum cannot be represented; dropped")
+		} else {
+			s["enum"] = []interface{}{value}
+		}
+		delete(s, "const")
+	}
+	if examples, ok := s["examples"].([]interface{}); ok {
+		if len(examples) > 0 {
+			if _, exists := s["example"]; !exists {
+				s["example"] = examples[0]
+				examples = examples[1:]
+			}
+		}
+		if len(examples) > 0 {
+			c.note(pointer+"/examples", "examples after the first cannot be represented; dropped")
+		}
+		delete(s, "examples")
+	}
+	for _, bound := range [][3]string{{"exclusiveMinimum", "minimum", ">"}, {"exclusiveMaximum", "maximum", "<"}} {
+		var exclusive, ok = s[bound[0]].(float64)
+		if !ok {
+			continue
+		}
+		if limit, ok := s[bound[1]].(float64); ok && (bound[2] == ">" && limit > exclusive || bound[2] == "<" && limit < exclusive) {
+			// The inclusive limit is stricter.
+			delete(s, bound[0])
+			continue
+		}
+		s[bound[1]], s[bound[0]] = exclusive, true
+	}
+	c.downgradeContent(pointer, s)
+	return s
+}
+
+// Rewrites type arrays of raw schema s at pointer as a type and nullable,
+// or an anyOf of types that are each nullable. A schema of the null type
+// only is a nullable string with a null enum.
+func (c *converter) downgradeType(pointer string, s map[string]interface{}) {
+	var (
+		types []interface{}
+		null  = s["nullable"] == true
+	)
+	switch t := s["type"].(type) {
+	case string:
+		types = []interface{}{t}
+	case []interface{}:
+		types = t
+	default:
+		return
+	}
+	var rest []interface{}
+	for _, t := range types {
+		if t == TypeNull {
+			null = true
+		} else {
+			rest = append(rest, t)
+		}
+	}
+	if !null && len(rest) == 1 {
+		s["type"] = rest[0]
+		return
+	}
+	delete(s, "type")
+	delete(s, "nullable")
+	switch len(rest) {
+	case 0:
+		s["type"], s["nullable"], s["enum"] = TypeString, true, []interface{}{nil}
+		c.note(pointer+"/type", "null type cannot be represented; written as a nullable string with a null enum")
+	case 1:
+		s["type"] = rest[0]
+		if null {
+			s["nullable"] = true
+		}
+	default:
+		var variants []interface{}
+		for _, t := range rest {
+			var variant = map[string]interface{}{"type": t}
+			if null {
+				variant["nullable"] = true
+			}
+			variants = append(variants, variant)
+		}
+		if _, exists := s["anyOf"]; exists {
+			s["allOf"] = append(rawList(s["allOf"]), map[string]interface{}{"anyOf": variants})
+		} else {
+			s["anyOf"] = variants
+		}
+	}
+}
+
+// Returns true if each raw schema of list is an inline schema with a type.
+func isTypedSchemas(list []interface{}) bool {
+	for _, v := range list {
+		var s = rawObject(v)
+		if _, ok := s["type"]; !ok {
+			return false
+		}
+		if _, ok := s["$ref"]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Rewrites contentEncoding and contentMediaType of raw schema s at pointer
+// as a format.
+func (c *converter) downgradeContent(pointer string, s map[string]interface{}) {
+	var encoding, hasEncoding = s["contentEncoding"]
+	var mediaType, hasMediaType = s["contentMediaType"]
+	delete(s, "contentEncoding")
+	delete(s, "contentMediaType")
+	switch {
+	case hasEncoding:
+		if encoding == "base64" {
+			s["format"] = "byte"
+		} else {
+			c.note(pointer+"/contentEncoding", "contentEncoding %v cannot be represented; dropped", encoding)
+		}
+		if hasMediaType {
+			c.note(pointer+"/contentMediaType", "contentMediaType of encoded content cannot be represented; dropped")
+		}
+	case hasMediaType:
+		s["format"] = "binary"
+		if mediaType != "application/octet-stream" {
+			c.note(pointer+"/contentMediaType", "contentMediaType %v written as format binary", mediaType)
+		}
+	}
+}
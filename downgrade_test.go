@@ -0,0 +1,185 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDowngradeV30(t *testing.T) {
+	var doc, err = FromJSON([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Pets", "summary": "Pet store", "version": "1.0", "license": {"name": "MIT", "identifier": "MIT"}},
+		"paths": {
+			"/pets": {"$ref": "#/components/pathItems/Pets"}
+		},
+		"webhooks": {
+			"newPet": {"post": {"responses": {"200": {"description": "OK"}}}}
+		},
+		"components": {
+			"schemas": {
+				"Pet": {
+					"type": "object",
+					"properties": {
+						"name": {"type": ["string", "null"], "examples": ["Rex", "Fido"]},
+						"kind": {"const": "dog"},
+						"age": {"type": "integer", "exclusiveMinimum": 0},
+						"id": {"type": ["string", "integer"]},
+						"owner": {"anyOf": [{"$ref": "#/components/schemas/Owner"}, {"type": "null"}]},
+						"nick": {"oneOf": [{"type": "string"}, {"type": "null"}]},
+						"code": {"type": ["string", "integer", "null"]},
+						"nothing": {"type": "null"},
+						"photo": {"type": "string", "contentEncoding": "base64"},
+						"tags": {"type": "array", "prefixItems": [{"type": "string"}]}
+					}
+				},
+				"Owner": {"type": "object"}
+			},
+			"pathItems": {
+				"Pets": {
+					"get": {
+						"responses": {
+							"200": {"description": "Pets", "content": {"image/png": {}}}
+						}
+					}
+				}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, notes, err := DowngradeV30(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err = json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	var components = rawObject(out["components"])
+	var checks = []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"openapi", out["openapi"], `"3.0.3"`},
+		{"info", out["info"], `{"license":{"name":"MIT","x-identifier":"MIT"},"title":"Pets","version":"1.0","x-summary":"Pet store"}`},
+		{"paths", out["paths"], `{"/pets":{"get":{"responses":{"200":{"content":{"image/png":` +
+			`{"schema":{"format":"binary","type":"string"}}},"description":"Pets"}}}}}`},
+		{"pet", rawObject(components["schemas"])["Pet"], `{"properties":{` +
+			`"age":{"exclusiveMinimum":true,"minimum":0,"type":"integer"},` +
+			`"code":{"anyOf":[{"nullable":true,"type":"string"},{"nullable":true,"type":"integer"}]},` +
+			`"id":{"anyOf":[{"type":"string"},{"type":"integer"}]},` +
+			`"kind":{"enum":["dog"]},` +
+			`"name":{"example":"Rex","nullable":true,"type":"string"},` +
+			`"nick":{"allOf":[{"nullable":true,"type":"string"}]},` +
+			`"nothing":{"enum":[null],"nullable":true,"type":"string"},` +
+			`"owner":{"allOf":[{"$ref":"#/components/schemas/Owner"}]},` +
+			`"photo":{"format":"byte","type":"string"},` +
+			`"tags":{"type":"array"}},"type":"object"}`},
+	}
+	for _, check := range checks {
+		if got := jsonString(t, check.v); got != check.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", check.name, got, check.want)
+		}
+	}
+	if _, ok := out["x-webhooks"]; !ok {
+		t.Error("webhooks not written as x-webhooks")
+	}
+	var report []string
+	for _, note := range notes {
+		report = append(report, note.String())
+	}
+	var want = `["#/components/schemas/Pet/properties/name/examples: examples after the first cannot be represented; dropped",` +
+		`"#/components/schemas/Pet/properties/nothing/type: null type cannot be represented; written as a nullable string with a null enum",` +
+		`"#/components/schemas/Pet/properties/owner/anyOf: null schema of anyOf cannot be represented without a type; dropped",` +
+		`"#/components/schemas/Pet/properties/tags/prefixItems: prefixItems cannot be represented; dropped",` +
+		`"#/webhooks: webhooks cannot be represented; written as x-webhooks",` +
+		`"#/info/summary: summary cannot be represented; written as x-summary",` +
+		`"#/info/license/identifier: identifier cannot be represented; written as x-identifier",` +
+		`"#/components/pathItems: pathItems cannot be represented; written as x-pathItems"]`
+	if got := jsonString(t, report); got != want {
+		t.Errorf("report:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestDowngradeV30Security(t *testing.T) {
+	var doc, err = FromJSON([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Pets", "version": "1.0"},
+		"security": [{"mtls": []}, {"key": []}],
+		"paths": {
+			"/pets": {
+				"post": {
+					"callbacks": {
+						"created": {
+							"{$request.body#/url}": {
+								"post": {"security": [{"mtls": [], "key": []}], "responses": {"200": {"description": "OK"}}}
+							}
+						}
+					},
+					"responses": {"200": {"description": "OK"}}
+				}
+			}
+		},
+		"webhooks": {
+			"newPet": {"post": {"security": [{"mtls": []}], "responses": {"200": {"description": "OK"}}}}
+		},
+		"components": {
+			"schemas": {
+				"Owner": {"type": "object"},
+				"Ref": {"$ref": "#/components/schemas/Owner", "$comment": "An owner."}
+			},
+			"securitySchemes": {
+				"mtls": {"type": "mutualTLS"},
+				"key": {"type": "apiKey", "name": "X-Key", "in": "header"}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, notes, err := DowngradeV30(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err = json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		callback = rawObject(rawObject(rawObject(rawObject(out["paths"])["/pets"])["post"])["callbacks"])["created"]
+		checks   = []struct {
+			name string
+			v    interface{}
+			want string
+		}{
+			{"security", out["security"], `[{"key":[]}]`},
+			{"webhook", rawObject(out["x-webhooks"])["newPet"], `{"post":{"responses":{"200":{"description":"OK"}},"security":[]}}`},
+			{"callback", callback, `{"{$request.body#/url}":{"post":{"responses":{"200":{"description":"OK"}},"security":[]}}}`},
+			{"ref", rawObject(rawObject(out["components"])["schemas"])["Ref"], `{"$ref":"#/components/schemas/Owner"}`},
+		}
+	)
+	for _, check := range checks {
+		if got := jsonString(t, check.v); got != check.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", check.name, got, check.want)
+		}
+	}
+	var report []string
+	for _, note := range notes {
+		report = append(report, note.String())
+	}
+	var want = `["#/components/schemas/Ref/$comment: $comment cannot be represented; dropped",` +
+		`"#/webhooks: webhooks cannot be represented; written as x-webhooks",` +
+		`"#/components/securitySchemes/mtls: mutualTLS security scheme cannot be represented; dropped",` +
+		`"#/security/0: security requirement of a mutualTLS scheme dropped",` +
+		`"#/paths/~1pets/post/callbacks/created/{$request.body#~1url}/post/security/0: security requirement of a mutualTLS scheme dropped",` +
+		`"#/x-webhooks/newPet/post/security/0: security requirement of a mutualTLS scheme dropped"]`
+	if got := jsonString(t, report); got != want {
+		t.Errorf("report:\ngot  %s\nwant %s", got, want)
+	}
+}
@@ -34,7 +34,9 @@ type Encoding struct {
 	// style is form, the default value is true. For all other styles, the
 	// default value is false. This property SHALL be ignored if the request
 	// body media type is not application/x-www-form-urlencoded.
-	Explode bool `json:"explode,omitempty"`
+	//
+	// A nil value means the default for the style is used.
+	Explode *bool `json:"explode,omitempty"`
 	// Determines whether the parameter value SHOULD allow reserved characters,
 	// as defined by RFC3986 :/?#[]@!$&'()*+,;= to be included without
 	// percent-encoding. The default value is false. This property SHALL be
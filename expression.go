@@ -0,0 +1,341 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidExpression is returned when parsing an invalid runtime expression.
+var ErrInvalidExpression = errors.New("invalid runtime expression")
+
+// Runtime expression sources.
+const (
+	ExpressionURL        = "$url"
+	ExpressionMethod     = "$method"
+	ExpressionStatusCode = "$statusCode"
+	ExpressionRequest    = "$request"
+	ExpressionResponse   = "$response"
+)
+
+// Runtime expression locations of request and response sources.
+const (
+	LocationHeader = "header"
+	LocationQuery  = "query"
+	LocationPath   = "path"
+	LocationBody   = "body"
+)
+
+// Expression is a parsed runtime expression used by Link and Callback Objects
+// to reference values of a HTTP request or response:
+//
+//   expression = ( "$url" / "$method" / "$statusCode" / "$request." source / "$response." source )
+//   source = ( header-reference / query-reference / path-reference / body-reference )
+//   header-reference = "header." token
+//   query-reference = "query." name
+//   path-reference = "path." name
+//   body-reference = "body" ["#" json-pointer ]
+type Expression struct {
+	// Source is one of the Expression* constants.
+	Source string
+	// Location is one of the Location* constants if Source is
+	// ExpressionRequest or ExpressionResponse.
+	Location string
+	// Name is the header, query or path parameter name.
+	Name string
+	// Pointer is the JSON pointer into the body, possibly empty.
+	Pointer string
+}
+
+// Parses a runtime expression.
+func ParseExpression(s string) (*Expression, error) {
+	switch s {
+	case ExpressionURL, ExpressionMethod, ExpressionStatusCode:
+		return &Expression{Source: s}, nil
+	}
+	var result = &Expression{}
+	switch {
+	case strings.HasPrefix(s, ExpressionRequest+"."):
+		result.Source = ExpressionRequest
+	case strings.HasPrefix(s, ExpressionResponse+"."):
+		result.Source = ExpressionResponse
+	default:
+		return nil, fmt.Errorf("%w: '%s'", ErrInvalidExpression, s)
+	}
+	var source = s[len(result.Source)+1:]
+	switch {
+	case strings.HasPrefix(source, LocationHeader+"."):
+		result.Location, result.Name = LocationHeader, source[len(LocationHeader)+1:]
+		if result.Name == "" || strings.IndexFunc(result.Name, func(r rune) bool { return !isTokenChar(r) }) >= 0 {
+			return nil, fmt.Errorf("%w: invalid header name in '%s'", ErrInvalidExpression, s)
+		}
+	case strings.HasPrefix(source, LocationQuery+"."):
+		result.Location, result.Name = LocationQuery, source[len(LocationQuery)+1:]
+	case strings.HasPrefix(source, LocationPath+"."):
+		result.Location, result.Name = LocationPath, source[len(LocationPath)+1:]
+	case source == LocationBody:
+		result.Location = LocationBody
+	case strings.HasPrefix(source, LocationBody+"#"):
+		result.Location, result.Pointer = LocationBody, source[len(LocationBody)+1:]
+		if err := checkPointer(result.Pointer); err != nil {
+			return nil, fmt.Errorf("%w: %v in '%s'", ErrInvalidExpression, err, s)
+		}
+	default:
+		return nil, fmt.Errorf("%w: invalid source in '%s'", ErrInvalidExpression, s)
+	}
+	return result, nil
+}
+
+// Returns true if r is a tchar as defined by RFC7230.
+func isTokenChar(r rune) bool {
+	return r < 0x80 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		strings.ContainsRune("!#$%&'*+-.^_`|~", r))
+}
+
+// Checks the syntax of a JSON pointer.
+func checkPointer(pointer string) error {
+	if pointer == "" {
+		return nil
+	}
+	if pointer[0] != '/' {
+		return fmt.Errorf("json pointer '%s' must start with '/'", pointer)
+	}
+	for i := 0; i < len(pointer); i++ {
+		if pointer[i] == '~' && (i+1 == len(pointer) || (pointer[i+1] != '0' && pointer[i+1] != '1')) {
+			return fmt.Errorf("invalid escape in json pointer '%s'", pointer)
+		}
+	}
+	return nil
+}
+
+// Returns the expression string.
+func (e *Expression) String() string {
+	switch e.Source {
+	case ExpressionRequest, ExpressionResponse:
+	default:
+		return e.Source
+	}
+	switch e.Location {
+	case LocationBody:
+		if e.Pointer != "" {
+			return e.Source + "." + LocationBody + "#" + e.Pointer
+		}
+		return e.Source + "." + LocationBody
+	}
+	return e.Source + "." + e.Location + "." + e.Name
+}
+
+// Exchange is a captured HTTP request and response pair against which
+// runtime expressions are evaluated.
+type Exchange struct {
+	// Request is the request. Its body is not read, RequestBody is used.
+	Request *http.Request
+	// RequestBody is the request body.
+	RequestBody []byte
+	// PathParams are values of path parameters of the request, as returned
+	// by Router.
+	PathParams map[string]string
+	// Response is the response, possibly nil. Its body is not read,
+	// ResponseBody is used.
+	Response *http.Response
+	// ResponseBody is the response body.
+	ResponseBody []byte
+
+	requestJSON, responseJSON interface{}
+}
+
+// Evaluates the expression against x.
+//
+// Header, query and path references evaluate to strings and are an empty
+// string if not present. A body reference evaluates to the body decoded from
+// JSON, or the body as a string if it is not JSON, or the value referenced by
+// the JSON pointer.
+func (e *Expression) Evaluate(x *Exchange) (interface{}, error) {
+	switch e.Source {
+	case ExpressionURL:
+		if x.Request == nil {
+			return nil, errors.New("no request")
+		}
+		return requestURL(x.Request), nil
+	case ExpressionMethod:
+		if x.Request == nil {
+			return nil, errors.New("no request")
+		}
+		return x.Request.Method, nil
+	case ExpressionStatusCode:
+		if x.Response == nil {
+			return nil, errors.New("no response")
+		}
+		return x.Response.StatusCode, nil
+	case ExpressionRequest:
+		if x.Request == nil {
+			return nil, errors.New("no request")
+		}
+		switch e.Location {
+		case LocationHeader:
+			return x.Request.Header.Get(e.Name), nil
+		case LocationQuery:
+			return x.Request.URL.Query().Get(e.Name), nil
+		case LocationPath:
+			return x.PathParams[e.Name], nil
+		case LocationBody:
+			return evaluateBody(x.RequestBody, &x.requestJSON, e.Pointer)
+		}
+	case ExpressionResponse:
+		if x.Response == nil {
+			return nil, errors.New("no response")
+		}
+		switch e.Location {
+		case LocationHeader:
+			return x.Response.Header.Get(e.Name), nil
+		case LocationQuery, LocationPath:
+			return nil, fmt.Errorf("%w: '%s' not available in a response", ErrInvalidExpression, e.Location)
+		case LocationBody:
+			return evaluateBody(x.ResponseBody, &x.responseJSON, e.Pointer)
+		}
+	}
+	return nil, fmt.Errorf("%w: '%s'", ErrInvalidExpression, e.String())
+}
+
+// Evaluates a body reference, caching the decoded body in cache.
+func evaluateBody(body []byte, cache *interface{}, pointer string) (interface{}, error) {
+	if *cache == nil {
+		if err := json.Unmarshal(body, cache); err != nil {
+			if pointer != "" {
+				return nil, fmt.Errorf("body is not json: %w", err)
+			}
+			return string(body), nil
+		}
+	}
+	return pointerGet(*cache, pointer)
+}
+
+// Returns the absolute URL of a request received by a server or sent by a
+// client.
+func requestURL(r *http.Request) string {
+	if r.URL.IsAbs() {
+		return r.URL.String()
+	}
+	var u = *r.URL
+	u.Host = r.Host
+	u.Scheme = "http"
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	return u.String()
+}
+
+// Evaluates s against x. If s is a runtime expression its value is returned.
+// If s contains expressions embedded in curly braces, as in
+// "http://example.com?id={$request.body#/id}", the string with embedded
+// expressions replaced by their values is returned. Otherwise s is returned
+// unmodified.
+func EvaluateString(s string, x *Exchange) (interface{}, error) {
+	if strings.HasPrefix(s, "$") {
+		var expr, err = ParseExpression(s)
+		if err != nil {
+			return nil, err
+		}
+		return expr.Evaluate(x)
+	}
+	return ExpandExpressions(s, x)
+}
+
+// Returns s with runtime expressions embedded in curly braces replaced by
+// their values formatted as strings.
+func ExpandExpressions(s string, x *Exchange) (string, error) {
+	var sb strings.Builder
+	for {
+		var start = strings.Index(s, "{$")
+		if start < 0 {
+			break
+		}
+		var end = strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%w: unterminated expression in '%s'", ErrInvalidExpression, s)
+		}
+		var expr, err = ParseExpression(s[start+1 : start+end])
+		if err != nil {
+			return "", err
+		}
+		value, err := expr.Evaluate(x)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(s[:start])
+		sb.WriteString(formatValue(value))
+		s = s[start+end+1:]
+	}
+	sb.WriteString(s)
+	return sb.String(), nil
+}
+
+// Formats a value decoded from JSON as a string.
+func formatValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(t)
+	case bool:
+		return strconv.FormatBool(t)
+	}
+	var data, err = json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// Evaluates link Parameters against x. String values that are runtime
+// expressions or contain embedded expressions are evaluated, other values are
+// returned as constants.
+func (l *Link) EvaluateParameters(x *Exchange) (map[string]interface{}, error) {
+	var result = make(map[string]interface{}, len(l.Parameters))
+	for name, v := range l.Parameters {
+		var value, err = evaluateConstant(v, x)
+		if err != nil {
+			return nil, fmt.Errorf("parameter '%s': %w", name, err)
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
+// Evaluates the link RequestBody against x. See EvaluateParameters.
+func (l *Link) EvaluateRequestBody(x *Exchange) (interface{}, error) {
+	return evaluateConstant(l.RequestBody, x)
+}
+
+// Evaluates v if it is a string holding an expression.
+func evaluateConstant(v interface{}, x *Exchange) (interface{}, error) {
+	if s, ok := v.(string); ok {
+		return EvaluateString(s, x)
+	}
+	return v, nil
+}
+
+// Evaluates callback expressions against x and returns the PathItems keyed by
+// the resulting callback URLs.
+func (c *Callback) Evaluate(x *Exchange) (map[string]*PathItem, error) {
+	var result = make(map[string]*PathItem, len(c.Expressions))
+	for _, key := range c.Keys() {
+		var value, err = EvaluateString(key, x)
+		if err != nil {
+			return nil, fmt.Errorf("callback '%s': %w", key, err)
+		}
+		result[formatValue(value)] = c.Expressions[key]
+	}
+	return result, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestExpressionEvaluate(t *testing.T) {
+	const body = `{
+		"failedUrl": "http://clientdomain.com/failed",
+		"successUrls": [
+			"http://clientdomain.com/fast",
+			"http://clientdomain.com/medium",
+			"http://clientdomain.com/slow"
+		]
+	}`
+	var req = httptest.NewRequest("POST", "http://example.org/subscribe/myevent?queryUrl=http://clientdomain.com/stillrunning", nil)
+	req.Header.Set("Content-Type", "application/json")
+	var x = &Exchange{
+		Request:     req,
+		RequestBody: []byte(body),
+		PathParams:  map[string]string{"eventType": "myevent"},
+		Response: &http.Response{
+			StatusCode: 201,
+			Header:     http.Header{"Location": {"http://example.org/subscription/1"}},
+		},
+	}
+	var tests = map[string]interface{}{
+		"$url":                         "http://example.org/subscribe/myevent?queryUrl=http://clientdomain.com/stillrunning",
+		"$method":                      "POST",
+		"$statusCode":                  201,
+		"$request.path.eventType":      "myevent",
+		"$request.query.queryUrl":      "http://clientdomain.com/stillrunning",
+		"$request.header.content-Type": "application/json",
+		"$request.body#/failedUrl":     "http://clientdomain.com/failed",
+		"$request.body#/successUrls/2": "http://clientdomain.com/slow",
+		"$response.header.Location":    "http://example.org/subscription/1",
+	}
+	for s, expected := range tests {
+		var expr, err = ParseExpression(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expr.String() != s {
+			t.Errorf("expected '%s' to format as itself, got '%s'", s, expr.String())
+		}
+		value, err := expr.Evaluate(x)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if !reflect.DeepEqual(value, expected) {
+			t.Errorf("%s: expected %v, got %v", s, expected, value)
+		}
+	}
+
+	s, err := ExpandExpressions("{$request.query.queryUrl}?status={$statusCode}", x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "http://clientdomain.com/stillrunning?status=201" {
+		t.Fatalf("unexpected expansion: %s", s)
+	}
+}
+
+func TestExpressionInvalid(t *testing.T) {
+	for _, s := range []string{
+		"$foo",
+		"$request",
+		"$request.cookie.id",
+		"$request.header.bad header",
+		"$request.body#nopointer",
+		"$request.body#/bad~2escape",
+		"$response.bodyx",
+	} {
+		if _, err := ParseExpression(s); err == nil {
+			t.Errorf("expected error for '%s'", s)
+		}
+	}
+}
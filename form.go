@@ -0,0 +1,687 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxMemory is the default maximum number of bytes of a form body a
+// FormDecoder keeps in memory.
+const DefaultMaxMemory = 32 << 20
+
+var (
+	// ErrUnsupportedMediaType is returned when a body of an unsupported media
+	// type is being decoded.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	// ErrPartTooLarge is returned when a urlencoded body or the non-file parts
+	// of a multipart body exceed the memory limit.
+	ErrPartTooLarge = errors.New("part too large")
+)
+
+// FormFile is a file part of a multipart body decoded by FormDecoder.
+//
+// Content of files up to the FormDecoder memory limit is kept in memory and
+// in a temporary file otherwise. Remove should be called when a FormFile is
+// no longer needed to remove the temporary file.
+type FormFile struct {
+	// Filename is the filename parameter of the part Content-Disposition.
+	Filename string
+	// ContentType is the effective content type of the part.
+	ContentType string
+	// Header is the header of the part.
+	Header textproto.MIMEHeader
+	// Size is the size of the content in bytes.
+	Size int64
+
+	content []byte
+	tmpfile string
+}
+
+// Returns a stream of file content.
+func (ff *FormFile) Open() (io.ReadCloser, error) {
+	if ff.tmpfile != "" {
+		return os.Open(ff.tmpfile)
+	}
+	return ioutil.NopCloser(bytes.NewReader(ff.content)), nil
+}
+
+// Removes the temporary file holding the content, if any.
+func (ff *FormFile) Remove() error {
+	if ff.tmpfile == "" {
+		return nil
+	}
+	var err = os.Remove(ff.tmpfile)
+	ff.tmpfile = ""
+	return err
+}
+
+// FormDecoder decodes "application/x-www-form-urlencoded" and "multipart"
+// request bodies described by a Media Type Object into values ready for
+// validation against the Media Type schema.
+//
+// Properties are decoded according to their schema and Encoding Object:
+// arrays and objects according to Encoding style and explode, JSON content
+// parsed into values, primitive values converted to the schema type and
+// binary parts returned as *FormFile or a value returned by FileHandler.
+type FormDecoder struct {
+	// MaxMemory is the maximum total number of bytes of a body kept in
+	// memory. Non-file parts of a multipart body must fit within it and file
+	// parts that exceed what remains of it are stored in temporary files. If
+	// zero DefaultMaxMemory is used.
+	MaxMemory int64
+	// FileHandler, if not nil, is called for each file part of a multipart
+	// body instead of buffering it to a *FormFile. The returned value is used
+	// as the property value. The part must be consumed before returning.
+	FileHandler func(name string, part *multipart.Part) (interface{}, error)
+
+	doc *OpenAPI
+}
+
+// Returns a new FormDecoder which resolves references against doc.
+func NewFormDecoder(doc *OpenAPI) *FormDecoder {
+	if doc == nil {
+		doc = &OpenAPI{}
+	}
+	return &FormDecoder{doc: doc}
+}
+
+// Returns the document references are resolved against, an empty document
+// for a FormDecoder not created by NewFormDecoder.
+func (fd *FormDecoder) document() *OpenAPI {
+	if fd.doc == nil {
+		return &OpenAPI{}
+	}
+	return fd.doc
+}
+
+// Returns the memory limit.
+func (fd *FormDecoder) maxMemory() int64 {
+	if fd.MaxMemory > 0 {
+		return fd.MaxMemory
+	}
+	return DefaultMaxMemory
+}
+
+// Decodes the body of r according to its Content-Type and mt.
+func (fd *FormDecoder) DecodeRequest(r *http.Request, mt *MediaType) (map[string]interface{}, error) {
+	var ct, params, err = mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedMediaType, err)
+	}
+	switch {
+	case ct == "application/x-www-form-urlencoded":
+		var data, err = ioutil.ReadAll(io.LimitReader(r.Body, fd.maxMemory()+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > fd.maxMemory() {
+			return nil, ErrPartTooLarge
+		}
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return nil, err
+		}
+		return fd.DecodeValues(values, mt)
+	case strings.HasPrefix(ct, "multipart/"):
+		if params["boundary"] == "" {
+			return nil, fmt.Errorf("%w: missing boundary", ErrUnsupportedMediaType)
+		}
+		return fd.DecodeMultipart(multipart.NewReader(r.Body, params["boundary"]), mt)
+	}
+	return nil, fmt.Errorf("%w: '%s'", ErrUnsupportedMediaType, ct)
+}
+
+// Decodes "application/x-www-form-urlencoded" values according to mt.
+// Values that are not described by the schema are returned as strings or
+// slices of strings if they occur more than once.
+func (fd *FormDecoder) DecodeValues(values url.Values, mt *MediaType) (map[string]interface{}, error) {
+	var schema, err = fd.schema(mt.Schema)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		result   = make(map[string]interface{})
+		consumed = make(map[string]bool)
+		props    = fd.document().objectProperties(schema)
+	)
+	for name, prop := range props {
+		if prop, err = fd.schema(prop); err != nil {
+			return nil, err
+		}
+		var value, ok, err = fd.decodeValue(name, prop, mt.Encoding[name], values, consumed)
+		if err != nil {
+			return nil, fmt.Errorf("property '%s': %w", name, err)
+		}
+		if ok {
+			result[name] = value
+		}
+	}
+	var additional, allowed = schemaAdditional(schema)
+	if additional, err = fd.schema(additional); err != nil {
+		return nil, err
+	}
+	for key, vals := range values {
+		if consumed[key] || !allowed {
+			continue
+		}
+		if additional == nil {
+			if len(vals) == 1 {
+				result[key] = vals[0]
+			} else {
+				result[key] = stringsToInterfaces(vals)
+			}
+			continue
+		}
+		var value, _, err = fd.decodeValue(key, additional, mt.Encoding[key], values, consumed)
+		if err != nil {
+			return nil, fmt.Errorf("property '%s': %w", key, err)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// Decodes a single property from url values.
+func (fd *FormDecoder) decodeValue(name string, schema *Schema, enc *Encoding, values url.Values, consumed map[string]bool) (interface{}, bool, error) {
+	var style, explode = encodingStyle(enc)
+	if enc != nil && isJSONMediaType(enc.ContentType) {
+		var raw, ok = values[name]
+		if !ok {
+			return nil, false, nil
+		}
+		consumed[name] = true
+		var result interface{}
+		if err := json.Unmarshal([]byte(raw[0]), &result); err != nil {
+			return nil, false, err
+		}
+		return result, true, nil
+	}
+	switch schema.PrimaryType() {
+	case TypeArray:
+		var raw, ok = values[name]
+		if !ok {
+			return nil, false, nil
+		}
+		consumed[name] = true
+		var items, err = fd.schema(schema.Items)
+		if err != nil {
+			return nil, false, err
+		}
+		if !explode || style != "form" {
+			raw = splitDelimited(raw, style)
+		}
+		var result = make([]interface{}, 0, len(raw))
+		for _, s := range raw {
+			var v, err = coerceString(items, s)
+			if err != nil {
+				return nil, false, err
+			}
+			result = append(result, v)
+		}
+		return result, true, nil
+	case TypeObject:
+		return fd.decodeObject(name, schema, style, explode, values, consumed)
+	}
+	var raw, ok = values[name]
+	if !ok {
+		return nil, false, nil
+	}
+	consumed[name] = true
+	var result, err = coerceString(schema, raw[0])
+	return result, err == nil, err
+}
+
+// Decodes an object property from url values.
+func (fd *FormDecoder) decodeObject(name string, schema *Schema, style string, explode bool, values url.Values, consumed map[string]bool) (interface{}, bool, error) {
+	var (
+		props  = fd.document().objectProperties(schema)
+		result = make(map[string]interface{})
+		pairs  = make(map[string]string)
+	)
+	switch {
+	case style == "deepObject":
+		var prefix = name + "["
+		for key, vals := range values {
+			if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, "]") {
+				continue
+			}
+			consumed[key] = true
+			var path = strings.Split(strings.TrimSuffix(strings.TrimPrefix(key, prefix), "]"), "][")
+			if err := fd.setDeep(result, schema, path, vals[0]); err != nil {
+				return nil, false, err
+			}
+		}
+		return result, len(result) > 0, nil
+	case style == "form" && explode:
+		for key := range props {
+			if vals, ok := values[key]; ok {
+				consumed[key] = true
+				pairs[key] = vals[0]
+			}
+		}
+	default:
+		var raw, ok = values[name]
+		if !ok {
+			return nil, false, nil
+		}
+		consumed[name] = true
+		var split = splitDelimited(raw[:1], style)
+		for i := 0; i+1 < len(split); i += 2 {
+			pairs[split[i]] = split[i+1]
+		}
+	}
+	for key, val := range pairs {
+		var prop, err = fd.schema(props[key])
+		if err != nil {
+			return nil, false, err
+		}
+		if result[key], err = coerceString(prop, val); err != nil {
+			return nil, false, fmt.Errorf("property '%s': %w", key, err)
+		}
+	}
+	return result, len(result) > 0, nil
+}
+
+// Sets a value in a nested map addressed by a deepObject key path.
+func (fd *FormDecoder) setDeep(m map[string]interface{}, schema *Schema, path []string, value string) error {
+	var prop, err = fd.schema(fd.document().objectProperties(schema)[path[0]])
+	if err != nil {
+		return err
+	}
+	if len(path) == 1 {
+		m[path[0]], err = coerceString(prop, value)
+		return err
+	}
+	var next, ok = m[path[0]].(map[string]interface{})
+	if !ok {
+		next = make(map[string]interface{})
+		m[path[0]] = next
+	}
+	return fd.setDeep(next, prop, path[1:], value)
+}
+
+// Decodes a multipart body according to mt.
+//
+// Parts are decoded according to their Content-Type, the Encoding Object
+// contentType or the default content type for the property schema. Parts
+// named after array properties are accumulated in a slice.
+func (fd *FormDecoder) DecodeMultipart(mr *multipart.Reader, mt *MediaType) (result map[string]interface{}, err error) {
+	var schema *Schema
+	if schema, err = fd.schema(mt.Schema); err != nil {
+		return nil, err
+	}
+	var (
+		props      = fd.document().objectProperties(schema)
+		budget     = fd.maxMemory()
+		additional *Schema
+		files      []*FormFile
+	)
+	defer func() {
+		if err != nil {
+			for _, file := range files {
+				file.Remove()
+			}
+		}
+	}()
+	additional, _ = schemaAdditional(schema)
+	if additional, err = fd.schema(additional); err != nil {
+		return nil, err
+	}
+	result = make(map[string]interface{})
+	for {
+		var part, err = mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var name = part.FormName()
+		if name == "" {
+			part.Close()
+			continue
+		}
+		var prop = additional
+		if p, ok := props[name]; ok {
+			if prop, err = fd.schema(p); err != nil {
+				return nil, err
+			}
+		}
+		var item = prop
+		if prop != nil && prop.PrimaryType() == TypeArray {
+			if item, err = fd.schema(prop.Items); err != nil {
+				return nil, err
+			}
+		}
+		value, err := fd.decodePart(name, part, item, mt.Encoding[name], &budget, &files)
+		part.Close()
+		if err != nil {
+			return nil, fmt.Errorf("part '%s': %w", name, err)
+		}
+		if prop != nil && prop.PrimaryType() == TypeArray {
+			var slice, _ = result[name].([]interface{})
+			result[name] = append(slice, value)
+			continue
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
+// Decodes a single multipart part.
+func (fd *FormDecoder) decodePart(name string, part *multipart.Part, schema *Schema, enc *Encoding, budget *int64, files *[]*FormFile) (interface{}, error) {
+	var ct = part.Header.Get("Content-Type")
+	if enc != nil {
+		if ct != "" && enc.ContentType != "" && !matchMediaRanges(enc.ContentType, ct) {
+			return nil, fmt.Errorf("%w: '%s' not in '%s'", ErrUnsupportedMediaType, ct, enc.ContentType)
+		}
+		if err := fd.checkPartHeaders(part, enc); err != nil {
+			return nil, err
+		}
+		if ct == "" && enc.ContentType != "" && !strings.ContainsAny(enc.ContentType, ",*") {
+			ct = enc.ContentType
+		}
+	}
+	if ct == "" {
+		ct = defaultPartContentType(schema)
+	}
+	var mediaType, _, err = mime.ParseMediaType(ct)
+	if err != nil {
+		mediaType = ct
+	}
+	if isFilePart(part, schema, mediaType) {
+		if fd.FileHandler != nil {
+			return fd.FileHandler(name, part)
+		}
+		var file, err = fd.spool(part, ct, budget)
+		if err != nil {
+			return nil, err
+		}
+		*files = append(*files, file)
+		return file, nil
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(part, *budget+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > *budget {
+		return nil, ErrPartTooLarge
+	}
+	*budget -= int64(len(data))
+	if isJSONMediaType(mediaType) {
+		var result interface{}
+		if err = json.Unmarshal(data, &result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	return coerceString(schema, string(data))
+}
+
+// Checks that required headers defined by enc are present in part.
+func (fd *FormDecoder) checkPartHeaders(part *multipart.Part, enc *Encoding) error {
+	for key, v := range enc.Headers {
+		if strings.EqualFold(key, "Content-Type") {
+			continue
+		}
+		var header *Header
+		if err := fd.document().resolve(v, &header); err != nil {
+			return err
+		}
+		if header != nil && header.Required && part.Header.Get(key) == "" {
+			return fmt.Errorf("missing required header '%s'", key)
+		}
+	}
+	return nil
+}
+
+// Reads a file part into memory or into a temporary file if it exceeds the
+// remaining memory budget.
+func (fd *FormDecoder) spool(part *multipart.Part, ct string, budget *int64) (*FormFile, error) {
+	var (
+		file = &FormFile{
+			Filename:    part.FileName(),
+			ContentType: ct,
+			Header:      part.Header,
+		}
+		buf    bytes.Buffer
+		n, err = io.CopyN(&buf, part, *budget+1)
+	)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if n <= *budget {
+		*budget -= n
+		file.content = buf.Bytes()
+		file.Size = n
+		return file, nil
+	}
+	tmp, err := ioutil.TempFile("", "openapi-form-")
+	if err != nil {
+		return nil, err
+	}
+	defer tmp.Close()
+	file.tmpfile = tmp.Name()
+	if n, err = io.Copy(tmp, io.MultiReader(&buf, part)); err != nil {
+		os.Remove(file.tmpfile)
+		return nil, err
+	}
+	file.Size = n
+	return file, nil
+}
+
+// Returns the resolved schema for v or nil if v is nil.
+func (fd *FormDecoder) schema(v interface{}) (*Schema, error) {
+	var result *Schema
+	if v == nil {
+		return nil, nil
+	}
+	if s, ok := v.(*Schema); ok && s == nil {
+		return nil, nil
+	}
+	if err := fd.document().resolve(v, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Returns properties of an object schema including properties of schemas
+// it is composed of using allOf. References are not resolved.
+func (o *OpenAPI) objectProperties(s *Schema) map[string]*Schema {
+	var result = make(map[string]*Schema)
+	o.collectProperties(s, result, make(map[*Schema]bool))
+	return result
+}
+
+// Collects properties of s and its allOf schemas into m.
+func (o *OpenAPI) collectProperties(s *Schema, m map[string]*Schema, seen map[*Schema]bool) {
+	if s == nil || seen[s] {
+		return
+	}
+	seen[s] = true
+	if s.Ref != "" {
+		var target *Schema
+		if err := o.resolve(s, &target); err == nil {
+			o.collectProperties(target, m, seen)
+		}
+	}
+	for _, sub := range s.AllOf {
+		o.collectProperties(sub, m, seen)
+	}
+	for name, prop := range s.Properties {
+		m[name] = prop
+	}
+}
+
+// Returns the additional properties schema of s and if additional properties
+// are allowed.
+func schemaAdditional(s *Schema) (*Schema, bool) {
+	if s == nil {
+		return nil, true
+	}
+	return s.AdditionalPropertiesSchema()
+}
+
+// Returns the style and explode values of an Encoding Object with defaults
+// applied.
+func encodingStyle(enc *Encoding) (style string, explode bool) {
+	if enc != nil {
+		style = enc.Style
+	}
+	if style == "" {
+		style = "form"
+	}
+	explode = style == "form"
+	if enc != nil && enc.Explode != nil {
+		explode = *enc.Explode
+	}
+	return
+}
+
+// Splits each value by the delimiter of style.
+func splitDelimited(values []string, style string) (result []string) {
+	var sep = ","
+	switch style {
+	case "spaceDelimited":
+		sep = " "
+	case "pipeDelimited":
+		sep = "|"
+	}
+	for _, v := range values {
+		result = append(result, strings.Split(v, sep)...)
+	}
+	return
+}
+
+// Converts a string to a value of the type described by schema. If schema
+// allows multiple types, they are tried in order and a string value is
+// returned if none match.
+func coerceString(schema *Schema, s string) (interface{}, error) {
+	if schema == nil {
+		return s, nil
+	}
+	var types = schema.Types()
+	if len(types) == 0 {
+		return s, nil
+	}
+	var err error
+	for _, t := range types {
+		switch t {
+		case TypeString:
+			return s, nil
+		case TypeInteger:
+			var i int64
+			if i, err = strconv.ParseInt(s, 10, 64); err == nil {
+				return i, nil
+			}
+		case TypeNumber:
+			var f float64
+			if f, err = strconv.ParseFloat(s, 64); err == nil {
+				return f, nil
+			}
+		case TypeBoolean:
+			var b bool
+			if b, err = strconv.ParseBool(s); err == nil {
+				return b, nil
+			}
+		case TypeNull:
+			if s == "" || s == "null" {
+				return nil, nil
+			}
+		case TypeObject, TypeArray:
+			var v interface{}
+			if err = json.Unmarshal([]byte(s), &v); err == nil {
+				return v, nil
+			}
+		}
+	}
+	if err == nil {
+		err = errors.New("type mismatch")
+	}
+	return nil, fmt.Errorf("invalid value '%s': %w", s, err)
+}
+
+// Returns the default content type of a multipart part for schema.
+func defaultPartContentType(schema *Schema) string {
+	switch {
+	case schema == nil:
+		return "text/plain"
+	case schema.IsBinary():
+		if schema.ContentMediaType != "" {
+			return schema.ContentMediaType
+		}
+		return "application/octet-stream"
+	case schema.PrimaryType() == TypeObject, schema.PrimaryType() == TypeArray:
+		return "application/json"
+	}
+	return "text/plain"
+}
+
+// Returns true if a part should be decoded as a file.
+func isFilePart(part *multipart.Part, schema *Schema, mediaType string) bool {
+	if schema != nil {
+		if schema.IsBinary() {
+			return true
+		}
+		if schema.PrimaryType() != "" {
+			return false
+		}
+	}
+	return part.FileName() != "" || !isTextMediaType(mediaType)
+}
+
+// Returns true if mediaType is a JSON media type.
+func isJSONMediaType(mediaType string) bool {
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// Returns true if mediaType is a textual media type.
+func isTextMediaType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		isJSONMediaType(mediaType) ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+// Returns true if mediaType matches any of comma separated media types or
+// media ranges in ranges.
+func matchMediaRanges(ranges, mediaType string) bool {
+	var mt, ok = ParseMediaRange(mediaType)
+	if !ok {
+		return false
+	}
+	for _, r := range strings.Split(ranges, ",") {
+		if mr, ok := ParseMediaRange(r); ok && mr.Includes(mt) {
+			return true
+		}
+	}
+	return false
+}
+
+// Converts a slice of strings to a slice of interfaces.
+func stringsToInterfaces(a []string) []interface{} {
+	var result = make([]interface{}, 0, len(a))
+	for _, s := range a {
+		result = append(result, s)
+	}
+	return result
+}
@@ -0,0 +1,306 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Returns a MediaType decoded from JSON data.
+func testMediaType(t *testing.T, data string) *MediaType {
+	var mt = &MediaType{}
+	if err := json.Unmarshal([]byte(data), mt); err != nil {
+		t.Fatal(err)
+	}
+	return mt
+}
+
+func TestFormDecoderDecodeValues(t *testing.T) {
+	const schema = `"schema": {"type": "object", "properties": {
+		"ids": {"type": "array", "items": {"type": "integer"}},
+		"color": {"type": "object", "properties": {"R": {"type": "integer"}, "G": {"type": "integer"}}},
+		"filter": {"type": "object", "properties": {
+			"name": {"type": "string"},
+			"age": {"type": "object", "properties": {"min": {"type": "integer"}}}
+		}},
+		"meta": {"type": "object"},
+		"count": {"type": "integer"}
+	}}`
+	var tests = []struct {
+		name, encoding, query, want string
+	}{
+		{"form explode", `{}`, "ids=1&ids=2", `{"ids":[1,2]}`},
+		{"form", `{"ids": {"explode": false}}`, "ids=1,2", `{"ids":[1,2]}`},
+		{"spaceDelimited", `{"ids": {"style": "spaceDelimited"}}`, "ids=1+2", `{"ids":[1,2]}`},
+		{"pipeDelimited", `{"ids": {"style": "pipeDelimited"}}`, "ids=1|2", `{"ids":[1,2]}`},
+		{"object explode", `{}`, "R=1&G=2", `{"color":{"G":2,"R":1}}`},
+		{"object", `{"color": {"explode": false}}`, "color=R,1,G,2", `{"color":{"G":2,"R":1}}`},
+		{"deepObject", `{"filter": {"style": "deepObject"}}`, "filter[name]=rex&filter[age][min]=3",
+			`{"filter":{"age":{"min":3},"name":"rex"}}`},
+		{"json", `{"meta": {"contentType": "application/json"}}`, `meta={"a":[1]}`, `{"meta":{"a":[1]}}`},
+		{"additional", `{}`, "count=3&x=a&x=b&y=c", `{"count":3,"x":["a","b"],"y":"c"}`},
+		{"invalid", `{}`, "count=three", `error`},
+	}
+	for _, test := range tests {
+		var (
+			mt          = testMediaType(t, `{`+schema+`, "encoding": `+test.encoding+`}`)
+			values, err = url.ParseQuery(test.query)
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got = "error"
+		if result, err := NewFormDecoder(&OpenAPI{}).DecodeValues(values, mt); err == nil {
+			got = jsonString(t, result)
+		}
+		if got != test.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", test.name, got, test.want)
+		}
+	}
+}
+
+// testPart is a part of a multipart body built by testMultipart.
+type testPart struct {
+	name, filename, contentType, content string
+	header                               map[string]string
+}
+
+// Returns a multipart body of parts and its content type.
+func testMultipart(t *testing.T, parts ...testPart) (*bytes.Buffer, string) {
+	var (
+		buf bytes.Buffer
+		mw  = multipart.NewWriter(&buf)
+	)
+	for _, part := range parts {
+		var header = make(textproto.MIMEHeader)
+		var disposition = `form-data; name="` + part.name + `"`
+		if part.filename != "" {
+			disposition += `; filename="` + part.filename + `"`
+		}
+		header.Set("Content-Disposition", disposition)
+		if part.contentType != "" {
+			header.Set("Content-Type", part.contentType)
+		}
+		for key, val := range part.header {
+			header.Set(key, val)
+		}
+		var w, err = mw.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(part.content))
+	}
+	mw.Close()
+	return &buf, mw.FormDataContentType()
+}
+
+// Returns result with *FormFile values replaced by their content.
+func testFormFiles(t *testing.T, result map[string]interface{}) map[string]interface{} {
+	for key, v := range result {
+		if file, ok := v.(*FormFile); ok {
+			var r, err = file.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := ioutil.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			result[key] = "file " + file.Filename + " " + file.ContentType + ": " + string(data)
+		}
+	}
+	return result
+}
+
+func TestFormDecoderDecodeMultipart(t *testing.T) {
+	var mt = testMediaType(t, `{
+		"schema": {"type": "object", "properties": {
+			"count": {"type": "integer"},
+			"meta": {"type": "object"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"file": {"type": "string", "format": "binary"},
+			"avatar": {"type": "string", "contentMediaType": "image/png"}
+		}},
+		"encoding": {
+			"avatar": {"contentType": "image/png, image/gif", "headers": {"X-Rate": {"required": true}}}
+		}
+	}`)
+	var avatar = map[string]string{"X-Rate": "1"}
+	var tests = []struct {
+		name      string
+		maxMemory int64
+		parts     []testPart
+		want      string
+	}{
+		{"values", 0, []testPart{
+			{name: "count", content: "3"},
+			{name: "meta", contentType: "application/json", content: `{"a":1}`},
+			{name: "tags", content: "a"},
+			{name: "tags", content: "b"},
+		}, `{"count":3,"meta":{"a":1},"tags":["a","b"]}`},
+		{"files", 0, []testPart{
+			{name: "file", filename: "a.bin", content: "abc"},
+			{name: "avatar", filename: "a.gif", contentType: "image/gif", content: "GIF", header: avatar},
+			{name: "other", filename: "b.txt", contentType: "text/plain", content: "text"},
+		}, `{"avatar":"file a.gif image/gif: GIF","file":"file a.bin application/octet-stream: abc",` +
+			`"other":"file b.txt text/plain: text"}`},
+		{"spooled", 4, []testPart{
+			{name: "file", filename: "a.bin", content: "abcdef"},
+			{name: "count", content: "1234"},
+		}, `{"count":1234,"file":"file a.bin application/octet-stream: abcdef"}`},
+		{"media type mismatch", 0, []testPart{
+			{name: "avatar", contentType: "image/jpeg", content: "JPG", header: avatar},
+		}, `error`},
+		{"missing header", 0, []testPart{
+			{name: "avatar", content: "PNG"},
+		}, `error`},
+		{"parts too large", 4, []testPart{
+			{name: "count", content: "12"},
+			{name: "tags", content: "abc"},
+		}, `error`},
+	}
+	for _, test := range tests {
+		var (
+			body, ct = testMultipart(t, test.parts...)
+			req      = httptest.NewRequest("POST", "/", body)
+			fd       = NewFormDecoder(&OpenAPI{})
+		)
+		req.Header.Set("Content-Type", ct)
+		fd.MaxMemory = test.maxMemory
+		var got = "error"
+		if result, err := fd.DecodeRequest(req, mt); err == nil {
+			got = jsonString(t, testFormFiles(t, result))
+			for _, v := range result {
+				if file, ok := v.(*FormFile); ok {
+					file.Remove()
+				}
+			}
+		}
+		if got != test.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFormDecoderV30(t *testing.T) {
+	var doc, err = FromYAML([]byte(`
+openapi: 3.0.3
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    post:
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                file: {type: string, format: binary}
+      responses:
+        "204": {description: Created}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		body   = rawObject(doc.Paths.Items["/pets"].Post.RequestBody)
+		mt     = testMediaType(t, jsonString(t, rawObject(body["content"])["multipart/form-data"]))
+		bb, ct = testMultipart(t, testPart{name: "file", content: "abc"})
+		req    = httptest.NewRequest("POST", "/pets", bb)
+	)
+	req.Header.Set("Content-Type", ct)
+	result, err := NewFormDecoder(doc).DecodeRequest(req, mt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := jsonString(t, testFormFiles(t, result)), `{"file":"file  application/octet-stream: abc"}`; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestFormDecoderConcurrent(t *testing.T) {
+	var (
+		fd = &FormDecoder{}
+		mt = testMediaType(t, `{"schema": {"type": "object", "properties": {"count": {"type": "integer"}}}}`)
+		wg sync.WaitGroup
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := fd.DecodeValues(url.Values{"count": {"1"}}, mt); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFormDecoderTempFiles(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "openapi-form-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var tmpdir = os.Getenv("TMPDIR")
+	os.Setenv("TMPDIR", dir)
+	defer os.Setenv("TMPDIR", tmpdir)
+
+	var (
+		mt = testMediaType(t, `{"schema": {"type": "object", "properties": {
+			"file": {"type": "string", "format": "binary"},
+			"count": {"type": "integer"}
+		}}}`)
+		fd    = &FormDecoder{MaxMemory: 2}
+		count = func() int {
+			var matches, _ = filepath.Glob(filepath.Join(dir, "openapi-form-*"))
+			return len(matches)
+		}
+	)
+	var body, ct = testMultipart(t, testPart{name: "file", filename: "a.bin", content: "abcdef"})
+	var _, params, _ = mime.ParseMediaType(ct)
+	result, err := fd.DecodeMultipart(multipart.NewReader(body, params["boundary"]), mt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count() != 1 {
+		t.Fatalf("got %d temporary files, want 1", count())
+	}
+	if err = result["file"].(*FormFile).Remove(); err != nil || count() != 0 {
+		t.Errorf("temporary file not removed: %v", err)
+	}
+
+	body, ct = testMultipart(t,
+		testPart{name: "file", filename: "a.bin", content: "abcdef"},
+		testPart{name: "count", content: "x"},
+	)
+	_, params, _ = mime.ParseMediaType(ct)
+	if _, err = fd.DecodeMultipart(multipart.NewReader(body, params["boundary"]), mt); err == nil {
+		t.Fatal("expected error")
+	}
+	if count() != 0 {
+		t.Errorf("temporary files of a failed decode not removed")
+	}
+
+	var req = httptest.NewRequest("POST", "/", strings.NewReader("a=1"))
+	req.Header.Set("Content-Type", "text/plain")
+	if _, err = fd.DecodeRequest(req, mt); !errors.Is(err, ErrUnsupportedMediaType) {
+		t.Errorf("got error %v, want ErrUnsupportedMediaType", err)
+	}
+}
@@ -2,4 +2,4 @@ module github.com/vedranvuk/openapi
 
 go 1.16
 
-require gopkg.in/yaml.v2 v2.4.0 // indirect
+require gopkg.in/yaml.v2 v2.4.0
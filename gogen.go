@@ -0,0 +1,975 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// generatedHeader is the first line of generated Go files.
+const generatedHeader = "// Code generated by openapi. DO NOT EDIT.\n\n"
+
+// goGenerator generates Go source code for schemas and operations of a
+// document. It is shared by the server, client and model generators.
+type goGenerator struct {
+	doc     *OpenAPI
+	pkg     string
+	imports map[string]bool
+	decls   strings.Builder
+	// types maps component schema names to Go type names.
+	types map[string]string
+	// idents are declared package level identifiers.
+	idents map[string]bool
+	// defined are declared type names that are not aliases.
+	defined map[string]bool
+}
+
+// Returns a new goGenerator of package pkg for doc. Reserved identifiers are
+// not used for generated types.
+func newGoGenerator(doc *OpenAPI, pkg string, reserved ...string) (*goGenerator, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name '%s'", pkg)
+	}
+	var g = &goGenerator{
+		doc:     doc,
+		pkg:     pkg,
+		imports: make(map[string]bool),
+		types:   make(map[string]string),
+		idents:  make(map[string]bool),
+		defined: make(map[string]bool),
+	}
+	for _, ident := range reserved {
+		g.idents[ident] = true
+	}
+	return g, nil
+}
+
+// Adds an import.
+func (g *goGenerator) use(path string) {
+	g.imports[path] = true
+}
+
+// Returns a unique package level identifier derived from name.
+func (g *goGenerator) ident(name string) string {
+	var result = uniqueName(goIdent(name), g.idents)
+	g.idents[result] = true
+	return result
+}
+
+// Writes formatted code to declarations.
+func (g *goGenerator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.decls, format, args...)
+}
+
+// Returns the formatted source of the generated file.
+func (g *goGenerator) source() ([]byte, error) {
+	var sb strings.Builder
+	sb.WriteString(generatedHeader)
+	fmt.Fprintf(&sb, "package %s\n\n", g.pkg)
+	if len(g.imports) > 0 {
+		var imports = make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			imports = append(imports, path)
+		}
+		sort.Strings(imports)
+		sb.WriteString("import (\n")
+		for _, path := range imports {
+			fmt.Fprintf(&sb, "\t%q\n", path)
+		}
+		sb.WriteString(")\n\n")
+	}
+	sb.WriteString(g.decls.String())
+	var src, err = format.Source([]byte(sb.String()))
+	if err != nil {
+		return nil, fmt.Errorf("format generated source: %w", err)
+	}
+	return src, nil
+}
+
+// Declares types of all component schemas in name order.
+func (g *goGenerator) declareComponents() error {
+	if g.doc.Components == nil {
+		return nil
+	}
+	var names = make([]string, 0, len(g.doc.Components.Schemas))
+	for name := range g.doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := g.componentType(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Converts v holding Schema Object | Reference Object to a Schema without
+// following references. Returns nil if v is nil.
+func (g *goGenerator) schema(v interface{}) (*Schema, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case *Schema:
+		return t, nil
+	}
+	var result = &Schema{}
+	if err := remarshal(v, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Returns the Go type of schema v which may be a Schema Object or a Reference
+// Object. Inline schemas that need a named type are declared using hint as
+// the type name.
+func (g *goGenerator) typeOf(v interface{}, hint string) (string, error) {
+	var s, err = g.schema(v)
+	if err != nil || s == nil {
+		return "interface{}", err
+	}
+	if s.Ref != "" {
+		if name := componentName(s.Ref, "schemas"); name != "" {
+			if target, ok := g.doc.Components.section("schemas")[name]; ok {
+				var t, err = g.componentType(name)
+				if err != nil {
+					return "", err
+				}
+				if ts, _ := g.schema(target); ts != nil && ts.IsNullable() {
+					t = pointerType(t)
+				}
+				return t, nil
+			}
+		}
+		var target *Schema
+		if err = g.doc.resolve(s, &target); err != nil {
+			return "", err
+		}
+		return g.typeOf(target, hint)
+	}
+	return g.schemaType(s, hint)
+}
+
+// Returns the Go type of a component schema, declaring it if needed.
+func (g *goGenerator) componentType(name string) (string, error) {
+	if t, ok := g.types[name]; ok {
+		return t, nil
+	}
+	var v, ok = g.doc.Components.section("schemas")[name]
+	if !ok {
+		return "", fmt.Errorf("%w: schema '%s'", ErrUnresolvedReference, name)
+	}
+	var goName = g.ident(name)
+	g.types[name] = goName
+	var s, err = g.schema(v)
+	if err != nil {
+		return "", err
+	}
+	if s == nil {
+		s = &Schema{}
+	}
+	if err = g.declareNamed(goName, s); err != nil {
+		return "", fmt.Errorf("schema '%s': %w", name, err)
+	}
+	return goName, nil
+}
+
+// Declares a type named name for s ignoring its nullability. Objects with
+// properties are declared as structs, oneOf schemas as unions and enums as
+// types with constants of their values.
+func (g *goGenerator) declareNamed(name string, s *Schema) error {
+	var doc = schemaDoc(name, s)
+	switch {
+	case g.isStruct(s):
+		g.defined[name] = true
+		var body, err = g.structBody(s, name)
+		if err != nil {
+			return err
+		}
+		g.printf("%stype %s struct {\n%s}\n\n", doc, name, body)
+		return nil
+	case len(unionVariants(s)) > 1:
+		g.defined[name] = true
+		return g.declareUnion(name, s)
+	case g.isEnum(s):
+		g.defined[name] = true
+		return g.declareEnum(name, s)
+	}
+	var t, err = g.schemaType(s, name+"Value")
+	if err != nil {
+		return err
+	}
+	if s.IsNullable() {
+		t = strings.TrimPrefix(t, "*")
+	}
+	if isAliasType(t) {
+		g.printf("%stype %s = %s\n\n", doc, name, t)
+		return nil
+	}
+	g.defined[name] = true
+	g.printf("%stype %s %s\n\n", doc, name, t)
+	return nil
+}
+
+// Returns true if Go type t should be declared as an alias rather than a
+// defined type as a defined type would lose its methods.
+func isAliasType(t string) bool {
+	return strings.HasPrefix(t, "*") || t == "interface{}" || strings.Contains(t, ".") ||
+		token.IsIdentifier(t) && unicode.IsUpper([]rune(t)[0])
+}
+
+// Returns the doc comment of a type declaration named name for s.
+func schemaDoc(name string, s *Schema) string {
+	var text = s.Description
+	if text == "" {
+		text = s.Title
+	}
+	if text == "" {
+		return ""
+	}
+	return goComment(text, "")
+}
+
+// Returns the Go identifier fragment of a response code, i.e. "200", "2XX"
+// or "Default".
+func codeIdent(code string) string {
+	if code == "default" {
+		return "Default"
+	}
+	return strings.ToUpper(code)
+}
+
+// Returns text as a Go comment with each line prefixed with indent.
+func goComment(text, indent string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			sb.WriteString(indent + "//\n")
+			continue
+		}
+		sb.WriteString(indent + "// " + line + "\n")
+	}
+	return sb.String()
+}
+
+// Returns true if s describes an object with properties that is generated
+// as a struct.
+func (g *goGenerator) isStruct(s *Schema) bool {
+	var t = schemaGoKind(s)
+	return (t == TypeObject || t == "") && len(g.doc.objectProperties(s)) > 0
+}
+
+// Returns the JSON type s describes, inferred from keywords if Type is not
+// set, or an empty string if unknown or if s allows multiple types.
+func schemaGoKind(s *Schema) string {
+	var types []string
+	for _, t := range s.Types() {
+		if t != TypeNull {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 1 {
+		return types[0]
+	}
+	if len(types) > 1 {
+		if len(types) == 2 && (types[0] == TypeInteger && types[1] == TypeNumber ||
+			types[0] == TypeNumber && types[1] == TypeInteger) {
+			return TypeNumber
+		}
+		return ""
+	}
+	var value = s.Const
+	if value == nil && len(s.Enum) > 0 {
+		value = s.Enum[0]
+	}
+	switch value.(type) {
+	case string:
+		return TypeString
+	case bool:
+		return TypeBoolean
+	case float64, int, int64:
+		return TypeNumber
+	}
+	switch {
+	case len(s.Properties) > 0 || s.AdditionalProperties != nil:
+		return TypeObject
+	case s.Items != nil:
+		return TypeArray
+	}
+	return ""
+}
+
+// Returns the Go type of a schema that is not a reference.
+func (g *goGenerator) schemaType(s *Schema, hint string) (string, error) {
+	var (
+		variants = unionVariants(s)
+		nullable = s.IsNullable() || len(variants) < len(s.OneOf)
+		t        string
+		err      error
+	)
+	switch {
+	case len(variants) == 1:
+		t, err = g.typeOf(variants[0], hint)
+	case len(s.AllOf) == 1 && s.Type == nil && len(s.Properties) == 0:
+		t, err = g.typeOf(s.AllOf[0], hint)
+	case len(variants) > 1 || g.isStruct(s) || g.isEnum(s):
+		t = g.ident(hint)
+		err = g.declareNamed(t, s)
+	case len(s.AnyOf) > 0:
+		t = "interface{}"
+	default:
+		t, err = g.kindType(s, hint)
+	}
+	if err != nil {
+		return "", err
+	}
+	if nullable {
+		t = pointerType(t)
+	}
+	return t, nil
+}
+
+// Returns the non nullable Go type of s that is not a struct, union or enum.
+func (g *goGenerator) kindType(s *Schema, hint string) (string, error) {
+	switch schemaGoKind(s) {
+	case TypeString:
+		switch s.Format {
+		case "date-time":
+			g.use("time")
+			return "time.Time", nil
+		case "byte":
+			return "[]byte", nil
+		}
+		if s.ContentEncoding == "base64" || s.IsBinary() {
+			return "[]byte", nil
+		}
+		return "string", nil
+	case TypeInteger:
+		switch s.Format {
+		case "int32":
+			return "int32", nil
+		case "int64":
+			return "int64", nil
+		}
+		return "int", nil
+	case TypeNumber:
+		if s.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case TypeBoolean:
+		return "bool", nil
+	case TypeArray:
+		var t, err = g.typeOf(s.Items, hint+"Item")
+		if err != nil {
+			return "", err
+		}
+		return "[]" + t, nil
+	case TypeObject, "":
+		if schemaGoKind(s) == "" && s.AdditionalProperties == nil {
+			return "interface{}", nil
+		}
+		var value, _ = s.AdditionalPropertiesSchema()
+		var t, err = g.typeOf(value, hint+"Value")
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + t, nil
+	}
+	return "interface{}", nil
+}
+
+// Returns oneOf schemas of s that are not a null type schema, such as
+// generated for pointers by SchemaFromType.
+func unionVariants(s *Schema) (result []*Schema) {
+	for _, sub := range s.OneOf {
+		if sub != nil && sub.Ref == "" && len(sub.Types()) == 1 && sub.HasType(TypeNull) {
+			continue
+		}
+		result = append(result, sub)
+	}
+	return
+}
+
+// Returns true if s is an enum of strings or numbers.
+func (g *goGenerator) isEnum(s *Schema) bool {
+	if len(s.Enum) == 0 || len(s.OneOf) > 0 || len(s.AllOf) > 0 {
+		return false
+	}
+	switch schemaGoKind(s) {
+	case TypeString:
+		return s.Format != "date-time" && s.Format != "byte" && !s.IsBinary()
+	case TypeInteger, TypeNumber:
+		return true
+	}
+	return false
+}
+
+// Declares an enum type named name with constants of enum values.
+func (g *goGenerator) declareEnum(name string, s *Schema) error {
+	var base, err = g.kindType(s, name)
+	if err != nil {
+		return err
+	}
+	g.printf("%stype %s %s\n\n", schemaDoc(name, s), name, base)
+	g.printf("// Values of %s.\nconst (\n", name)
+	for _, v := range s.Enum {
+		var literal string
+		switch t := v.(type) {
+		case string:
+			literal = strconv.Quote(t)
+		case float64:
+			if base != "float64" && base != "float32" && t != float64(int64(t)) {
+				continue
+			}
+			literal = formatValue(t)
+		case int, int64, json.Number:
+			literal = fmt.Sprint(t)
+		default:
+			continue
+		}
+		var suffix = formatValue(v)
+		if suffix == "" {
+			suffix = "Empty"
+		}
+		var constName = g.ident(name + " " + suffix)
+		g.printf("\t%s %s = %s\n", constName, name, literal)
+	}
+	g.printf(")\n\n")
+	return nil
+}
+
+// Declares a union type named name for a oneOf schema s.
+//
+// The union is a struct holding a value of a sealed interface implemented by
+// variant types. It implements json.Unmarshaler which selects the variant by
+// the discriminator property if s has a Discriminator, or the first variant
+// the data decodes into without unknown fields otherwise.
+func (g *goGenerator) declareUnion(name string, s *Schema) error {
+	g.use("bytes")
+	g.use("encoding/json")
+	g.use("fmt")
+	var (
+		iface  = g.ident(name + "Variant")
+		method = "is" + name
+		types  []string
+		byRef  = make(map[string]string)
+	)
+	for i, variant := range unionVariants(s) {
+		var t, err = g.typeOf(variant, fmt.Sprintf("%sOption%d", name, i+1))
+		if err != nil {
+			return err
+		}
+		t = strings.TrimPrefix(t, "*")
+		if !g.defined[t] {
+			var wrapper = g.ident(name + variantSuffix(t, variant))
+			if t == "interface{}" {
+				t = "json.RawMessage"
+			}
+			g.printf("// %s is a variant of %s.\ntype %s %s\n\n", wrapper, name, wrapper, t)
+			g.defined[wrapper] = true
+			t = wrapper
+		}
+		if containsString(types, t) {
+			continue
+		}
+		types = append(types, t)
+		if variant != nil && variant.Ref != "" {
+			byRef[variant.Ref] = t
+		}
+	}
+	var variants = strings.Join(types, ", ")
+	if len(types) > 1 {
+		variants = strings.Join(types[:len(types)-1], ", ") + " or " + types[len(types)-1]
+	}
+	var text = fmt.Sprintf("%s holds one of %s.", name, variants)
+	if doc := s.Description; doc != "" {
+		text = doc + "\n\n" + text
+	}
+	g.printf("%stype %s struct {\n\t// Value is the variant or nil.\n\tValue %s\n}\n\n", goComment(text, ""), name, iface)
+	g.printf("// %s is implemented by variants of %s.\ntype %s interface {\n\t%s()\n}\n\n", iface, name, iface, method)
+	for _, t := range types {
+		g.printf("func (%s) %s() {}\n\n", t, method)
+	}
+	g.printf("// MarshalJSON implements json.Marshaler.\n")
+	g.printf("func (u %s) MarshalJSON() ([]byte, error) {\n\treturn json.Marshal(u.Value)\n}\n\n", name)
+	g.printf("// UnmarshalJSON implements json.Unmarshaler.\n")
+	g.printf("func (u *%s) UnmarshalJSON(data []byte) error {\n", name)
+	g.printf("\tif bytes.Equal(bytes.TrimSpace(data), []byte(\"null\")) {\n\t\tu.Value = nil\n\t\treturn nil\n\t}\n")
+	if s.Discriminator != nil && s.Discriminator.PropertyName != "" {
+		var values, err = g.discriminatorValues(s.Discriminator, byRef)
+		if err != nil {
+			return err
+		}
+		g.printf("\tvar probe struct {\n\t\tValue string `json:%q`\n\t}\n", s.Discriminator.PropertyName)
+		g.printf("\tif err := json.Unmarshal(data, &probe); err != nil {\n\t\treturn err\n\t}\n")
+		g.printf("\tswitch probe.Value {\n")
+		for _, value := range values {
+			g.printf("\tcase %q:\n\t\tvar v %s\n", value[0], value[1])
+			g.printf("\t\tif err := json.Unmarshal(data, &v); err != nil {\n\t\t\treturn err\n\t\t}\n")
+			g.printf("\t\tu.Value = v\n")
+		}
+		g.printf("\tdefault:\n\t\treturn fmt.Errorf(\"unknown %s %s '%%s'\", probe.Value)\n\t}\n", name, s.Discriminator.PropertyName)
+		g.printf("\treturn nil\n}\n\n")
+		return nil
+	}
+	for _, t := range types {
+		g.printf("\t{\n\t\tvar v %s\n\t\tvar dec = json.NewDecoder(bytes.NewReader(data))\n", t)
+		g.printf("\t\tdec.DisallowUnknownFields()\n\t\tif dec.Decode(&v) == nil {\n")
+		g.printf("\t\t\tu.Value = v\n\t\t\treturn nil\n\t\t}\n\t}\n")
+	}
+	g.printf("\treturn fmt.Errorf(\"data matches no variant of %s\")\n}\n\n", name)
+	return nil
+}
+
+// Returns a type name suffix for a variant of Go type t that is not a named
+// type.
+func variantSuffix(t string, s *Schema) string {
+	switch {
+	case s != nil && s.Title != "":
+		return goIdent(s.Title)
+	case strings.HasPrefix(t, "[]"):
+		return "List"
+	case strings.HasPrefix(t, "map["):
+		return "Map"
+	case t == "interface{}":
+		return "Raw"
+	}
+	return goIdent(t)
+}
+
+// Returns discriminator values and Go types of variants in value order.
+// Variants not listed in the mapping are identified by their component name.
+func (g *goGenerator) discriminatorValues(d *Discriminator, byRef map[string]string) ([][2]string, error) {
+	var (
+		result [][2]string
+		mapped = make(map[string]bool)
+	)
+	for value, target := range d.Mapping {
+		var ref = target
+		if !strings.Contains(ref, "#") && !strings.Contains(ref, "/") {
+			ref = componentsPrefix + "schemas/" + escapePointer(target)
+		}
+		var t, ok = byRef[ref]
+		if !ok {
+			var err error
+			if t, err = g.typeOf(&Schema{Ref: ref}, ""); err != nil {
+				return nil, fmt.Errorf("discriminator mapping '%s': %w", value, err)
+			}
+		}
+		mapped[ref] = true
+		result = append(result, [2]string{value, strings.TrimPrefix(t, "*")})
+	}
+	for ref, t := range byRef {
+		if name := componentName(ref, "schemas"); name != "" && !mapped[ref] {
+			result = append(result, [2]string{name, t})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i][0] < result[j][0] })
+	return result, nil
+}
+
+// Returns t as a pointer type unless it is already nilable.
+func pointerType(t string) string {
+	if isNilable(t) {
+		return t
+	}
+	return "*" + t
+}
+
+// Returns true if Go type t can hold nil.
+func isNilable(t string) bool {
+	return strings.HasPrefix(t, "*") || strings.HasPrefix(t, "[]") ||
+		strings.HasPrefix(t, "map[") || t == "interface{}"
+}
+
+// Returns fields of a struct generated for an object schema s named name.
+// Properties that are not required are pointers and omitted if empty.
+func (g *goGenerator) structBody(s *Schema, name string) (string, error) {
+	var (
+		props    = g.doc.objectProperties(s)
+		required = make(map[string]bool)
+		keys     = make([]string, 0, len(props))
+		fields   = make(map[string]bool)
+		sb       strings.Builder
+	)
+	g.doc.collectRequired(s, required, make(map[*Schema]bool))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var prop = props[key]
+		var field = uniqueName(goIdent(key), fields)
+		fields[field] = true
+		var t, err = g.typeOf(prop, name+field)
+		if err != nil {
+			return "", fmt.Errorf("property '%s': %w", key, err)
+		}
+		var tag = key
+		if !required[key] {
+			t = pointerType(t)
+			tag += ",omitempty"
+		}
+		if prop != nil && prop.Description != "" {
+			sb.WriteString(goComment(prop.Description, "\t"))
+		}
+		fmt.Fprintf(&sb, "\t%s %s `json:%q`\n", field, t, tag)
+	}
+	return sb.String(), nil
+}
+
+// Collects required property names of s and its allOf schemas into m.
+func (o *OpenAPI) collectRequired(s *Schema, m map[string]bool, seen map[*Schema]bool) {
+	if s == nil || seen[s] {
+		return
+	}
+	seen[s] = true
+	if s.Ref != "" {
+		var target *Schema
+		if err := o.resolve(s, &target); err == nil {
+			o.collectRequired(target, m, seen)
+		}
+	}
+	for _, sub := range s.AllOf {
+		o.collectRequired(sub, m, seen)
+	}
+	for _, name := range s.Required {
+		m[name] = true
+	}
+}
+
+// goInitialisms are words written in upper case in Go identifiers.
+var goInitialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "LHS": true, "QPS": true,
+	"RAM": true, "RHS": true, "RPC": true, "SLA": true, "SMTP": true,
+	"SQL": true, "SSH": true, "TCP": true, "TLS": true, "TTL": true,
+	"UDP": true, "UI": true, "UID": true, "URI": true, "URL": true,
+	"UTF8": true, "UUID": true, "VM": true, "XML": true, "XSRF": true,
+	"XSS": true,
+}
+
+// Converts s to an exported Go identifier, splitting words on non
+// alphanumeric characters and case changes, i.e. "pet_id" and "petId" to
+// "PetID". An identifier that would start with a digit is prefixed with "X".
+func goIdent(s string) string {
+	var sb strings.Builder
+	for _, w := range identWords(s) {
+		if upper := strings.ToUpper(w); goInitialisms[upper] {
+			sb.WriteString(upper)
+			continue
+		} else if strings.HasSuffix(upper, "S") && goInitialisms[upper[:len(upper)-1]] {
+			sb.WriteString(upper[:len(upper)-1] + "s")
+			continue
+		}
+		var r = []rune(w)
+		sb.WriteRune(unicode.ToUpper(r[0]))
+		sb.WriteString(string(r[1:]))
+	}
+	var result = sb.String()
+	if result == "" {
+		return "X"
+	}
+	if unicode.IsDigit([]rune(result)[0]) {
+		return "X" + result
+	}
+	return result
+}
+
+// Converts s to a lower camel case identifier, i.e. "PetID" and "pet_id" to
+// "petID".
+func lowerIdent(s string) string {
+	var r = []rune(goIdent(s))
+	for i := 0; i < len(r) && unicode.IsUpper(r[i]); i++ {
+		if i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+			break
+		}
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
+
+// Returns words of s split on non alphanumeric characters and case changes.
+func identWords(s string) []string {
+	var (
+		words []string
+		word  []rune
+		prev  rune
+	)
+	var flush = func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	for _, r := range s {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+		prev = r
+	}
+	flush()
+	return words
+}
+
+// goOperation describes an operation for which Go code is generated.
+type goOperation struct {
+	// Name is the Go name of the operation.
+	Name      string
+	Method    string
+	Path      string
+	Operation *Operation
+	// Params are path item and operation parameters.
+	Params []*goParam
+	// Body is the request body or nil if none.
+	Body *goBody
+	// Responses are responses in key order, with default last.
+	Responses []*goResponse
+}
+
+// goParam is a parameter of a goOperation.
+type goParam struct {
+	*Parameter
+	// Field is the Go name of the parameter in the params struct.
+	Field string
+	// Type is the Go type of the field.
+	Type string
+	// Style is the parameter style, or "content" if the parameter is
+	// serialized as content of a media type.
+	Style   string
+	Explode bool
+	// Required is true for required and path parameters.
+	Required bool
+}
+
+// goBody is a request body of a goOperation.
+type goBody struct {
+	ContentType string
+	// Type is the Go type of a JSON body or an empty string if the body
+	// is not JSON.
+	Type string
+	// JSONTypes are the JSON media types of the body if Type is set.
+	JSONTypes []string
+	Required  bool
+}
+
+// goResponse is a response of a goOperation.
+type goResponse struct {
+	// Code is the status code, a range such as "2XX" or "default".
+	Code        string
+	Description string
+	// ContentType is the media type of the response body or an empty
+	// string if the response has no body.
+	ContentType string
+	// Type is the Go type of the body, "[]byte" if the body is not JSON.
+	Type string
+}
+
+// Returns operations of the document in path and Methods order.
+func (g *goGenerator) operations() ([]*goOperation, error) {
+	if g.doc.Paths == nil {
+		return nil, nil
+	}
+	var (
+		result []*goOperation
+		names  = make(map[string]bool)
+	)
+	for _, path := range g.doc.Paths.Keys() {
+		var item = g.doc.Paths.Items[path]
+		for _, method := range Methods {
+			var op = item.Operation(method)
+			if op == nil {
+				continue
+			}
+			var name = op.OperationID
+			if name == "" {
+				name = operationName(method, path)
+			}
+			var gop = &goOperation{
+				Name:      uniqueName(goIdent(name), names),
+				Method:    method,
+				Path:      path,
+				Operation: op,
+			}
+			names[gop.Name] = true
+			if err := g.operation(gop, item); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			result = append(result, gop)
+		}
+	}
+	return result, nil
+}
+
+// Fills params, body and responses of op.
+func (g *goGenerator) operation(op *goOperation, item *PathItem) error {
+	var params, err = g.doc.operationParameters(item, op.Operation)
+	if err != nil {
+		return err
+	}
+	var fields = make(map[string]bool)
+	for _, p := range params {
+		var gp = &goParam{Parameter: p}
+		gp.Field = uniqueName(goIdent(p.Name), fields)
+		fields[gp.Field] = true
+		gp.Style, gp.Explode = p.SerializationStyle()
+		var schema = p.Schema
+		if len(p.Content) > 0 {
+			var key = sortedContentKeys(p.Content)[0]
+			schema, gp.Style = p.Content[key].Schema, "content"
+		}
+		var t, err = g.typeOf(schema, op.Name+gp.Field+"Param")
+		if err != nil {
+			return fmt.Errorf("parameter '%s': %w", p.Name, err)
+		}
+		gp.Required = p.Required || p.In == "path"
+		if !gp.Required {
+			t = pointerType(t)
+		}
+		gp.Type = t
+		op.Params = append(op.Params, gp)
+	}
+	if op.Operation.RequestBody != nil {
+		var rb *RequestBody
+		if err := g.doc.resolve(op.Operation.RequestBody, &rb); err != nil {
+			return err
+		}
+		if key, mt := preferredContent(rb.Content); mt != nil {
+			op.Body = &goBody{ContentType: key, Required: rb.Required}
+			if isJSONMediaType(key) {
+				var t, err = g.typeOf(mt.Schema, op.Name+"RequestBody")
+				if err != nil {
+					return fmt.Errorf("request body: %w", err)
+				}
+				op.Body.Type = t
+				for _, ct := range sortedContentKeys(rb.Content) {
+					if isJSONMediaType(ct) {
+						op.Body.JSONTypes = append(op.Body.JSONTypes, ct)
+					}
+				}
+			}
+		}
+	}
+	if op.Operation.Responses == nil {
+		return nil
+	}
+	var keys = op.Operation.Responses.Keys()
+	if op.Operation.Responses.Default != nil {
+		keys = append(keys, "default")
+	}
+	for _, key := range keys {
+		var v interface{}
+		if key == "default" {
+			v = op.Operation.Responses.Default
+		} else {
+			v = op.Operation.Responses.Codes[key]
+		}
+		var resp *Response
+		if err := g.doc.resolve(v, &resp); err != nil {
+			return err
+		}
+		var gr = &goResponse{Code: strings.ToUpper(key), Description: resp.Description}
+		if key == "default" {
+			gr.Code = key
+		}
+		if ct, mt := preferredContent(resp.Content); mt != nil {
+			gr.ContentType, gr.Type = ct, "[]byte"
+			if isJSONMediaType(ct) {
+				var t, err = g.typeOf(mt.Schema, op.Name+codeIdent(gr.Code)+"Response")
+				if err != nil {
+					return fmt.Errorf("response '%s': %w", key, err)
+				}
+				gr.Type = t
+			}
+		}
+		op.Responses = append(op.Responses, gr)
+	}
+	return nil
+}
+
+// Returns resolved parameters of item and op. Operation parameters override
+// path item parameters of the same name and location.
+func (o *OpenAPI) operationParameters(item *PathItem, op *Operation) ([]*Parameter, error) {
+	var params []*Parameter
+	for _, list := range [][]interface{}{item.Parameters, op.Parameters} {
+		for _, v := range list {
+			var p *Parameter
+			if err := o.resolve(v, &p); err != nil {
+				return nil, err
+			}
+			var replaced bool
+			for i, existing := range params {
+				if existing.Name == p.Name && existing.In == p.In {
+					params[i], replaced = p, true
+				}
+			}
+			if !replaced {
+				params = append(params, p)
+			}
+		}
+	}
+	return params, nil
+}
+
+// Returns the first JSON media type of content or the first media type if
+// content has no JSON media types.
+func preferredContent(content map[string]*MediaType) (string, *MediaType) {
+	var keys = sortedContentKeys(content)
+	for _, key := range keys {
+		if isJSONMediaType(key) {
+			return key, content[key]
+		}
+	}
+	if len(keys) == 0 {
+		return "", nil
+	}
+	return keys[0], content[keys[0]]
+}
+
+// Declares the params struct of op and returns its name.
+func (g *goGenerator) declareParams(op *goOperation) string {
+	var name = g.ident(op.Name + "Params")
+	g.printf("// %s holds parameters of %s.\ntype %s struct {\n", name, op.Name, name)
+	for _, p := range op.Params {
+		var text = p.Description
+		if text == "" {
+			text = fmt.Sprintf("%s is the '%s' %s parameter.", p.Field, p.Name, p.In)
+		}
+		g.printf("%s\t%s %s\n", goComment(text, "\t"), p.Field, p.Type)
+	}
+	g.printf("}\n\n")
+	return name
+}
+
+// Returns the doc comment of an operation method that verb the operation
+// request.
+func operationDoc(op *goOperation, verb, indent string) string {
+	var text = fmt.Sprintf("%s %s %s %s.", op.Name, verb, op.Method, op.Path)
+	for _, s := range []string{op.Operation.Summary, op.Operation.Description} {
+		if s != "" {
+			text += "\n\n" + s
+		}
+	}
+	if op.Operation.Deprecated {
+		text += "\n\nDeprecated: the operation is deprecated."
+	}
+	return goComment(text, indent)
+}
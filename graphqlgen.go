@@ -0,0 +1,683 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GraphQLManifest maps a GraphQL schema generated by GenerateGraphQL back to
+// the document it was generated from.
+type GraphQLManifest struct {
+	// Resolvers of Query and Mutation fields in schema order.
+	Resolvers []*GraphQLResolver `json:"resolvers"`
+	// Unions of the schema in name order.
+	Unions []*GraphQLUnion `json:"unions,omitempty"`
+	// Fields maps type names to names of fields that differ from the names
+	// of the properties they hold, which are not valid GraphQL names, to the
+	// property names.
+	Fields map[string]map[string]string `json:"fields,omitempty"`
+}
+
+// GraphQLResolver maps a Query or Mutation field to the operation it
+// resolves.
+type GraphQLResolver struct {
+	// Type is "Query" or "Mutation".
+	Type  string `json:"type"`
+	Field string `json:"field"`
+	// OperationID is empty if the operation defines no id.
+	OperationID string `json:"operationId,omitempty"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	// Arguments of the field that hold parameters.
+	Arguments []*GraphQLArgument `json:"arguments,omitempty"`
+	// Body is the name of the argument holding the request body of
+	// ContentType, if any.
+	Body        string `json:"body,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// GraphQLArgument maps an argument of a field to an operation parameter.
+type GraphQLArgument struct {
+	Name      string `json:"name"`
+	Parameter string `json:"parameter"`
+	In        string `json:"in"`
+}
+
+// GraphQLUnion describes how the member type of a union value is resolved.
+type GraphQLUnion struct {
+	Name string `json:"name"`
+	// PropertyName is the discriminator property or empty if the schema of
+	// the union has no discriminator.
+	PropertyName string `json:"propertyName,omitempty"`
+	// Mapping maps discriminator values to member type names.
+	Mapping map[string]string `json:"mapping,omitempty"`
+}
+
+// GenerateGraphQL generates a GraphQL schema in SDL of doc and a manifest
+// mapping its fields to operations of doc.
+//
+// Component schemas are mapped as follows:
+//
+//   - Objects with properties are object types and, where they are used as
+//     arguments, input types with an "Input" suffix. Required properties
+//     that are not nullable are non-null fields.
+//   - oneOf schemas whose variants are all references to objects are
+//     unions. The discriminator of the schema is recorded in the manifest.
+//   - String enums whose values are valid GraphQL names are enums.
+//   - Other schemas are not declared and are expanded where referenced.
+//
+// Strings with date-time format map to a DateTime scalar, integers that are
+// not int32 to a BigInt scalar and schemas with no GraphQL equivalent, such
+// as maps and unions in input types, to a JSON scalar.
+//
+// GET operations are fields of Query and other operations are fields of
+// Mutation, named after their operation id in lower camel case. Parameters
+// are arguments and the JSON request body is an "input" argument. The field
+// type is that of the first success response, or the default response. It
+// is String for non-JSON content and Boolean if the response has no content.
+func GenerateGraphQL(doc *OpenAPI) ([]byte, *GraphQLManifest, error) {
+	var g = newGraphQLGenerator(doc)
+	if err := g.declareComponents(); err != nil {
+		return nil, nil, err
+	}
+	if err := g.declareOperations(); err != nil {
+		return nil, nil, err
+	}
+	return g.source(), g.manifest, nil
+}
+
+// graphqlGenerator generates a GraphQL schema of a document.
+type graphqlGenerator struct {
+	doc      *OpenAPI
+	manifest *GraphQLManifest
+	// decls are type declarations by type name.
+	decls map[string]string
+	// roots are Query and Mutation fields by type.
+	roots map[string]*strings.Builder
+	// outputs and inputs map component schema names to type names, or to
+	// an empty string for schemas that are expanded where referenced.
+	outputs map[string]string
+	inputs  map[string]string
+	// expanding are names of components being expanded.
+	expanding map[string]bool
+	idents    map[string]bool
+	scalars   map[string]bool
+}
+
+// graphqlScalars are descriptions of custom scalars by name.
+var graphqlScalars = map[string]string{
+	"BigInt":   "An integer that may not fit 32 bits.",
+	"DateTime": "An RFC 3339 date-time string.",
+	"JSON":     "An arbitrary JSON value.",
+}
+
+// graphqlName matches valid GraphQL names.
+var graphqlName = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// Returns a new graphqlGenerator for doc.
+func newGraphQLGenerator(doc *OpenAPI) *graphqlGenerator {
+	var g = &graphqlGenerator{
+		doc:       doc,
+		manifest:  &GraphQLManifest{Resolvers: []*GraphQLResolver{}},
+		decls:     make(map[string]string),
+		roots:     make(map[string]*strings.Builder),
+		outputs:   make(map[string]string),
+		inputs:    make(map[string]string),
+		expanding: make(map[string]bool),
+		idents:    make(map[string]bool),
+		scalars:   make(map[string]bool),
+	}
+	for _, name := range []string{"Query", "Mutation", "Subscription", "String", "Int", "Float", "Boolean", "ID"} {
+		g.idents[name] = true
+	}
+	for name := range graphqlScalars {
+		g.idents[name] = true
+	}
+	return g
+}
+
+// Returns a unique type name derived from name.
+func (g *graphqlGenerator) ident(name string) string {
+	var result = uniqueName(goIdent(name), g.idents)
+	g.idents[result] = true
+	return result
+}
+
+// Returns a custom scalar, marking it used.
+func (g *graphqlGenerator) scalar(name string) string {
+	g.scalars[name] = true
+	return name
+}
+
+// Returns the generated schema.
+func (g *graphqlGenerator) source() []byte {
+	var (
+		sb    strings.Builder
+		names []string
+	)
+	for name := range g.scalars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%sscalar %s\n\n", graphqlDescription(graphqlScalars[name], ""), name)
+	}
+	for _, root := range []string{"Query", "Mutation"} {
+		if fields := g.roots[root]; fields != nil {
+			fmt.Fprintf(&sb, "type %s {\n%s}\n\n", root, fields.String())
+		}
+	}
+	names = names[:0]
+	for name := range g.decls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sb.WriteString(g.decls[name] + "\n")
+	}
+	return []byte(strings.TrimRight(sb.String(), "\n") + "\n")
+}
+
+// Converts v holding Schema Object | Reference Object to a Schema without
+// following references. Returns nil if v is nil.
+func (g *graphqlGenerator) schema(v interface{}) (*Schema, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case *Schema:
+		return t, nil
+	}
+	var result = &Schema{}
+	if err := remarshal(v, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Returns true if s is generated as an object type.
+func (g *graphqlGenerator) isObject(s *Schema) bool {
+	var kind = schemaGoKind(s)
+	return len(unionVariants(s)) < 2 && (kind == TypeObject || kind == "") &&
+		len(g.doc.objectProperties(s)) > 0
+}
+
+// Returns true if s is generated as an enum.
+func isGraphQLEnum(s *Schema) bool {
+	if !isProtoEnum(s) {
+		return false
+	}
+	for _, v := range s.Enum {
+		var value, ok = v.(string)
+		if !ok || !graphqlName.MatchString(value) || value == "true" || value == "false" || value == "null" {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns component names of variants of s if s is generated as a union.
+func (g *graphqlGenerator) unionMembers(s *Schema) []string {
+	var variants = unionVariants(s)
+	if len(variants) < 2 || g.doc.Components == nil {
+		return nil
+	}
+	var result []string
+	for _, variant := range variants {
+		var name = componentName(variant.Ref, "schemas")
+		if name == "" {
+			return nil
+		}
+		var target, err = g.schema(g.doc.Components.Schemas[name])
+		if err != nil || target == nil || target.Ref != "" || !g.isObject(target) {
+			return nil
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+// Assigns type names to component schemas and declares them in name order.
+func (g *graphqlGenerator) declareComponents() error {
+	if g.doc.Components == nil {
+		return nil
+	}
+	var (
+		names   = make([]string, 0, len(g.doc.Components.Schemas))
+		schemas = make(map[string]*Schema)
+	)
+	for name := range g.doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var s, err = g.schema(g.doc.Components.Schemas[name])
+		if err != nil {
+			return fmt.Errorf("schema '%s': %w", name, err)
+		}
+		if s == nil || s.Ref != "" || !g.isObject(s) && !isGraphQLEnum(s) && g.unionMembers(s) == nil {
+			g.outputs[name] = ""
+			continue
+		}
+		schemas[name] = s
+		g.outputs[name] = g.ident(name)
+	}
+	for _, name := range names {
+		var s = schemas[name]
+		if s == nil {
+			continue
+		}
+		if err := g.declare(g.outputs[name], s, false); err != nil {
+			return fmt.Errorf("schema '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Declares type name of s which is an object, union or enum. Objects are
+// declared as input types if input is true.
+func (g *graphqlGenerator) declare(name string, s *Schema, input bool) error {
+	var sb strings.Builder
+	sb.WriteString(graphqlDescription(s.Description, ""))
+	switch {
+	case isGraphQLEnum(s):
+		fmt.Fprintf(&sb, "enum %s {\n", name)
+		for _, v := range s.Enum {
+			fmt.Fprintf(&sb, "  %s\n", v)
+		}
+		sb.WriteString("}\n")
+	case len(unionVariants(s)) > 1:
+		var (
+			members = g.unionMembers(s)
+			types   = make([]string, len(members))
+			union   = &GraphQLUnion{Name: name}
+		)
+		for i, member := range members {
+			types[i] = g.outputs[member]
+		}
+		if d := s.Discriminator; d != nil {
+			union.PropertyName = d.PropertyName
+			union.Mapping = make(map[string]string)
+			for _, member := range members {
+				union.Mapping[member] = g.outputs[member]
+			}
+			for value, target := range d.Mapping {
+				var member = componentName(target, "schemas")
+				if member == "" && !strings.Contains(target, "/") {
+					member = target
+				}
+				var t = g.outputs[member]
+				if t == "" {
+					return fmt.Errorf("discriminator mapping '%s': '%s' is not a member", value, target)
+				}
+				union.Mapping[value] = t
+			}
+		}
+		g.manifest.Unions = append(g.manifest.Unions, union)
+		sort.Slice(g.manifest.Unions, func(i, j int) bool {
+			return g.manifest.Unions[i].Name < g.manifest.Unions[j].Name
+		})
+		fmt.Fprintf(&sb, "union %s = %s\n", name, strings.Join(types, " | "))
+	default:
+		var body, err = g.objectBody(name, s, input)
+		if err != nil {
+			return err
+		}
+		var keyword = "type"
+		if input {
+			keyword = "input"
+		}
+		fmt.Fprintf(&sb, "%s %s {\n%s}\n", keyword, name, body)
+	}
+	g.decls[name] = sb.String()
+	return nil
+}
+
+// Returns fields of object type name for the properties of s.
+func (g *graphqlGenerator) objectBody(name string, s *Schema, input bool) (string, error) {
+	var (
+		props    = g.doc.objectProperties(s)
+		required = make(map[string]bool)
+		keys     = make([]string, 0, len(props))
+		fields   = make(map[string]bool)
+		sb       strings.Builder
+	)
+	g.doc.collectRequired(s, required, make(map[*Schema]bool))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var prop = props[key]
+		var t, err = g.typeOf(prop, name+goIdent(key), input)
+		if err != nil {
+			return "", fmt.Errorf("property '%s': %w", key, err)
+		}
+		if required[key] {
+			t = graphqlNonNull(t, prop)
+		}
+		var field = key
+		if !graphqlName.MatchString(key) || strings.HasPrefix(key, "__") {
+			field = lowerIdent(key)
+		}
+		field = uniqueName(field, fields)
+		fields[field] = true
+		if field != key {
+			if g.manifest.Fields == nil {
+				g.manifest.Fields = make(map[string]map[string]string)
+			}
+			if g.manifest.Fields[name] == nil {
+				g.manifest.Fields[name] = make(map[string]string)
+			}
+			g.manifest.Fields[name][field] = key
+		}
+		var deprecated bool
+		if prop != nil {
+			sb.WriteString(graphqlDescription(prop.Description, "  "))
+			deprecated = prop.Deprecated && !input
+		}
+		fmt.Fprintf(&sb, "  %s: %s%s\n", field, t, graphqlDeprecated(deprecated))
+	}
+	return sb.String(), nil
+}
+
+// Returns the type of schema v which may be a Schema Object or a Reference
+// Object, as an input type if input is true. Types for inline schemas are
+// declared using hint as their name.
+func (g *graphqlGenerator) typeOf(v interface{}, hint string, input bool) (string, error) {
+	var s, err = g.schema(v)
+	if err != nil {
+		return "", err
+	}
+	if s == nil {
+		return g.scalar("JSON"), nil
+	}
+	if s.Ref != "" {
+		var name = componentName(s.Ref, "schemas")
+		if t := g.outputs[name]; t != "" {
+			if !input {
+				return t, nil
+			}
+			return g.inputType(name)
+		}
+		if g.expanding[name] {
+			return g.scalar("JSON"), nil
+		}
+		var target *Schema
+		if err = g.doc.resolve(s, &target); err != nil {
+			return "", err
+		}
+		if name != "" {
+			g.expanding[name] = true
+			defer delete(g.expanding, name)
+			hint = name
+		}
+		return g.typeOf(target, hint, input)
+	}
+	switch variants := unionVariants(s); {
+	case len(variants) == 1:
+		return g.typeOf(variants[0], hint, input)
+	case len(s.AllOf) == 1 && s.Type == nil && len(s.Properties) == 0:
+		return g.typeOf(s.AllOf[0], hint, input)
+	case len(variants) > 1:
+		if input || g.unionMembers(s) == nil {
+			return g.scalar("JSON"), nil
+		}
+		var name = g.ident(hint)
+		return name, g.declare(name, s, false)
+	case g.isObject(s) || isGraphQLEnum(s):
+		if input && !isGraphQLEnum(s) {
+			hint += "Input"
+		}
+		var name = g.ident(hint)
+		return name, g.declare(name, s, input)
+	}
+	switch schemaGoKind(s) {
+	case TypeString:
+		if s.Format == "date-time" {
+			return g.scalar("DateTime"), nil
+		}
+		return "String", nil
+	case TypeInteger:
+		if s.Format == "" || s.Format == "int32" {
+			return "Int", nil
+		}
+		return g.scalar("BigInt"), nil
+	case TypeNumber:
+		return "Float", nil
+	case TypeBoolean:
+		return "Boolean", nil
+	case TypeArray:
+		var item, err = g.typeOf(s.Items, hint+"Item", input)
+		if err != nil {
+			return "", err
+		}
+		return "[" + graphqlNonNull(item, s.Items) + "]", nil
+	}
+	return g.scalar("JSON"), nil
+}
+
+// Returns the input type of component schema name which has an output type,
+// declaring it on first use.
+func (g *graphqlGenerator) inputType(name string) (string, error) {
+	if t, ok := g.inputs[name]; ok {
+		return t, nil
+	}
+	var s, err = g.schema(g.doc.Components.Schemas[name])
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case isGraphQLEnum(s):
+		g.inputs[name] = g.outputs[name]
+	case g.isObject(s):
+		g.inputs[name] = g.ident(name + "Input")
+		if err = g.declare(g.inputs[name], s, true); err != nil {
+			return "", fmt.Errorf("schema '%s': %w", name, err)
+		}
+	default:
+		g.inputs[name] = g.scalar("JSON")
+	}
+	return g.inputs[name], nil
+}
+
+// Returns t as a non-null type unless s is nullable.
+func graphqlNonNull(t string, s *Schema) string {
+	if s != nil && s.IsNullable() {
+		return t
+	}
+	return t + "!"
+}
+
+// Returns a description of text as a block string indented by indent, or an
+// empty string if text is empty. Text ending in a quote is written on its own
+// line so that the quote does not close the block string.
+func graphqlDescription(text, indent string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+	text = strings.ReplaceAll(text, `"""`, `\"""`)
+	if !strings.Contains(text, "\n") && !strings.HasSuffix(text, `"`) {
+		return indent + `"""` + text + `"""` + "\n"
+	}
+	var sb strings.Builder
+	sb.WriteString(indent + `"""` + "\n")
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimRight(line, " \t"); line != "" {
+			sb.WriteString(indent + line)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(indent + `"""` + "\n")
+	return sb.String()
+}
+
+// Returns the deprecated directive if deprecated is true.
+func graphqlDeprecated(deprecated bool) string {
+	if deprecated {
+		return " @deprecated"
+	}
+	return ""
+}
+
+// Declares Query and Mutation fields of document operations.
+func (g *graphqlGenerator) declareOperations() error {
+	if g.doc.Paths == nil {
+		return nil
+	}
+	var names = map[string]map[string]bool{
+		"Query":    make(map[string]bool),
+		"Mutation": make(map[string]bool),
+	}
+	for _, path := range g.doc.Paths.Keys() {
+		var item = g.doc.Paths.Items[path]
+		for _, method := range Methods {
+			var op = item.Operation(method)
+			if op == nil {
+				continue
+			}
+			var r = &GraphQLResolver{
+				Type:        "Mutation",
+				OperationID: op.OperationID,
+				Method:      method,
+				Path:        path,
+			}
+			if method == http.MethodGet {
+				r.Type = "Query"
+			}
+			var name = op.OperationID
+			if name == "" {
+				name = operationName(method, path)
+			}
+			r.Field = uniqueName(lowerIdent(name), names[r.Type])
+			names[r.Type][r.Field] = true
+			if err := g.declareField(r, item, op); err != nil {
+				return fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			g.manifest.Resolvers = append(g.manifest.Resolvers, r)
+		}
+	}
+	return nil
+}
+
+// Declares the field of resolver r of op.
+func (g *graphqlGenerator) declareField(r *GraphQLResolver, item *PathItem, op *Operation) error {
+	var params, err = g.doc.operationParameters(item, op)
+	if err != nil {
+		return err
+	}
+	var (
+		hint  = goIdent(r.Field)
+		names = make(map[string]bool)
+		args  []string
+		docs  bool
+	)
+	for _, p := range params {
+		var schema = p.Schema
+		if len(p.Content) > 0 {
+			schema = p.Content[sortedContentKeys(p.Content)[0]].Schema
+		}
+		var t, err = g.typeOf(schema, hint+goIdent(p.Name), true)
+		if err != nil {
+			return fmt.Errorf("parameter '%s': %w", p.Name, err)
+		}
+		if p.Required || p.In == "path" {
+			t += "!"
+		}
+		var arg = p.Name
+		if !graphqlName.MatchString(arg) || strings.HasPrefix(arg, "__") {
+			arg = lowerIdent(arg)
+		}
+		arg = uniqueName(arg, names)
+		names[arg] = true
+		r.Arguments = append(r.Arguments, &GraphQLArgument{Name: arg, Parameter: p.Name, In: p.In})
+		args = append(args, graphqlDescription(p.Description, "    ")+"    "+arg+": "+t)
+		docs = docs || p.Description != ""
+	}
+	if op.RequestBody != nil {
+		var rb *RequestBody
+		if err = g.doc.resolve(op.RequestBody, &rb); err != nil {
+			return err
+		}
+		if key, mt := preferredContent(rb.Content); mt != nil {
+			var t = "String"
+			if isJSONMediaType(key) {
+				if t, err = g.typeOf(mt.Schema, hint+"Body", true); err != nil {
+					return fmt.Errorf("request body: %w", err)
+				}
+			}
+			if rb.Required {
+				t += "!"
+			}
+			r.Body, r.ContentType = uniqueName("input", names), key
+			args = append(args, graphqlDescription(rb.Description, "    ")+"    "+r.Body+": "+t)
+			docs = docs || rb.Description != ""
+		}
+	}
+	result, err := g.resultType(hint, op)
+	if err != nil {
+		return err
+	}
+
+	var fields = g.roots[r.Type]
+	if fields == nil {
+		fields = &strings.Builder{}
+		g.roots[r.Type] = fields
+	}
+	fields.WriteString(graphqlDescription(strings.TrimSpace(op.Summary+"\n\n"+op.Description), "  "))
+	fields.WriteString("  " + r.Field)
+	switch {
+	case len(args) == 0:
+	case docs:
+		fmt.Fprintf(fields, "(\n%s\n  )", strings.Join(args, "\n"))
+	default:
+		for i := range args {
+			args[i] = strings.TrimSpace(args[i])
+		}
+		fmt.Fprintf(fields, "(%s)", strings.Join(args, ", "))
+	}
+	fmt.Fprintf(fields, ": %s%s\n", result, graphqlDeprecated(op.Deprecated))
+	return nil
+}
+
+// Returns the type of the field of op.
+func (g *graphqlGenerator) resultType(hint string, op *Operation) (string, error) {
+	var v interface{}
+	if op.Responses != nil {
+		for _, key := range op.Responses.Keys() {
+			if strings.HasPrefix(key, "2") {
+				v = op.Responses.Codes[key]
+				break
+			}
+		}
+		if v == nil {
+			v = op.Responses.Default
+		}
+	}
+	if v == nil {
+		return "Boolean", nil
+	}
+	var resp *Response
+	if err := g.doc.resolve(v, &resp); err != nil {
+		return "", err
+	}
+	var key, mt = preferredContent(resp.Content)
+	switch {
+	case mt == nil:
+		return "Boolean", nil
+	case !isJSONMediaType(key):
+		return "String", nil
+	}
+	var t, err = g.typeOf(mt.Schema, hint+"Result", false)
+	if err != nil {
+		return "", fmt.Errorf("response: %w", err)
+	}
+	return t, nil
+}
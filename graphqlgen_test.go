@@ -0,0 +1,152 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"testing"
+)
+
+func TestGenerateGraphQL(t *testing.T) {
+	var doc, err = FromJSON([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Pets", "version": "1.0"},
+		"paths": {
+			"/pets": {
+				"get": {
+					"operationId": "listPets",
+					"parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer", "format": "int32"}}],
+					"responses": {"200": {"description": "Pets", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}}}}}
+				},
+				"post": {
+					"operationId": "create_pet",
+					"summary": "Creates a pet.",
+					"requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Cat"}}}},
+					"responses": {"201": {"description": "Created", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}
+				}
+			},
+			"/pets/{petId}": {
+				"delete": {
+					"parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer", "format": "int64"}}],
+					"responses": {"204": {"description": "Deleted"}}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"Pet": {
+					"oneOf": [{"$ref": "#/components/schemas/Cat"}, {"$ref": "#/components/schemas/Dog"}],
+					"discriminator": {"propertyName": "kind", "mapping": {"cat": "#/components/schemas/Cat"}}
+				},
+				"Cat": {
+					"type": "object",
+					"description": "A cat.",
+					"required": ["name", "kind"],
+					"properties": {
+						"kind": {"type": "string"},
+						"name": {"type": "string"},
+						"color": {"$ref": "#/components/schemas/Color"},
+						"born-at": {"type": "string", "format": "date-time"}
+					}
+				},
+				"Dog": {
+					"type": "object",
+					"properties": {
+						"kind": {"type": "string"},
+						"labels": {"type": "object", "additionalProperties": {"type": "string"}}
+					}
+				},
+				"Color": {"type": "string", "enum": ["black", "white"]}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sdl, manifest, err := GenerateGraphQL(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want = `"""An integer that may not fit 32 bits."""
+scalar BigInt
+
+"""An RFC 3339 date-time string."""
+scalar DateTime
+
+"""An arbitrary JSON value."""
+scalar JSON
+
+type Query {
+  listPets(limit: Int): [Pet!]
+}
+
+type Mutation {
+  """Creates a pet."""
+  createPet(input: CatInput!): Pet
+  deletePetsByPetID(petId: BigInt!): Boolean
+}
+
+"""A cat."""
+type Cat {
+  bornAt: DateTime
+  color: Color
+  kind: String!
+  name: String!
+}
+
+"""A cat."""
+input CatInput {
+  bornAt: DateTime
+  color: Color
+  kind: String!
+  name: String!
+}
+
+enum Color {
+  black
+  white
+}
+
+type Dog {
+  kind: String
+  labels: JSON
+}
+
+union Pet = Cat | Dog
+`
+	if string(sdl) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", sdl, want)
+	}
+
+	if got, want := jsonString(t, manifest.Resolvers[1]), `{"type":"Mutation","field":"createPet","operationId":"create_pet",`+
+		`"method":"POST","path":"/pets","body":"input","contentType":"application/json"}`; got != want {
+		t.Errorf("resolver:\ngot  %s\nwant %s", got, want)
+	}
+	if got, want := jsonString(t, manifest.Resolvers[2].Arguments), `[{"name":"petId","parameter":"petId","in":"path"}]`; got != want {
+		t.Errorf("arguments: got %s, want %s", got, want)
+	}
+	if got, want := jsonString(t, manifest.Unions), `[{"name":"Pet","propertyName":"kind","mapping":{"Cat":"Cat","Dog":"Dog","cat":"Cat"}}]`; got != want {
+		t.Errorf("unions: got %s, want %s", got, want)
+	}
+	if got, want := jsonString(t, manifest.Fields), `{"Cat":{"bornAt":"born-at"},"CatInput":{"bornAt":"born-at"}}`; got != want {
+		t.Errorf("fields: got %s, want %s", got, want)
+	}
+}
+
+func TestGraphQLDescription(t *testing.T) {
+	var tests = []struct {
+		text, want string
+	}{
+		{"", ""},
+		{"A pet.", `"""A pet."""` + "\n"},
+		{`Says """hi""" twice`, `"""Says \"""hi\""" twice"""` + "\n"},
+		{`Named "Rex"`, "\"\"\"\nNamed \"Rex\"\n\"\"\"\n"},
+		{"A\n\npet.", "\"\"\"\nA\n\npet.\n\"\"\"\n"},
+	}
+	for _, test := range tests {
+		if got := graphqlDescription(test.text, ""); got != test.want {
+			t.Errorf("%q:\ngot  %q\nwant %q", test.text, got, test.want)
+		}
+	}
+}
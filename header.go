@@ -23,7 +23,37 @@ package openapi
 // schema:
 //   type: integer
 type Header struct {
-
+	// A brief description of the header. This could contain examples of use.
+	// CommonMark syntax MAY be used for rich text representation.
+	Description string `json:"description,omitempty"`
+	// Determines whether this header is mandatory. The default value is false.
+	Required bool `json:"required,omitempty"`
+	// Specifies that a header is deprecated and SHOULD be transitioned out of
+	// usage. Default value is false.
+	Deprecated bool `json:"deprecated,omitempty"`
+	// Describes how the header value will be serialized. The only applicable
+	// value for headers is "simple".
+	Style string `json:"style,omitempty"`
+	// When this is true, header values of type array or object generate a
+	// single header whose value is a comma-separated list of key=value pairs.
+	Explode bool `json:"explode,omitempty"`
+	// Holds Schema Object | Reference Object
+	//
+	// The schema defining the type used for the header.
+	Schema interface{} `json:"schema,omitempty"`
+	// Holds Any object.
+	//
+	// Example of the header's potential value.
+	Example interface{} `json:"example,omitempty"`
+	// Holds Map[ string, Example Object | Reference Object]
+	//
+	// Examples of the header's potential value.
+	Examples map[string]interface{} `json:"examples,omitempty"`
+	// A map containing the representations for the header. The key is the
+	// media type and the value describes it. The map MUST only contain one
+	// entry.
+	Content map[string]*MediaType `json:"content,omitempty"`
+	// This object MAY be extended with Specification Extensions.
 }
 
 // Header Object Example
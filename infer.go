@@ -0,0 +1,266 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// InferEnumLimit is the maximum number of distinct values of strings that
+// InferSchema describes as an enum.
+const InferEnumLimit = 5
+
+// InferSchema returns the tightest reasonable schema that validates all
+// samples. Samples are JSON values as decoded by encoding/json into an
+// interface{}. Other values are converted by marshaling them to JSON and
+// samples that cannot be marshaled are ignored.
+//
+// Samples are merged as follows:
+//
+//   - Properties of objects are merged and those present in every object
+//     are required.
+//   - Values of different types are oneOf variants, except null which is
+//     added to the type of a single variant. Integers are numbers if any
+//     value is a non-integer number.
+//   - Strings have a date-time, uuid, email or uri format if all of them are
+//     of the format. Strings without a format are an enum if there are at
+//     most InferEnumLimit distinct values and a value occurs more than once.
+//   - Arrays of equal length of at least two whose items differ in type by
+//     position are tuples described by prefixItems. Items of other arrays
+//     are merged into a single items schema.
+//
+// Returns an empty schema if there are no samples.
+func InferSchema(samples ...interface{}) *Schema {
+	var values = make([]interface{}, 0, len(samples))
+	for _, sample := range samples {
+		var v interface{}
+		if err := remarshal(sample, &v); err == nil {
+			values = append(values, v)
+		}
+	}
+	return inferSchema(values)
+}
+
+// inferKinds are JSON Schema types in the order they are listed in inferred
+// schemas.
+var inferKinds = []string{TypeObject, TypeArray, TypeString, TypeInteger, TypeNumber, TypeBoolean, TypeNull}
+
+// uuidPattern matches UUIDs.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Returns the JSON Schema type of JSON value v decoded into an interface{}.
+func jsonKind(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return TypeNull
+	case bool:
+		return TypeBoolean
+	case float64:
+		if t == math.Trunc(t) && !math.IsInf(t, 0) {
+			return TypeInteger
+		}
+		return TypeNumber
+	case string:
+		return TypeString
+	case []interface{}:
+		return TypeArray
+	case map[string]interface{}:
+		return TypeObject
+	}
+	return ""
+}
+
+// Returns a schema of samples, which are JSON values decoded into
+// interface{} values, as described by InferSchema.
+func inferSchema(samples []interface{}) *Schema {
+	var byKind = make(map[string][]interface{})
+	for _, v := range samples {
+		var kind = jsonKind(v)
+		byKind[kind] = append(byKind[kind], v)
+	}
+	if numbers, ok := byKind[TypeNumber]; ok {
+		byKind[TypeNumber] = append(numbers, byKind[TypeInteger]...)
+		delete(byKind, TypeInteger)
+	}
+	var (
+		_, nullable = byKind[TypeNull]
+		variants    []*Schema
+	)
+	for _, kind := range inferKinds {
+		if values, ok := byKind[kind]; ok && kind != TypeNull {
+			variants = append(variants, inferKind(kind, values))
+		}
+	}
+	switch len(variants) {
+	case 0:
+		if nullable {
+			return &Schema{Type: TypeNull}
+		}
+		return &Schema{}
+	case 1:
+		var s = variants[0]
+		if nullable {
+			s.Type = []interface{}{s.Type, TypeNull}
+			if s.Enum != nil {
+				s.Enum = append(s.Enum, nil)
+			}
+		}
+		return s
+	}
+	if nullable {
+		variants = append(variants, &Schema{Type: TypeNull})
+	}
+	return &Schema{OneOf: variants}
+}
+
+// Returns a schema of values of JSON Schema type kind.
+func inferKind(kind string, values []interface{}) *Schema {
+	var s = &Schema{Type: kind}
+	switch kind {
+	case TypeObject:
+		inferProperties(s, values)
+	case TypeArray:
+		inferItems(s, values)
+	case TypeString:
+		var strs = make([]string, len(values))
+		for i, v := range values {
+			strs[i] = v.(string)
+		}
+		if s.Format = stringFormat(strs); s.Format == "" {
+			s.Enum = stringEnum(strs)
+		}
+	}
+	return s
+}
+
+// Sets properties and required properties of s from object samples.
+func inferProperties(s *Schema, objects []interface{}) {
+	var values = make(map[string][]interface{})
+	for _, v := range objects {
+		for name, value := range v.(map[string]interface{}) {
+			values[name] = append(values[name], value)
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+	s.Properties = make(map[string]*Schema)
+	for name, samples := range values {
+		s.Properties[name] = inferSchema(samples)
+		if len(samples) == len(objects) {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+}
+
+// Sets items or prefixItems of s from array samples.
+func inferItems(s *Schema, arrays []interface{}) {
+	var (
+		length = len(arrays[0].([]interface{}))
+		tuple  = length >= 2
+		items  []interface{}
+	)
+	for _, v := range arrays {
+		var a = v.([]interface{})
+		tuple = tuple && len(a) == length
+		items = append(items, a...)
+	}
+	if tuple {
+		var (
+			columns = make([][]interface{}, length)
+			kinds   = make([]string, length)
+			uniform = true
+		)
+		for _, v := range arrays {
+			for i, item := range v.([]interface{}) {
+				columns[i] = append(columns[i], item)
+			}
+		}
+		for i, column := range columns {
+			var set = make(map[string]bool)
+			for _, item := range column {
+				set[jsonKind(item)] = true
+			}
+			var list = make([]string, 0, len(set))
+			for kind := range set {
+				list = append(list, kind)
+			}
+			sort.Strings(list)
+			kinds[i] = strings.Join(list, ",")
+			uniform = uniform && kinds[i] == kinds[0]
+		}
+		if !uniform {
+			for _, column := range columns {
+				s.PrefixItems = append(s.PrefixItems, inferSchema(column))
+			}
+			s.MinItems, s.MaxItems = &length, &length
+			return
+		}
+	}
+	if len(items) > 0 {
+		s.Items = inferSchema(items)
+	}
+}
+
+// Returns the format all strs are of or an empty string.
+func stringFormat(strs []string) string {
+	var formats = []struct {
+		name  string
+		match func(s string) bool
+	}{
+		{"date-time", func(s string) bool {
+			var _, err = time.Parse(time.RFC3339, s)
+			return err == nil
+		}},
+		{"uuid", uuidPattern.MatchString},
+		{"email", func(s string) bool {
+			var addr, err = mail.ParseAddress(s)
+			return err == nil && addr.Name == "" && addr.Address == s
+		}},
+		{"uri", func(s string) bool {
+			var u, err = url.Parse(s)
+			return err == nil && u.Scheme != "" && u.Host != ""
+		}},
+	}
+	for _, format := range formats {
+		var match = true
+		for _, s := range strs {
+			if !format.match(s) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return format.name
+		}
+	}
+	return ""
+}
+
+// Returns distinct strs in order of appearance if there are at most
+// InferEnumLimit of them and a value occurs more than once, nil otherwise.
+func stringEnum(strs []string) []interface{} {
+	var (
+		seen   = make(map[string]bool)
+		result []interface{}
+	)
+	for _, s := range strs {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	if len(result) > InferEnumLimit || len(result) == len(strs) {
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"testing"
+)
+
+func TestInferSchema(t *testing.T) {
+	type point struct {
+		X int `json:"x"`
+	}
+	var tests = []struct {
+		name    string
+		samples []interface{}
+		want    string
+	}{
+		{"empty", nil, `{}`},
+		{"numbers", []interface{}{1, 2.5}, `{"type":"number"}`},
+		{"nullable", []interface{}{"a", nil}, `{"type":["string","null"]}`},
+		{"oneOf", []interface{}{"a", 1, nil}, `{"oneOf":[{"type":"string"},{"type":"integer"},{"type":"null"}]}`},
+		{"formats", []interface{}{
+			map[string]interface{}{"at": "2021-01-02T15:04:05Z", "email": "ann@example.com",
+				"url": "https://example.com/a", "id": "3f2504e0-4f89-11d3-9a0c-0305e82c3301"},
+			map[string]interface{}{"at": "2021-03-04T00:00:00+01:00", "email": "bob@example.com",
+				"url": "http://example.org", "id": "3f2504e0-4f89-11d3-9a0c-0305e82c3302"},
+		}, `{"properties":{"at":{"type":"string","format":"date-time"},"email":{"type":"string","format":"email"},` +
+			`"id":{"type":"string","format":"uuid"},"url":{"type":"string","format":"uri"}},` +
+			`"type":"object","required":["at","email","id","url"]}`},
+		{"list", []interface{}{[]interface{}{1, 2}, []interface{}{3}}, `{"items":{"type":"integer"},"type":"array"}`},
+		{"tuple", []interface{}{[]interface{}{"a", 1}, []interface{}{"b", 2}},
+			`{"prefixItems":[{"type":"string"},{"type":"integer"}],"type":"array","maxItems":2,"minItems":2}`},
+		{"enum", []interface{}{"cat", "dog", "cat", nil}, `{"type":["string","null"],"enum":["cat","dog",null]}`},
+		{"not enum", []interface{}{"Ann", "Bob"}, `{"type":"string"}`},
+		{"objects", []interface{}{point{X: 1}, map[string]interface{}{"x": 2, "y": "a"}},
+			`{"properties":{"x":{"type":"integer"},"y":{"type":"string"}},"type":"object","required":["x"]}`},
+	}
+	for _, test := range tests {
+		if got := jsonString(t, InferSchema(test.samples...)); got != test.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONSchemaDialect is the $schema of schemas exported by ExportJSONSchema
+// from documents that do not define a JsonSchemaDialect.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// ExportJSONSchema exports component schema name of doc as a self-contained
+// JSON Schema 2020-12 document in JSON.
+//
+// Component schemas referenced by the schema, directly or transitively, are
+// included in $defs and references to them are rewritten from
+// "#/components/schemas/X" to "#/$defs/X". References to the exported schema
+// itself are rewritten to "#".
+//
+// Keywords of the OpenAPI vocabulary are removed, except example which is
+// added to examples. $schema is set to the document JsonSchemaDialect or to
+// JSONSchemaDialect, unless the schema defines it.
+func ExportJSONSchema(doc *OpenAPI, name string) ([]byte, error) {
+	var x = &jsonSchemaExporter{root: name, defs: make(map[string]interface{})}
+	var root, err = x.load(doc, name)
+	if err != nil {
+		return nil, err
+	}
+	root = x.rewrite(root)
+	for len(x.queue) > 0 {
+		var next = x.queue[0]
+		x.queue = x.queue[1:]
+		var def, err = x.load(doc, next)
+		if err != nil {
+			return nil, err
+		}
+		x.defs[next] = x.rewrite(def)
+	}
+	var s = rawObject(root)
+	if s == nil {
+		// Boolean schemas are written as their object equivalents so that
+		// $schema and $defs can be added.
+		s = make(map[string]interface{})
+		if root == false {
+			s["not"] = map[string]interface{}{}
+		}
+	}
+	if len(x.defs) > 0 {
+		var defs = rawObject(s["$defs"])
+		if defs == nil {
+			defs = make(map[string]interface{})
+		}
+		for def, v := range x.defs {
+			if _, exists := defs[def]; exists {
+				return nil, fmt.Errorf("schema '%s': $defs '%s' conflicts with a referenced component", name, def)
+			}
+			defs[def] = v
+		}
+		s["$defs"] = defs
+	}
+	if _, ok := s["$schema"]; !ok {
+		s["$schema"] = JSONSchemaDialect
+		if doc.JsonSchemaDialect != "" {
+			s["$schema"] = doc.JsonSchemaDialect
+		}
+	}
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// oasKeywords are keywords of the OpenAPI base vocabulary that are removed
+// from exported schemas.
+var oasKeywords = []string{"discriminator", "xml", "externalDocs", "example"}
+
+// jsonSchemaExporter exports a component schema with its dependencies.
+type jsonSchemaExporter struct {
+	// root is the name of the exported component.
+	root string
+	// defs are rewritten referenced components by name, or nil if queued.
+	defs  map[string]interface{}
+	queue []string
+}
+
+// Returns component schema name of doc as a raw value.
+func (x *jsonSchemaExporter) load(doc *OpenAPI, name string) (interface{}, error) {
+	var v, ok = doc.Components.section("schemas")[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: schema '%s'", ErrUnresolvedReference, name)
+	}
+	var result interface{}
+	if err := remarshal(v, &result); err != nil {
+		return nil, fmt.Errorf("schema '%s': %w", name, err)
+	}
+	return result, nil
+}
+
+// Returns raw schema v with references to components rewritten and OpenAPI
+// keywords removed. Referenced components are queued for export.
+func (x *jsonSchemaExporter) rewrite(v interface{}) interface{} {
+	var s = rawObject(v)
+	if s == nil {
+		return v
+	}
+	if ref, ok := s["$ref"].(string); ok && strings.HasPrefix(ref, componentsPrefix+"schemas/") {
+		var (
+			rest     = strings.TrimPrefix(ref, componentsPrefix+"schemas/")
+			token    = strings.SplitN(rest, "/", 2)[0]
+			name     = unescapePointer(token)
+			fragment = strings.TrimPrefix(rest, token)
+		)
+		if name == x.root {
+			s["$ref"] = "#" + fragment
+		} else {
+			s["$ref"] = "#/$defs/" + token + fragment
+			if _, seen := x.defs[name]; !seen {
+				x.defs[name] = nil
+				x.queue = append(x.queue, name)
+			}
+		}
+	}
+	if example, ok := s["example"]; ok {
+		var examples = rawList(s["examples"])
+		s["examples"] = append([]interface{}{example}, examples...)
+	}
+	for _, key := range oasKeywords {
+		delete(s, key)
+	}
+	for key, sub := range s {
+		switch key {
+		case "properties", "patternProperties", "$defs", "dependentSchemas":
+			var m = rawObject(sub)
+			for name := range m {
+				m[name] = x.rewrite(m[name])
+			}
+		case "allOf", "anyOf", "oneOf", "prefixItems":
+			var list = rawList(sub)
+			for i := range list {
+				list[i] = x.rewrite(list[i])
+			}
+		case "items", "additionalProperties", "not", "if", "then", "else", "contains",
+			"propertyNames", "unevaluatedItems", "unevaluatedProperties", "contentSchema":
+			s[key] = x.rewrite(sub)
+		}
+	}
+	return s
+}
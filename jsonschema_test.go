@@ -0,0 +1,61 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestExportJSONSchema(t *testing.T) {
+	var doc, err = FromJSON([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Pets", "version": "1.0"},
+		"components": {
+			"schemas": {
+				"Pet": {
+					"type": "object",
+					"discriminator": {"propertyName": "kind"},
+					"xml": {"name": "pet"},
+					"properties": {
+						"xml": {"type": "string", "example": "Rex"},
+						"owner": {"$ref": "#/components/schemas/Owner"},
+						"parent": {"$ref": "#/components/schemas/Pet"}
+					}
+				},
+				"Owner": {
+					"type": "object",
+					"properties": {"address": {"$ref": "#/components/schemas/Address/properties/street"}}
+				},
+				"Address": {"type": "object", "properties": {"street": {"type": "string"}}},
+				"Unused": {"type": "string"}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ExportJSONSchema(doc, "Pet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want = `{"$defs":{` +
+		`"Address":{"properties":{"street":{"type":"string"}},"type":"object"},` +
+		`"Owner":{"properties":{"address":{"$ref":"#/$defs/Address/properties/street"}},"type":"object"}},` +
+		`"$schema":"https://json-schema.org/draft/2020-12/schema",` +
+		`"properties":{"owner":{"$ref":"#/$defs/Owner"},"parent":{"$ref":"#"},` +
+		`"xml":{"examples":["Rex"],"type":"string"}},"type":"object"}`
+	var out interface{}
+	if err = json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if got := jsonString(t, out); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if _, err = ExportJSONSchema(doc, "Missing"); !errors.Is(err, ErrUnresolvedReference) {
+		t.Errorf("got error %v, want ErrUnresolvedReference", err)
+	}
+}
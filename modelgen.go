@@ -0,0 +1,29 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+// GenerateModels generates the source of a Go package named pkg that declares
+// a type for each schema in doc Components.Schemas.
+//
+// Schemas are mapped to Go types as follows:
+//
+//   - Objects with properties are structs with doc comments from their
+//     descriptions. Properties that are not required are pointers.
+//   - Objects without properties are maps of additionalProperties.
+//   - oneOf schemas are unions: a struct holding a value of a sealed
+//     interface implemented by variants, decoded using the Discriminator
+//     mapping if one is defined.
+//   - Enums of strings and numbers are types with constants of their values.
+//   - Nullable schemas and schemas with a "null" type are pointers.
+func GenerateModels(doc *OpenAPI, pkg string) ([]byte, error) {
+	var g, err = newGoGenerator(doc, pkg)
+	if err != nil {
+		return nil, err
+	}
+	if err = g.declareComponents(); err != nil {
+		return nil, err
+	}
+	return g.source()
+}
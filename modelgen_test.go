@@ -0,0 +1,124 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateModels(t *testing.T) {
+	var doc, err = FromJSON([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Models", "version": "1.0"},
+		"components": {
+			"schemas": {
+				"Pet": {
+					"description": "Pet is a cat or a dog.",
+					"oneOf": [{"$ref": "#/components/schemas/Cat"}, {"$ref": "#/components/schemas/Dog"}],
+					"discriminator": {"propertyName": "kind", "mapping": {"cat": "#/components/schemas/Cat"}}
+				},
+				"Cat": {
+					"type": "object",
+					"required": ["kind", "lives"],
+					"properties": {
+						"kind": {"type": "string"},
+						"lives": {"type": "integer"},
+						"color": {"$ref": "#/components/schemas/Color"}
+					}
+				},
+				"Dog": {
+					"type": "object",
+					"required": ["kind"],
+					"properties": {
+						"kind": {"type": "string"},
+						"nickname": {"type": ["string", "null"]},
+						"owner": {"type": "string", "nullable": true},
+						"labels": {"type": "object", "additionalProperties": {"type": "integer"}}
+					}
+				},
+				"Color": {"type": "string", "enum": ["black", "white", "tabby"]},
+				"Size": {"type": "integer", "enum": [1, 2, 3]},
+				"Id": {"oneOf": [{"type": "string"}, {"type": "integer"}]}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := GenerateModels(doc, "generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goTestFiles(t, map[string][]byte{
+		"models.go": src,
+		"models_test.go": []byte(`package generated
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestModels(t *testing.T) {
+	var pets []Pet
+	if err := json.Unmarshal([]byte(` + "`" + `[
+		{"kind": "cat", "lives": 9, "color": "tabby"},
+		{"kind": "Dog", "nickname": null, "labels": {"good": 1}}
+	]` + "`" + `), &pets); err != nil {
+		t.Fatal(err)
+	}
+	if cat, ok := pets[0].Value.(Cat); !ok || cat.Lives != 9 || *cat.Color != ColorTabby {
+		t.Fatalf("unexpected cat: %#v", pets[0].Value)
+	}
+	if dog, ok := pets[1].Value.(Dog); !ok || dog.Nickname != nil || dog.Owner != nil || dog.Labels["good"] != 1 {
+		t.Fatalf("unexpected dog: %#v", pets[1].Value)
+	}
+	if err := json.Unmarshal([]byte(` + "`" + `{"kind": "bird"}` + "`" + `), &pets[0]); err == nil {
+		t.Fatal("expected unknown discriminator error")
+	}
+	data, err := json.Marshal(pets[0])
+	if err != nil || string(data) != ` + "`" + `{"color":"tabby","kind":"cat","lives":9}` + "`" + ` {
+		t.Fatalf("unexpected encoding %s: %v", data, err)
+	}
+
+	var id ID
+	if err := json.Unmarshal([]byte("42"), &id); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := id.Value.(IDInt); !ok {
+		t.Fatalf("unexpected id variant: %#v", id.Value)
+	}
+	var _ Size = Size2
+}
+`),
+	})
+}
+
+func TestGenerateModelsV30Binary(t *testing.T) {
+	var doc, err = FromYAML([]byte(`
+openapi: 3.0.3
+info: {title: Models, version: "1.0"}
+components:
+  schemas:
+    Upload:
+      type: object
+      required: [file]
+      properties:
+        file: {type: string, format: binary}
+        data: {type: string, format: byte}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := GenerateModels(doc, "generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"\tFile []byte `json:\"file\"`", "\tData []byte `json:\"data,omitempty\"`"} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("missing %q in:\n%s", want, src)
+		}
+	}
+}
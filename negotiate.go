@@ -0,0 +1,318 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MediaRange is a parsed media type or media range such as "text/*" with its
+// parameters and quality value.
+type MediaRange struct {
+	// Type is the lowercase top level type, "*" for any.
+	Type string
+	// Subtype is the lowercase subtype, "*" for any.
+	Subtype string
+	// Params are media type parameters excluding the quality value.
+	Params map[string]string
+	// Q is the quality value, 1 if unspecified.
+	Q float64
+}
+
+// Returns the parsed media range s or false if s is not a valid media type
+// or media range.
+func ParseMediaRange(s string) (MediaRange, bool) {
+	var mt, params, err = mime.ParseMediaType(strings.TrimSpace(s))
+	if err != nil {
+		return MediaRange{}, false
+	}
+	var result = MediaRange{Params: params, Q: 1}
+	if i := strings.IndexByte(mt, '/'); i > 0 {
+		result.Type, result.Subtype = mt[:i], mt[i+1:]
+	} else if mt == "*" {
+		result.Type, result.Subtype = "*", "*"
+	} else {
+		return MediaRange{}, false
+	}
+	if q, ok := params["q"]; ok {
+		if f, err := strconv.ParseFloat(q, 64); err == nil && f >= 0 && f <= 1 {
+			result.Q = f
+		}
+		delete(params, "q")
+	}
+	return result, true
+}
+
+// Parses an Accept header into media ranges in the order they appear.
+// Invalid ranges are skipped. An empty header yields "*/*".
+func ParseAccept(header string) (result []MediaRange) {
+	if strings.TrimSpace(header) == "" {
+		return []MediaRange{{Type: "*", Subtype: "*", Q: 1}}
+	}
+	for _, s := range strings.Split(header, ",") {
+		if mr, ok := ParseMediaRange(s); ok {
+			result = append(result, mr)
+		}
+	}
+	return
+}
+
+// Returns the media type without parameters, i.e. "text/plain".
+func (mr MediaRange) String() string {
+	return mr.Type + "/" + mr.Subtype
+}
+
+// Returns the specificity of the range; higher is more specific.
+// "*/*" < "type/*" < "type/subtype" < "type/subtype; with=params".
+func (mr MediaRange) Specificity() int {
+	switch {
+	case mr.Type == "*":
+		return 0
+	case mr.Subtype == "*":
+		return 1
+	}
+	return 2 + len(mr.Params)
+}
+
+// Returns true if mr includes the media type other. Parameters of mr must
+// be present with equal values in other.
+func (mr MediaRange) Includes(other MediaRange) bool {
+	if mr.Type != "*" && mr.Type != other.Type {
+		return false
+	}
+	if mr.Subtype != "*" && mr.Subtype != other.Subtype {
+		return false
+	}
+	for key, val := range mr.Params {
+		if !strings.EqualFold(other.Params[key], val) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns true if either of the ranges includes the other.
+func (mr MediaRange) Overlaps(other MediaRange) bool {
+	return mr.Includes(other) || other.Includes(mr)
+}
+
+// Returns the key of the Media Type in content that applies to a payload of
+// contentType, following the rule that the most specific key is applicable,
+// e.g. "text/plain" overrides "text/*". Returns false if no key matches.
+func MatchMediaType(content map[string]*MediaType, contentType string) (string, *MediaType, bool) {
+	var target, ok = ParseMediaRange(contentType)
+	if !ok {
+		return "", nil, false
+	}
+	var (
+		best  string
+		score = -1
+	)
+	for _, key := range sortedContentKeys(content) {
+		var mr, ok = ParseMediaRange(key)
+		if !ok || !mr.Includes(target) {
+			continue
+		}
+		if s := mr.Specificity(); s > score {
+			best, score = key, s
+		}
+	}
+	if score < 0 {
+		return "", nil, false
+	}
+	return best, content[best], true
+}
+
+// Returns the key of the Media Type in content that is preferred by a client
+// sending the accept header. Each key is weighted by the quality value of the
+// most specific accepted range that overlaps it; ties are resolved in favour
+// of more specific keys and then keys that appear earlier in accept.
+// Returns false if no key is acceptable.
+func NegotiateMediaType(content map[string]*MediaType, accept string) (string, *MediaType, bool) {
+	var (
+		ranges = ParseAccept(accept)
+		best   string
+		bestQ  float64
+		bestS  = -1
+		bestI  int
+	)
+	for _, key := range sortedContentKeys(content) {
+		var mr, ok = ParseMediaRange(key)
+		if !ok {
+			continue
+		}
+		var (
+			q     = -1.0
+			index int
+			spec  = -1
+		)
+		for i, r := range ranges {
+			if !r.Overlaps(mr) {
+				continue
+			}
+			if s := r.Specificity(); s > spec {
+				q, index, spec = r.Q, i, s
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		var s = mr.Specificity()
+		if q > bestQ || (q == bestQ && (s > bestS || (s == bestS && index < bestI))) {
+			best, bestQ, bestS, bestI = key, q, s, index
+		}
+	}
+	if bestS < 0 {
+		return "", nil, false
+	}
+	return best, content[best], true
+}
+
+// Returns an Accept header value listing keys of content, for use by clients.
+// More specific keys are listed first.
+func AcceptHeader(content map[string]*MediaType) string {
+	var keys = sortedContentKeys(content)
+	sort.SliceStable(keys, func(i, j int) bool {
+		var a, _ = ParseMediaRange(keys[i])
+		var b, _ = ParseMediaRange(keys[j])
+		return a.Specificity() > b.Specificity()
+	})
+	return strings.Join(keys, ", ")
+}
+
+// Returns the keys of content sorted lexically.
+func sortedContentKeys(content map[string]*MediaType) []string {
+	var keys = make([]string, 0, len(content))
+	for key := range content {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Returns the key and Media Type of the response content preferred by a
+// client sending the accept header. See NegotiateMediaType.
+func (r *Response) Negotiate(accept string) (string, *MediaType, bool) {
+	return NegotiateMediaType(r.Content, accept)
+}
+
+// Returns the key and Media Type of the response content that applies to a
+// received response of contentType. See MatchMediaType.
+func (r *Response) Match(contentType string) (string, *MediaType, bool) {
+	return MatchMediaType(r.Content, contentType)
+}
+
+// Returns the key and Media Type of the request body content that applies to
+// a request body of contentType. See MatchMediaType.
+func (rb *RequestBody) Match(contentType string) (string, *MediaType, bool) {
+	return MatchMediaType(rb.Content, contentType)
+}
+
+// Negotiation holds the result of content negotiation of a request.
+type Negotiation struct {
+	// RequestKey is the key of the request body Media Type that applies to the
+	// request, empty if the operation defines no request body or the request
+	// has no body.
+	RequestKey string
+	// Request is the request body Media Type.
+	Request *MediaType
+	// ResponseKeys are keys of the negotiated response Media Types keyed by
+	// response status code key, e.g. "200", "2XX" or "default".
+	ResponseKeys map[string]string
+}
+
+// ContentNegotiator is a server middleware that matches the request body
+// Content-Type and the Accept header against Media Types of the routed
+// Operation.
+//
+// Requests with a body whose Content-Type is not defined by the request body
+// are answered with 415. Requests with an Accept header that matches none of
+// the Media Types of success (2XX) responses that define content are
+// answered with 406. Results are stored in the request context and can be
+// retrieved using NegotiationFromContext.
+type ContentNegotiator struct {
+	doc    *OpenAPI
+	router *Router
+}
+
+// Returns a new ContentNegotiator for the doc.
+func NewContentNegotiator(doc *OpenAPI) *ContentNegotiator {
+	return &ContentNegotiator{doc: doc, router: NewRouter(doc)}
+}
+
+// Handler returns a http.Handler that negotiates content before passing
+// requests to next. Requests that do not match an operation are passed to
+// next unchecked.
+func (cn *ContentNegotiator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var route, _, err = cn.router.Match(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var n = &Negotiation{ResponseKeys: make(map[string]string)}
+		if status := cn.negotiate(r, route.Operation, n); status != 0 {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), negotiationKey{}, n)))
+	})
+}
+
+// Negotiates content for r and op into n. Returns a non-zero status code if
+// negotiation failed.
+func (cn *ContentNegotiator) negotiate(r *http.Request, op *Operation, n *Negotiation) int {
+	var body *RequestBody
+	if err := cn.doc.resolve(op.RequestBody, &body); err != nil {
+		return http.StatusInternalServerError
+	}
+	if ct := r.Header.Get("Content-Type"); body != nil && ct != "" && len(body.Content) > 0 {
+		var ok bool
+		if n.RequestKey, n.Request, ok = body.Match(ct); !ok {
+			return http.StatusUnsupportedMediaType
+		}
+	}
+	if op.Responses == nil {
+		return 0
+	}
+	var (
+		accept     = r.Header.Get("Accept")
+		offered    bool
+		acceptable bool
+	)
+	for _, code := range op.Responses.Keys() {
+		var resp *Response
+		if err := cn.doc.resolve(op.Responses.Codes[code], &resp); err != nil {
+			return http.StatusInternalServerError
+		}
+		if resp == nil || len(resp.Content) == 0 {
+			continue
+		}
+		var success = strings.HasPrefix(code, "2")
+		offered = offered || success
+		if key, _, ok := resp.Negotiate(accept); ok {
+			n.ResponseKeys[code] = key
+			acceptable = acceptable || success
+		}
+	}
+	if offered && !acceptable {
+		return http.StatusNotAcceptable
+	}
+	return 0
+}
+
+// negotiationKey is the context key for Negotiation.
+type negotiationKey struct{}
+
+// Returns the Negotiation stored in ctx by ContentNegotiator or nil if none.
+func NegotiationFromContext(ctx context.Context) *Negotiation {
+	var n, _ = ctx.Value(negotiationKey{}).(*Negotiation)
+	return n
+}
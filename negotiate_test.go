@@ -0,0 +1,154 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseMediaRange(t *testing.T) {
+	var tests = []struct {
+		in, want string
+	}{
+		{"text/html", `{"Type":"text","Subtype":"html","Params":{},"Q":1}`},
+		{"Text/HTML; Level=1; q=0.5", `{"Type":"text","Subtype":"html","Params":{"level":"1"},"Q":0.5}`},
+		{"*", `{"Type":"*","Subtype":"*","Params":{},"Q":1}`},
+		{"*/*;q=0", `{"Type":"*","Subtype":"*","Params":{},"Q":0}`},
+		{"text/*;q=2", `{"Type":"text","Subtype":"*","Params":{},"Q":1}`},
+		{"text", `invalid`},
+		{"", `invalid`},
+		{"text/html; =1", `invalid`},
+	}
+	for _, test := range tests {
+		var got = "invalid"
+		if mr, ok := ParseMediaRange(test.in); ok {
+			got = jsonString(t, mr)
+		}
+		if got != test.want {
+			t.Errorf("%q:\ngot  %s\nwant %s", test.in, got, test.want)
+		}
+	}
+}
+
+func TestMatchMediaType(t *testing.T) {
+	var content = map[string]*MediaType{
+		"*/*":                             {},
+		"text/*":                          {},
+		"text/plain":                      {},
+		"application/json; charset=utf-8": {},
+	}
+	var tests = []struct {
+		contentType, want string
+	}{
+		{"text/plain; charset=utf-8", "text/plain"},
+		{"text/html", "text/*"},
+		{"image/png", "*/*"},
+		{"application/json; charset=UTF-8", "application/json; charset=utf-8"},
+		{"application/json", "*/*"},
+		{"text", ""},
+	}
+	for _, test := range tests {
+		if got, _, _ := MatchMediaType(content, test.contentType); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.contentType, got, test.want)
+		}
+	}
+}
+
+func TestNegotiateMediaType(t *testing.T) {
+	var (
+		content = map[string]*MediaType{
+			"application/json": {},
+			"application/xml":  {},
+			"text/*":           {},
+		}
+		versioned = map[string]*MediaType{
+			"application/json; version=1": {},
+			"application/json; version=2": {},
+		}
+	)
+	var tests = []struct {
+		content      map[string]*MediaType
+		accept, want string
+	}{
+		{content, "", "application/json"},
+		{content, "application/xml", "application/xml"},
+		{content, "application/json;q=0, */*", "application/xml"},
+		{content, "*/*;q=0.1, application/json;q=0.9, application/xml", "application/xml"},
+		{content, "application/*;q=0.5, text/*;q=0.8", "text/*"},
+		{content, "text/html", "text/*"},
+		{content, "*/*;q=0", ""},
+		{content, "image/png", ""},
+		{content, "garbage, application/xml", "application/xml"},
+		{content, "garbage", ""},
+		{versioned, "application/json; version=2", "application/json; version=2"},
+		{versioned, "application/json; version=3", ""},
+		{versioned, "application/json", "application/json; version=1"},
+	}
+	for _, test := range tests {
+		if got, _, _ := NegotiateMediaType(test.content, test.accept); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.accept, got, test.want)
+		}
+	}
+}
+
+func TestContentNegotiator(t *testing.T) {
+	var doc, err = FromJSON([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Pets", "version": "1.0.0"},
+		"paths": {
+			"/pets": {
+				"post": {
+					"requestBody": {"content": {"application/json": {}}},
+					"responses": {
+						"200": {"description": "OK", "content": {"application/json": {}}},
+						"400": {"description": "Bad request", "content": {"text/plain": {}}}
+					}
+				}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var negotiation *Negotiation
+	var handler = NewContentNegotiator(doc).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		negotiation = NegotiationFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	var tests = []struct {
+		method, path, contentType, accept string
+		status                            int
+		want                              string
+	}{
+		{"POST", "/pets", "application/json", "application/json", http.StatusNoContent,
+			`{"RequestKey":"application/json","Request":{},"ResponseKeys":{"200":"application/json"}}`},
+		{"POST", "/pets", "", "", http.StatusNoContent,
+			`{"RequestKey":"","Request":null,"ResponseKeys":{"200":"application/json","400":"text/plain"}}`},
+		{"POST", "/pets", "text/xml", "", http.StatusUnsupportedMediaType, `null`},
+		{"POST", "/pets", "application/json", "text/html", http.StatusNotAcceptable, `null`},
+		{"POST", "/pets", "application/json", "text/plain", http.StatusNotAcceptable, `null`},
+		{"GET", "/unknown", "text/xml", "text/html", http.StatusNoContent, `null`},
+	}
+	for _, test := range tests {
+		var req = httptest.NewRequest(test.method, test.path, nil)
+		if test.contentType != "" {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		var rec = httptest.NewRecorder()
+		negotiation = nil
+		handler.ServeHTTP(rec, req)
+		if rec.Code != test.status {
+			t.Errorf("%s %s %q %q: expected %d, got %d", test.method, test.path, test.contentType, test.accept, test.status, rec.Code)
+		}
+		if got := jsonString(t, negotiation); got != test.want {
+			t.Errorf("%s %s %q %q:\ngot  %s\nwant %s", test.method, test.path, test.contentType, test.accept, got, test.want)
+		}
+	}
+}
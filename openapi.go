@@ -4,6 +4,8 @@
 
 package openapi
 
+import "net/url"
+
 // OpenAPI object.
 // This is the root document object of the OpenAPI document.
 type OpenAPI struct {
@@ -51,6 +53,44 @@ type OpenAPI struct {
 	Tags []*Tag `json:"tags,omitempty"`
 	// Additional external documentation.
 	ExternalDocs *ExternalDocumentation `json:"externalDocs,omitempty"`
+	// SourceVersion is the openapi version of a document decoded by
+	// FromJSON, FromYAML or UpgradeV30 before it was upgraded to Version, or
+	// empty if the document was not upgraded. It is not a part of the
+	// specification.
+	SourceVersion string `json:"-"`
 
 	// This object MAY be extended with Specification Extensions.
 }
+
+// Sets the Base of all Server Objects defined in the document to the location
+// the document was retrieved from, so that relative server URLs expand to
+// absolute URLs.
+func (o *OpenAPI) SetLocation(location string) error {
+	var base, err = url.Parse(location)
+	if err != nil {
+		return err
+	}
+	var set = func(servers []*Server) {
+		for _, server := range servers {
+			if server != nil {
+				server.Base = base
+			}
+		}
+	}
+	set(o.Servers)
+	if o.Paths == nil {
+		return nil
+	}
+	for _, item := range o.Paths.Items {
+		if item == nil {
+			continue
+		}
+		set(item.Servers)
+		for _, method := range Methods {
+			if op := item.Operation(method); op != nil {
+				set(op.Servers)
+			}
+		}
+	}
+	return nil
+}
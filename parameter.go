@@ -55,7 +55,9 @@ type Parameter struct {
 	// for query - form; for path - simple; for header - simple; for cookie - form.
 	Style string `json:"style,omitempty"`
 	// When this is true, parameter values of type array or object generate separate parameters for each value of the array or key-value pair of the map. For other types of parameters this property has no effect. When style is form, the default value is true. For all other styles, the default value is false.
-	Explode bool `json:"explode,omitempty"`
+	//
+	// A nil value means the default for the style is used.
+	Explode *bool `json:"explode,omitempty"`
 	// Determines whether the parameter value SHOULD allow reserved characters, as defined by RFC3986 :/?#[]@!$&'()*+,;= to be included without percent-encoding. This property only applies to parameters with an in value of query. The default value is false.
 	AllowReserved bool `json:"allowReserved,omitempty"`
 	// Holds Schema Object | Reference Object
@@ -85,6 +87,25 @@ type Parameter struct {
 	// This object MAY be extended with Specification Extensions.
 }
 
+// Returns the parameter style and explode values with defaults for the
+// parameter location applied.
+func (p *Parameter) SerializationStyle() (style string, explode bool) {
+	style = p.Style
+	if style == "" {
+		switch p.In {
+		case "query", "cookie":
+			style = "form"
+		default:
+			style = "simple"
+		}
+	}
+	explode = style == "form"
+	if p.Explode != nil {
+		explode = *p.Explode
+	}
+	return
+}
+
 // Style Values
 // In order to support common ways of serializing simple parameters, a set of style values are defined.
 
@@ -4,6 +4,11 @@
 
 package openapi
 
+import (
+	"net/http"
+	"strings"
+)
+
 // Path Item Object
 // Describes the operations available on a single path. A Path Item MAY be
 // empty, due to ACL constraints. The path itself is still exposed to the
@@ -49,6 +54,66 @@ type PathItem struct {
 	// This object MAY be extended with Specification Extensions.
 }
 
+// Methods lists HTTP methods for which a PathItem can define an Operation, in
+// the order they are declared in PathItem.
+var Methods = []string{
+	http.MethodGet,
+	http.MethodPut,
+	http.MethodPost,
+	http.MethodDelete,
+	http.MethodOptions,
+	http.MethodHead,
+	http.MethodPatch,
+	http.MethodTrace,
+}
+
+// Returns the Operation defined for the HTTP method or nil if none defined.
+// Method is case insensitive.
+func (pi *PathItem) Operation(method string) *Operation {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		return pi.Get
+	case http.MethodPut:
+		return pi.Put
+	case http.MethodPost:
+		return pi.Post
+	case http.MethodDelete:
+		return pi.Delete
+	case http.MethodOptions:
+		return pi.Options
+	case http.MethodHead:
+		return pi.Head
+	case http.MethodPatch:
+		return pi.Patch
+	case http.MethodTrace:
+		return pi.Trace
+	}
+	return nil
+}
+
+// Sets the Operation for the HTTP method. Method is case insensitive.
+// Unsupported methods are ignored.
+func (pi *PathItem) SetOperation(method string, op *Operation) {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		pi.Get = op
+	case http.MethodPut:
+		pi.Put = op
+	case http.MethodPost:
+		pi.Post = op
+	case http.MethodDelete:
+		pi.Delete = op
+	case http.MethodOptions:
+		pi.Options = op
+	case http.MethodHead:
+		pi.Head = op
+	case http.MethodPatch:
+		pi.Patch = op
+	case http.MethodTrace:
+		pi.Trace = op
+	}
+}
+
 // Path Item Object Example
 // {
 //   "get": {
@@ -4,6 +4,12 @@
 
 package openapi
 
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
 // Paths Object
 // Holds the relative paths to the individual endpoints and their operations.
 // The path is appended to the URL from the Server Object in order to construct
@@ -17,11 +23,52 @@ type Paths struct {
 	// counterparts. Templated paths with the same hierarchy but different 
 	// templated names MUST NOT exist as they are identical. In case of
 	// ambiguous matching, it's up to the tooling to decide which one to use.
+	//
 	// /{path} *PathItem
+	Items map[string]*PathItem `json:"-"`
 
 	// This object MAY be extended with Specification Extensions.
 }
 
+// Returns the paths sorted lexically.
+func (p *Paths) Keys() (result []string) {
+	result = make([]string, 0, len(p.Items))
+	for key := range p.Items {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p *Paths) MarshalJSON() ([]byte, error) {
+	if p.Items == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(p.Items)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// Specification Extensions are skipped.
+func (p *Paths) UnmarshalJSON(data []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	p.Items = make(map[string]*PathItem, len(m))
+	for key, raw := range m {
+		if strings.HasPrefix(key, "x-") {
+			continue
+		}
+		var item = &PathItem{}
+		if err := json.Unmarshal(raw, item); err != nil {
+			return err
+		}
+		p.Items[key] = item
+	}
+	return nil
+}
+
 // Path Templating Matching
 // Assuming the following paths, the concrete definition, /pets/mine, will be matched first if used:
 
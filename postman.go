@@ -0,0 +1,741 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PostmanSchema is the schema of collections generated by
+// GeneratePostmanCollection.
+const PostmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// GeneratePostmanCollection generates a Postman v2.1 collection of doc.
+//
+// Operations are grouped in folders by their first tag, in the order of the
+// document Tags followed by tags in order of appearance. Operations without
+// tags are placed at the root of the collection.
+//
+// Request URLs are relative to a baseUrl variable holding the URL of the
+// first document server, with server variables written as collection
+// variables holding their defaults. Parameters and request bodies are
+// pre-filled from their examples, or from schema defaults, examples and
+// enums. Bodies without examples are generated from their schema. Optional
+// parameters without a value are disabled.
+//
+// Security Requirements are mapped to Postman auth of the collection and of
+// operations that override them, using the first scheme of a requirement
+// that Postman supports: http basic and bearer, apiKey, oauth2 and
+// openIdConnect, which is sent as a bearer token. Credentials are collection
+// variables named after the scheme, e.g. "petAuthToken".
+//
+// Examples of response media types are saved as example responses.
+func GeneratePostmanCollection(doc *OpenAPI) ([]byte, error) {
+	var g = &postmanGenerator{doc: doc, vars: make(map[string]bool)}
+	var collection, err = g.collection()
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(collection, "", "  ")
+}
+
+// postmanCollection is a Postman v2.1 collection.
+type postmanCollection struct {
+	Info     *postmanInfo   `json:"info"`
+	Item     []*postmanItem `json:"item"`
+	Auth     *postmanAuth   `json:"auth,omitempty"`
+	Variable []*postmanPair `json:"variable,omitempty"`
+}
+
+type postmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// postmanItem is a folder if it has items and a request otherwise.
+type postmanItem struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Item        []*postmanItem     `json:"item,omitempty"`
+	Request     *postmanRequest    `json:"request,omitempty"`
+	Response    []*postmanResponse `json:"response,omitempty"`
+}
+
+type postmanRequest struct {
+	Method      string         `json:"method"`
+	Header      []*postmanPair `json:"header"`
+	Body        *postmanBody   `json:"body,omitempty"`
+	URL         *postmanURL    `json:"url"`
+	Auth        *postmanAuth   `json:"auth,omitempty"`
+	Description string         `json:"description,omitempty"`
+}
+
+type postmanURL struct {
+	Raw      string         `json:"raw"`
+	Host     []string       `json:"host"`
+	Path     []string       `json:"path,omitempty"`
+	Query    []*postmanPair `json:"query,omitempty"`
+	Variable []*postmanPair `json:"variable,omitempty"`
+}
+
+// postmanPair is a header, query parameter, variable, form field or auth
+// attribute.
+type postmanPair struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+type postmanBody struct {
+	Mode       string                 `json:"mode"`
+	Raw        string                 `json:"raw,omitempty"`
+	URLEncoded []*postmanPair         `json:"urlencoded,omitempty"`
+	FormData   []*postmanPair         `json:"formdata,omitempty"`
+	Options    map[string]interface{} `json:"options,omitempty"`
+}
+
+type postmanAuth struct {
+	Type   string         `json:"type"`
+	Basic  []*postmanPair `json:"basic,omitempty"`
+	Bearer []*postmanPair `json:"bearer,omitempty"`
+	APIKey []*postmanPair `json:"apikey,omitempty"`
+	OAuth2 []*postmanPair `json:"oauth2,omitempty"`
+}
+
+type postmanResponse struct {
+	Name            string          `json:"name"`
+	OriginalRequest *postmanRequest `json:"originalRequest"`
+	Status          string          `json:"status"`
+	Code            int             `json:"code"`
+	PreviewLanguage string          `json:"_postman_previewlanguage"`
+	Header          []*postmanPair  `json:"header"`
+	Body            string          `json:"body"`
+}
+
+// postmanGenerator generates a Postman collection of a document.
+type postmanGenerator struct {
+	doc *OpenAPI
+	// variables are collection variables in declaration order.
+	variables []*postmanPair
+	vars      map[string]bool
+}
+
+// postmanSampleDepth limits the nesting of values generated from schemas.
+const postmanSampleDepth = 8
+
+// Declares collection variable name if not declared.
+func (g *postmanGenerator) variable(name, value, description string) {
+	if g.vars[name] {
+		return
+	}
+	g.vars[name] = true
+	g.variables = append(g.variables, &postmanPair{Key: name, Value: value, Type: "string", Description: description})
+}
+
+// Returns the collection of the document.
+func (g *postmanGenerator) collection() (*postmanCollection, error) {
+	var c = &postmanCollection{
+		Info: &postmanInfo{Schema: PostmanSchema},
+		Item: []*postmanItem{},
+	}
+	if info := g.doc.Info; info != nil {
+		c.Info.Name, c.Info.Description, c.Info.Version = info.Title, info.Description, info.Version
+	}
+	g.servers()
+	var err error
+	if c.Auth, err = g.auth(g.doc.Security); err != nil {
+		return nil, err
+	}
+	var (
+		folders = make(map[string]*postmanItem)
+		order   []*postmanItem
+	)
+	for _, tag := range g.doc.Tags {
+		if tag != nil && folders[tag.Name] == nil {
+			folders[tag.Name] = &postmanItem{Name: tag.Name, Description: tag.Description}
+			order = append(order, folders[tag.Name])
+		}
+	}
+	var root []*postmanItem
+	if g.doc.Paths != nil {
+		for _, path := range g.doc.Paths.Keys() {
+			var item = g.doc.Paths.Items[path]
+			for _, method := range Methods {
+				var op = item.Operation(method)
+				if op == nil {
+					continue
+				}
+				var pi, err = g.item(method, path, item, op)
+				if err != nil {
+					return nil, fmt.Errorf("%s %s: %w", method, path, err)
+				}
+				if len(op.Tags) == 0 {
+					root = append(root, pi)
+					continue
+				}
+				var folder = folders[op.Tags[0]]
+				if folder == nil {
+					folder = &postmanItem{Name: op.Tags[0]}
+					folders[op.Tags[0]] = folder
+					order = append(order, folder)
+				}
+				folder.Item = append(folder.Item, pi)
+			}
+		}
+	}
+	for _, folder := range order {
+		if len(folder.Item) > 0 {
+			c.Item = append(c.Item, folder)
+		}
+	}
+	c.Item = append(c.Item, root...)
+	c.Variable = g.variables
+	return c, nil
+}
+
+// Declares baseUrl and server variables of the first document server.
+func (g *postmanGenerator) servers() {
+	if len(g.doc.Servers) == 0 || g.doc.Servers[0] == nil {
+		g.variable("baseUrl", "", "")
+		return
+	}
+	var server = g.doc.Servers[0]
+	var base = templateExpression.ReplaceAllString(strings.TrimSuffix(server.URL, "/"), "{{$1}}")
+	g.variable("baseUrl", base, server.Description)
+	var names = make([]string, 0, len(server.Variables))
+	for name := range server.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if sv := server.Variables[name]; sv != nil {
+			var description = sv.Description
+			if len(sv.Enum) > 0 {
+				description = strings.TrimSpace(description + " One of: " + strings.Join(sv.Enum, ", ") + ".")
+			}
+			g.variable(name, sv.Default, description)
+		}
+	}
+}
+
+// Returns the request item of op.
+func (g *postmanGenerator) item(method, path string, item *PathItem, op *Operation) (*postmanItem, error) {
+	var name = op.Summary
+	if name == "" {
+		name = op.OperationID
+	}
+	if name == "" {
+		name = method + " " + path
+	}
+	var req, err = g.request(method, path, item, op)
+	if err != nil {
+		return nil, err
+	}
+	var result = &postmanItem{Name: name, Request: req}
+	if result.Response, err = g.responses(req, op); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Returns the request of op.
+func (g *postmanGenerator) request(method, path string, item *PathItem, op *Operation) (*postmanRequest, error) {
+	var req = &postmanRequest{
+		Method:      method,
+		Header:      []*postmanPair{},
+		URL:         &postmanURL{Host: []string{"{{baseUrl}}"}},
+		Description: op.Description,
+	}
+	if op.Security != nil {
+		var auth, err = g.auth(op.Security)
+		if err != nil {
+			return nil, err
+		}
+		if auth == nil {
+			auth = &postmanAuth{Type: "noauth"}
+		}
+		req.Auth = auth
+	}
+	var params, err = g.doc.operationParameters(item, op)
+	if err != nil {
+		return nil, err
+	}
+	var cookies []string
+	for _, p := range params {
+		var values, err = g.parameterValues(p)
+		if err != nil {
+			return nil, fmt.Errorf("parameter '%s': %w", p.Name, err)
+		}
+		var pair = &postmanPair{Key: p.Name, Description: p.Description}
+		if len(values) > 0 {
+			pair.Value = values[0]
+		}
+		switch p.In {
+		case "path":
+			req.URL.Variable = append(req.URL.Variable, pair)
+		case "query":
+			pair.Disabled = len(values) == 0 && !p.Required
+			req.URL.Query = append(req.URL.Query, pair)
+			for i := 1; i < len(values); i++ {
+				req.URL.Query = append(req.URL.Query, &postmanPair{Key: p.Name, Value: values[i]})
+			}
+		case "header":
+			switch http.CanonicalHeaderKey(p.Name) {
+			case "Accept", "Content-Type", "Authorization":
+				continue
+			}
+			pair.Disabled = len(values) == 0 && !p.Required
+			req.Header = append(req.Header, pair)
+		case "cookie":
+			if len(values) > 0 {
+				cookies = append(cookies, p.Name+"="+values[0])
+			}
+		}
+	}
+	if len(cookies) > 0 {
+		req.Header = append(req.Header, &postmanPair{Key: "Cookie", Value: strings.Join(cookies, "; ")})
+	}
+	var segments = strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = templateExpression.ReplaceAllString(segment, ":$1")
+	}
+	req.URL.Path = segments
+	req.URL.Raw = "{{baseUrl}}/" + strings.Join(segments, "/")
+	var query []string
+	for _, pair := range req.URL.Query {
+		if !pair.Disabled {
+			query = append(query, pair.Key+"="+pair.Value)
+		}
+	}
+	if len(query) > 0 {
+		req.URL.Raw += "?" + strings.Join(query, "&")
+	}
+	if op.RequestBody != nil {
+		var rb *RequestBody
+		if err := g.doc.resolve(op.RequestBody, &rb); err != nil {
+			return nil, err
+		}
+		if ct, mt := preferredContent(rb.Content); mt != nil {
+			if req.Body, err = g.body(ct, mt); err != nil {
+				return nil, fmt.Errorf("request body: %w", err)
+			}
+			if ct != "multipart/form-data" {
+				req.Header = append(req.Header, &postmanPair{Key: "Content-Type", Value: ct})
+			}
+		}
+	}
+	return req, nil
+}
+
+// Returns values of p to pre-fill from its examples or schema. Arrays of
+// exploded form parameters have a value per item and other arrays are comma
+// separated. Returns nil if p has no value.
+func (g *postmanGenerator) parameterValues(p *Parameter) ([]string, error) {
+	var (
+		schema   = p.Schema
+		example  = p.Example
+		examples = p.Examples
+	)
+	if len(p.Content) > 0 {
+		var mt = p.Content[sortedContentKeys(p.Content)[0]]
+		schema, example, examples = mt.Schema, mt.Example, nil
+		if example == nil && len(mt.Examples) > 0 {
+			examples = mt.Examples
+		}
+	}
+	var value, ok, err = g.exampleValue(example, examples)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		var s *Schema
+		if err := g.doc.resolve(schema, &s); err != nil {
+			return nil, err
+		}
+		if value, ok = schemaExample(s); !ok {
+			return nil, nil
+		}
+	}
+	var items, isList = value.([]interface{})
+	if !isList || len(p.Content) > 0 {
+		return []string{formatValue(value)}, nil
+	}
+	var values = make([]string, len(items))
+	for i, item := range items {
+		values[i] = formatValue(item)
+	}
+	if style, explode := p.SerializationStyle(); style == "form" && explode {
+		return values, nil
+	}
+	return []string{strings.Join(values, ",")}, nil
+}
+
+// Returns the value of example, or of the first of examples holding Example
+// Object | Reference Object values by name, and true if either has a value.
+func (g *postmanGenerator) exampleValue(example, examples interface{}) (interface{}, bool, error) {
+	if example != nil {
+		return example, true, nil
+	}
+	var m = rawObject(examples)
+	for _, name := range sortedKeys(m) {
+		var ex *Example
+		if err := g.doc.resolve(m[name], &ex); err != nil {
+			return nil, false, err
+		}
+		if ex != nil && ex.Value != nil {
+			return ex.Value, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// Returns the value s defines as a default, example, const or first enum
+// value and true if it defines one.
+func schemaExample(s *Schema) (interface{}, bool) {
+	switch {
+	case s == nil:
+		return nil, false
+	case s.Default != nil:
+		return s.Default, true
+	case len(s.Examples) > 0:
+		return s.Examples[0], true
+	case s.Example != nil:
+		return s.Example, true
+	case s.Const != nil:
+		return s.Const, true
+	case len(s.Enum) > 0:
+		return s.Enum[0], true
+	}
+	return nil, false
+}
+
+// Returns the body of media type mt of content type ct.
+func (g *postmanGenerator) body(ct string, mt *MediaType) (*postmanBody, error) {
+	var value, ok, err = g.exampleValue(mt.Example, mt.Examples)
+	if err != nil {
+		return nil, err
+	}
+	var s *Schema
+	if err := g.doc.resolve(mt.Schema, &s); err != nil {
+		return nil, err
+	}
+	if !ok {
+		if value, err = g.sample(s, 0, make(map[string]bool)); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case isJSONMediaType(ct):
+		var data, err = json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &postmanBody{
+			Mode:    "raw",
+			Raw:     string(data),
+			Options: map[string]interface{}{"raw": map[string]interface{}{"language": "json"}},
+		}, nil
+	case ct == "application/x-www-form-urlencoded" || ct == "multipart/form-data":
+		var (
+			fields = rawObject(value)
+			props  = g.doc.objectProperties(s)
+			pairs  = []*postmanPair{}
+		)
+		for _, name := range sortedKeys(fields) {
+			var prop *Schema
+			if err := g.doc.resolve(props[name], &prop); err != nil {
+				return nil, err
+			}
+			var pair = &postmanPair{Key: name, Value: formatValue(fields[name]), Type: "text"}
+			if ct == "multipart/form-data" && isBinaryContent(prop) {
+				pair.Type, pair.Value = "file", ""
+			}
+			pairs = append(pairs, pair)
+		}
+		if ct == "multipart/form-data" {
+			return &postmanBody{Mode: "formdata", FormData: pairs}, nil
+		}
+		return &postmanBody{Mode: "urlencoded", URLEncoded: pairs}, nil
+	case isBinaryContent(s) || s == nil && value == nil:
+		return &postmanBody{Mode: "file"}, nil
+	}
+	return &postmanBody{Mode: "raw", Raw: formatValue(value)}, nil
+}
+
+// Returns true if s describes binary content.
+func isBinaryContent(s *Schema) bool {
+	return s != nil && s.HasType(TypeString) && s.ContentEncoding == "" &&
+		(s.ContentMediaType != "" || s.Format == "binary")
+}
+
+// Returns a value generated from s at depth. seen holds names of component
+// schemas being generated.
+func (g *postmanGenerator) sample(s *Schema, depth int, seen map[string]bool) (interface{}, error) {
+	if s == nil || depth > postmanSampleDepth {
+		return nil, nil
+	}
+	if s.Ref != "" {
+		var name = componentName(s.Ref, "schemas")
+		if seen[name] {
+			return nil, nil
+		}
+		var target *Schema
+		if err := g.doc.resolve(s, &target); err != nil {
+			return nil, err
+		}
+		seen[name] = true
+		defer delete(seen, name)
+		return g.sample(target, depth+1, seen)
+	}
+	if value, ok := schemaExample(s); ok {
+		return value, nil
+	}
+	if variants := unionVariants(s); len(variants) > 0 {
+		return g.sample(variants[0], depth+1, seen)
+	}
+	switch schemaGoKind(s) {
+	case TypeObject, "":
+		var props = g.doc.objectProperties(s)
+		if len(props) == 0 && schemaGoKind(s) == "" {
+			return nil, nil
+		}
+		var result = make(map[string]interface{})
+		for name, prop := range props {
+			var resolved *Schema
+			if err := g.doc.resolve(prop, &resolved); err != nil {
+				return nil, err
+			}
+			if resolved != nil && resolved.ReadOnly {
+				continue
+			}
+			var value, err = g.sample(prop, depth+1, seen)
+			if err != nil {
+				return nil, err
+			}
+			result[name] = value
+		}
+		return result, nil
+	case TypeArray:
+		var item, err = g.sample(s.Items, depth+1, seen)
+		if err != nil || item == nil {
+			return []interface{}{}, err
+		}
+		return []interface{}{item}, nil
+	case TypeString:
+		switch s.Format {
+		case "date-time":
+			return "2021-01-01T00:00:00Z", nil
+		case "date":
+			return "2021-01-01", nil
+		case "email":
+			return "user@example.com", nil
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000", nil
+		case "uri", "url":
+			return "https://example.com", nil
+		}
+		return "string", nil
+	case TypeInteger, TypeNumber:
+		if s.Minimum != nil {
+			return *s.Minimum, nil
+		}
+		return 0, nil
+	case TypeBoolean:
+		return false, nil
+	}
+	return nil, nil
+}
+
+// Returns the Postman auth of the first scheme of requirements that Postman
+// supports or nil if there is none.
+func (g *postmanGenerator) auth(requirements []*SecurityRequirement) (*postmanAuth, error) {
+	for _, req := range requirements {
+		if req == nil {
+			continue
+		}
+		var names = make([]string, 0, len(req.Schemes))
+		for name := range req.Schemes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if g.doc.Components == nil {
+				return nil, fmt.Errorf("%w: security scheme '%s'", ErrUnresolvedReference, name)
+			}
+			var scheme *SecurityScheme
+			if err := g.doc.resolve(g.doc.Components.SecuritySchemes[name], &scheme); err != nil {
+				return nil, err
+			}
+			if scheme == nil {
+				return nil, fmt.Errorf("%w: security scheme '%s'", ErrUnresolvedReference, name)
+			}
+			if auth := g.schemeAuth(name, scheme, req.Schemes[name]); auth != nil {
+				return auth, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Returns the Postman auth of scheme name with scopes or nil if Postman does
+// not support the scheme.
+func (g *postmanGenerator) schemeAuth(name string, scheme *SecurityScheme, scopes []string) *postmanAuth {
+	var credential = func(suffix string) string {
+		var key = lowerIdent(name) + suffix
+		g.variable(key, "", scheme.Description)
+		return "{{" + key + "}}"
+	}
+	switch scheme.Type {
+	case "http":
+		switch strings.ToLower(scheme.Scheme) {
+		case "basic":
+			return &postmanAuth{Type: "basic", Basic: []*postmanPair{
+				{Key: "username", Value: credential("Username"), Type: "string"},
+				{Key: "password", Value: credential("Password"), Type: "string"},
+			}}
+		case "bearer":
+			return &postmanAuth{Type: "bearer", Bearer: []*postmanPair{
+				{Key: "token", Value: credential("Token"), Type: "string"},
+			}}
+		}
+	case "apiKey":
+		var key, value, in = scheme.Name, credential("Key"), scheme.In
+		if in == "cookie" {
+			key, value, in = "Cookie", scheme.Name+"="+value, "header"
+		}
+		return &postmanAuth{Type: "apikey", APIKey: []*postmanPair{
+			{Key: "key", Value: key, Type: "string"},
+			{Key: "value", Value: value, Type: "string"},
+			{Key: "in", Value: in, Type: "string"},
+		}}
+	case "oauth2":
+		if scheme.Flows == nil {
+			return nil
+		}
+		var flows = []struct {
+			grant string
+			flow  *OAuthFlow
+		}{
+			{"authorization_code", scheme.Flows.AuthorizationCode},
+			{"client_credentials", scheme.Flows.ClientCredentials},
+			{"password_credentials", scheme.Flows.Password},
+			{"implicit", scheme.Flows.Implicit},
+		}
+		for _, f := range flows {
+			if f.flow == nil {
+				continue
+			}
+			if len(scopes) == 0 {
+				for scope := range f.flow.Scopes {
+					scopes = append(scopes, scope)
+				}
+				sort.Strings(scopes)
+			}
+			var pairs = []*postmanPair{{Key: "grant_type", Value: f.grant, Type: "string"}}
+			if f.flow.AuthorizationURL != "" {
+				pairs = append(pairs, &postmanPair{Key: "authUrl", Value: f.flow.AuthorizationURL, Type: "string"})
+			}
+			if f.flow.TokenURL != "" {
+				pairs = append(pairs, &postmanPair{Key: "accessTokenUrl", Value: f.flow.TokenURL, Type: "string"})
+			}
+			pairs = append(pairs,
+				&postmanPair{Key: "clientId", Value: credential("ClientId"), Type: "string"},
+				&postmanPair{Key: "clientSecret", Value: credential("ClientSecret"), Type: "string"},
+				&postmanPair{Key: "scope", Value: strings.Join(scopes, " "), Type: "string"},
+			)
+			if f.grant == "password_credentials" {
+				pairs = append(pairs,
+					&postmanPair{Key: "username", Value: credential("Username"), Type: "string"},
+					&postmanPair{Key: "password", Value: credential("Password"), Type: "string"},
+				)
+			}
+			return &postmanAuth{Type: "oauth2", OAuth2: pairs}
+		}
+	case "openIdConnect":
+		return &postmanAuth{Type: "bearer", Bearer: []*postmanPair{
+			{Key: "token", Value: credential("Token"), Type: "string"},
+		}}
+	}
+	return nil
+}
+
+// Returns example responses of op for request req.
+func (g *postmanGenerator) responses(req *postmanRequest, op *Operation) ([]*postmanResponse, error) {
+	if op.Responses == nil {
+		return nil, nil
+	}
+	var result []*postmanResponse
+	for _, key := range op.Responses.Keys() {
+		var code, err = strconv.Atoi(strings.NewReplacer("X", "0", "x", "0").Replace(key))
+		if err != nil {
+			continue
+		}
+		var resp *Response
+		if err := g.doc.resolve(op.Responses.Codes[key], &resp); err != nil {
+			return nil, err
+		}
+		if resp == nil {
+			continue
+		}
+		for _, ct := range sortedContentKeys(resp.Content) {
+			var mt = resp.Content[ct]
+			if mt == nil {
+				continue
+			}
+			var (
+				names  []string
+				values []interface{}
+			)
+			if mt.Example != nil {
+				names, values = append(names, resp.Description), append(values, mt.Example)
+			}
+			for _, name := range sortedKeys(mt.Examples) {
+				var ex *Example
+				if err := g.doc.resolve(mt.Examples[name], &ex); err != nil {
+					return nil, err
+				}
+				if ex == nil || ex.Value == nil {
+					continue
+				}
+				if ex.Summary != "" {
+					name = ex.Summary
+				}
+				names, values = append(names, name), append(values, ex.Value)
+			}
+			for i, value := range values {
+				var r = &postmanResponse{
+					Name:            names[i],
+					OriginalRequest: req,
+					Status:          http.StatusText(code),
+					Code:            code,
+					PreviewLanguage: "text",
+					Header:          []*postmanPair{{Key: "Content-Type", Value: ct}},
+					Body:            formatValue(value),
+				}
+				if r.Name == "" {
+					r.Name = key + " " + r.Status
+				}
+				if isJSONMediaType(ct) {
+					var data, err = json.MarshalIndent(value, "", "  ")
+					if err != nil {
+						return nil, err
+					}
+					r.PreviewLanguage, r.Body = "json", string(data)
+				}
+				result = append(result, r)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGeneratePostmanCollection(t *testing.T) {
+	var doc, err = FromJSON([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Pets", "version": "1.0"},
+		"servers": [{"url": "https://{region}.example.com/v1/", "variables": {"region": {"default": "eu"}}}],
+		"security": [{"petAuth": []}],
+		"tags": [{"name": "pets", "description": "Pet operations"}],
+		"paths": {
+			"/pets/{petId}": {
+				"get": {
+					"tags": ["pets"],
+					"summary": "Get a pet",
+					"parameters": [
+						{"name": "petId", "in": "path", "required": true, "example": 7, "schema": {"type": "integer"}},
+						{"name": "fields", "in": "query", "schema": {"type": "array", "items": {"type": "string"}, "default": ["name", "age"]}},
+						{"name": "X-Trace", "in": "header", "schema": {"type": "string"}}
+					],
+					"responses": {
+						"200": {
+							"description": "A pet",
+							"content": {"application/json": {"examples": {"rex": {"summary": "Rex", "value": {"name": "Rex"}}}}}
+						}
+					}
+				}
+			},
+			"/pets": {
+				"post": {
+					"operationId": "createPet",
+					"security": [],
+					"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+					"responses": {"201": {"description": "Created"}}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"Pet": {
+					"type": "object",
+					"properties": {
+						"id": {"type": "integer", "readOnly": true},
+						"name": {"type": "string"},
+						"kind": {"enum": ["cat", "dog"]}
+					}
+				}
+			},
+			"securitySchemes": {"petAuth": {"type": "http", "scheme": "bearer"}}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := GeneratePostmanCollection(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err = json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		items  = rawList(out["item"])
+		folder = rawObject(items[0])
+		get    = rawObject(rawList(folder["item"])[0])
+		post   = rawObject(items[1])
+	)
+	var checks = []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"auth", out["auth"], `{"bearer":[{"key":"token","type":"string","value":"{{petAuthToken}}"}],"type":"bearer"}`},
+		{"variable", out["variable"], `[{"key":"baseUrl","type":"string","value":"https://{{region}}.example.com/v1"},` +
+			`{"key":"region","type":"string","value":"eu"},{"key":"petAuthToken","type":"string","value":""}]`},
+		{"folder", []interface{}{folder["name"], folder["description"]}, `["pets","Pet operations"]`},
+		{"get", rawObject(get["request"]), `{"header":[{"disabled":true,"key":"X-Trace","value":""}],"method":"GET",` +
+			`"url":{"host":["{{baseUrl}}"],"path":["pets",":petId"],` +
+			`"query":[{"key":"fields","value":"name"},{"key":"fields","value":"age"}],` +
+			`"raw":"{{baseUrl}}/pets/:petId?fields=name\u0026fields=age","variable":[{"key":"petId","value":"7"}]}}`},
+		{"response", []interface{}{rawObject(rawList(get["response"])[0])["name"], rawObject(rawList(get["response"])[0])["body"]},
+			`["Rex","{\n  \"name\": \"Rex\"\n}"]`},
+		{"post", rawObject(post["request"])["auth"], `{"type":"noauth"}`},
+		{"body", rawObject(rawObject(post["request"])["body"])["raw"], `"{\n  \"kind\": \"cat\",\n  \"name\": \"string\"\n}"`},
+	}
+	for _, check := range checks {
+		if got := jsonString(t, check.v); got != check.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", check.name, got, check.want)
+		}
+	}
+}
@@ -0,0 +1,877 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ProtoFieldsExtension is the Schema extension holding numbers of fields of
+// the Protocol Buffers message or enum generated for the schema, keyed by
+// property names, oneof field names of oneOf schemas or enum values.
+//
+// Numbers of keys that no longer exist remain reserved so that they are not
+// reused. See AssignProtoFieldNumbers.
+const ProtoFieldsExtension = "x-proto-fields"
+
+// AssignProtoFieldNumbers records numbers of fields of messages and enums
+// GenerateProto generates for component schemas of doc in ProtoFieldsExtension
+// of the schemas, so that numbers remain stable across runs as the schemas
+// change. Fields without a recorded number are numbered after the highest
+// recorded number in name order. Component schemas are replaced with
+// *Schema values holding the extension.
+//
+// Returns the number of newly assigned field numbers.
+func AssignProtoFieldNumbers(doc *OpenAPI) (int, error) {
+	if doc.Components == nil {
+		return 0, nil
+	}
+	var g = newProtoGenerator(doc, "")
+	g.assign = true
+	for name, v := range doc.Components.Schemas {
+		var s, err = g.schema(v)
+		if err != nil {
+			return 0, fmt.Errorf("schema '%s': %w", name, err)
+		}
+		if s != nil {
+			doc.Components.Schemas[name] = s
+		}
+	}
+	if err := g.declareComponents(); err != nil {
+		return 0, err
+	}
+	return g.assigned, nil
+}
+
+// GenerateProto generates a proto3 file of package pkg with messages of
+// doc schemas and a service of doc operations.
+//
+// Component schemas are mapped as follows:
+//
+//   - Objects with properties are messages. Properties that are not
+//     required and have scalar types are optional fields. Property names
+//     are converted to snake case with a json_name option of the property
+//     name.
+//   - oneOf schemas are messages with a single oneof.
+//   - String enums are enums with an <ENUM>_UNSPECIFIED zero value.
+//   - Other schemas are not declared and are expanded where referenced.
+//
+// Field numbers are read from ProtoFieldsExtension of schemas, see
+// AssignProtoFieldNumbers. Fields without a recorded number are numbered
+// after the highest recorded number in name order.
+//
+// Each operation is an rpc of a service named after the API title with a
+// google.api.http option of its method and path template. The request
+// message holds path and query parameters in definition order followed by
+// a body field mapped to the request body. Header and cookie parameters are
+// not mapped. The response is the message of the first success response,
+// google.protobuf.Empty if it has no content or a message whose value field
+// is mapped to the response body otherwise.
+func GenerateProto(doc *OpenAPI, pkg string) ([]byte, error) {
+	for _, part := range strings.Split(pkg, ".") {
+		if !token.IsIdentifier(part) {
+			return nil, fmt.Errorf("invalid package name '%s'", pkg)
+		}
+	}
+	var g = newProtoGenerator(doc, pkg)
+	if err := g.declareComponents(); err != nil {
+		return nil, err
+	}
+	if err := g.declareService(); err != nil {
+		return nil, err
+	}
+	return g.source(), nil
+}
+
+// protoGenerator generates Protocol Buffers definitions of a document.
+type protoGenerator struct {
+	doc     *OpenAPI
+	pkg     string
+	imports map[string]bool
+	decls   strings.Builder
+	service strings.Builder
+	// types maps component schema names to message or enum names, or to an
+	// empty string for schemas that are expanded where referenced.
+	types map[string]string
+	// idents are declared top level names.
+	idents map[string]bool
+	// assign records assigned field numbers in schema extensions.
+	assign   bool
+	assigned int
+}
+
+// protoType is the type of a field.
+type protoType struct {
+	// Name is the type name, a map type or a scalar type.
+	Name     string
+	Repeated bool
+	// Scalar is true for scalar and enum types that have no presence
+	// unless optional.
+	Scalar bool
+}
+
+// protoField is a field of a message.
+type protoField struct {
+	Name     string
+	JSONName string
+	Type     protoType
+	Optional bool
+	Number   int
+	Doc      string
+	// Deprecated marks the field deprecated.
+	Deprecated bool
+}
+
+// Returns a new protoGenerator of package pkg for doc.
+func newProtoGenerator(doc *OpenAPI, pkg string) *protoGenerator {
+	return &protoGenerator{
+		doc:     doc,
+		pkg:     pkg,
+		imports: make(map[string]bool),
+		types:   make(map[string]string),
+		idents:  make(map[string]bool),
+	}
+}
+
+// Returns a unique top level name derived from name.
+func (g *protoGenerator) ident(name string) string {
+	var result = uniqueName(goIdent(name), g.idents)
+	g.idents[result] = true
+	return result
+}
+
+// Writes formatted declarations.
+func (g *protoGenerator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.decls, format, args...)
+}
+
+// Returns the generated file.
+func (g *protoGenerator) source() []byte {
+	var sb strings.Builder
+	sb.WriteString("// Code generated by openapi. DO NOT EDIT.\n\n")
+	sb.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&sb, "package %s;\n\n", g.pkg)
+	if len(g.imports) > 0 {
+		var imports = make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			imports = append(imports, path)
+		}
+		sort.Strings(imports)
+		for _, path := range imports {
+			fmt.Fprintf(&sb, "import %q;\n", path)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(g.service.String())
+	sb.WriteString(g.decls.String())
+	return []byte(strings.TrimRight(sb.String(), "\n") + "\n")
+}
+
+// Converts v holding Schema Object | Reference Object to a Schema without
+// following references. Returns nil if v is nil.
+func (g *protoGenerator) schema(v interface{}) (*Schema, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case *Schema:
+		return t, nil
+	}
+	var result = &Schema{}
+	if err := remarshal(v, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Returns true if s is generated as a message.
+func (g *protoGenerator) isMessage(s *Schema) bool {
+	if len(unionVariants(s)) > 1 {
+		return true
+	}
+	var kind = schemaGoKind(s)
+	return (kind == TypeObject || kind == "") && len(g.doc.objectProperties(s)) > 0
+}
+
+// Returns true if s is generated as an enum.
+func isProtoEnum(s *Schema) bool {
+	return len(s.Enum) > 0 && len(s.OneOf) == 0 && len(s.AllOf) == 0 &&
+		schemaGoKind(s) == TypeString && s.Format == ""
+}
+
+// Declares messages and enums of component schemas in name order.
+func (g *protoGenerator) declareComponents() error {
+	if g.doc.Components == nil {
+		return nil
+	}
+	var (
+		names   = make([]string, 0, len(g.doc.Components.Schemas))
+		schemas = make(map[string]*Schema)
+	)
+	for name := range g.doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var s, err = g.schema(g.doc.Components.Schemas[name])
+		if err != nil {
+			return fmt.Errorf("schema '%s': %w", name, err)
+		}
+		if s == nil || s.Ref != "" || !g.isMessage(s) && !isProtoEnum(s) {
+			g.types[name] = ""
+			continue
+		}
+		schemas[name] = s
+		g.types[name] = g.ident(name)
+	}
+	for _, name := range names {
+		var s = schemas[name]
+		if s == nil {
+			continue
+		}
+		var decl, err = g.declaration(g.types[name], s, "")
+		if err != nil {
+			return fmt.Errorf("schema '%s': %w", name, err)
+		}
+		g.printf("%s\n", decl)
+	}
+	return nil
+}
+
+// Returns the declaration of a message or enum named name for s, indented
+// by indent.
+func (g *protoGenerator) declaration(name string, s *Schema, indent string) (string, error) {
+	var sb strings.Builder
+	if s.Description != "" {
+		sb.WriteString(goComment(s.Description, indent))
+	}
+	if isProtoEnum(s) {
+		if err := g.enum(&sb, name, s, indent); err != nil {
+			return "", err
+		}
+		return sb.String(), nil
+	}
+	var (
+		fields []*protoField
+		oneof  string
+		nested strings.Builder
+		err    error
+	)
+	if variants := unionVariants(s); len(variants) > 1 {
+		oneof = protoSnake(name)
+		fields, err = g.oneofFields(name, variants, &nested, indent+"  ")
+	} else {
+		fields, err = g.propertyFields(name, s, &nested, indent+"  ")
+	}
+	if err != nil {
+		return "", err
+	}
+	var keys = make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = f.JSONName
+		if oneof != "" {
+			keys[i] = f.Name
+		}
+	}
+	numbers, reserved, err := g.fieldNumbers(s, keys)
+	if err != nil {
+		return "", err
+	}
+	for i, f := range fields {
+		f.Number = numbers[keys[i]]
+	}
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Number < fields[j].Number })
+	fmt.Fprintf(&sb, "%smessage %s {\n", indent, name)
+	sb.WriteString(nested.String())
+	if len(reserved) > 0 {
+		fmt.Fprintf(&sb, "%s  reserved %s;\n", indent, strings.Join(reserved, ", "))
+	}
+	var fieldIndent = indent + "  "
+	if oneof != "" {
+		fmt.Fprintf(&sb, "%s  oneof %s {\n", indent, oneof)
+		fieldIndent += "  "
+	}
+	for _, f := range fields {
+		writeProtoField(&sb, f, fieldIndent)
+	}
+	if oneof != "" {
+		fmt.Fprintf(&sb, "%s  }\n", indent)
+	}
+	fmt.Fprintf(&sb, "%s}\n", indent)
+	return sb.String(), nil
+}
+
+// Writes the declaration of f indented by indent.
+func writeProtoField(sb *strings.Builder, f *protoField, indent string) {
+	if f.Doc != "" {
+		sb.WriteString(goComment(f.Doc, indent))
+	}
+	var label string
+	switch {
+	case f.Type.Repeated:
+		label = "repeated "
+	case f.Optional:
+		label = "optional "
+	}
+	var options []string
+	if f.JSONName != "" && f.JSONName != protoJSONName(f.Name) {
+		options = append(options, "json_name = "+strconv.Quote(f.JSONName))
+	}
+	if f.Deprecated {
+		options = append(options, "deprecated = true")
+	}
+	var opts string
+	if len(options) > 0 {
+		opts = " [" + strings.Join(options, ", ") + "]"
+	}
+	fmt.Fprintf(sb, "%s%s%s %s = %d%s;\n", indent, label, f.Type.Name, f.Name, f.Number, opts)
+}
+
+// Writes an enum named name of values of s.
+func (g *protoGenerator) enum(sb *strings.Builder, name string, s *Schema, indent string) error {
+	var (
+		prefix = strings.ToUpper(protoSnake(name)) + "_"
+		keys   []string
+		names  = map[string]bool{prefix + "UNSPECIFIED": true}
+	)
+	for _, v := range s.Enum {
+		if str, ok := v.(string); ok && !containsString(keys, str) {
+			keys = append(keys, str)
+		}
+	}
+	var numbers, reserved, err = g.fieldNumbers(s, keys)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(sb, "%senum %s {\n", indent, name)
+	if len(reserved) > 0 {
+		fmt.Fprintf(sb, "%s  reserved %s;\n", indent, strings.Join(reserved, ", "))
+	}
+	fmt.Fprintf(sb, "%s  %sUNSPECIFIED = 0;\n", indent, prefix)
+	sort.SliceStable(keys, func(i, j int) bool { return numbers[keys[i]] < numbers[keys[j]] })
+	for _, key := range keys {
+		var value = strings.ToUpper(protoSnake(key))
+		if key == "" {
+			value = "EMPTY"
+		}
+		value = uniqueName(prefix+value, names)
+		names[value] = true
+		fmt.Fprintf(sb, "%s  %s = %d;\n", indent, value, numbers[key])
+	}
+	fmt.Fprintf(sb, "%s}\n", indent)
+	return nil
+}
+
+// Returns fields of properties of an object schema s of message name.
+// Nested declarations are written to nested.
+func (g *protoGenerator) propertyFields(name string, s *Schema, nested *strings.Builder, indent string) ([]*protoField, error) {
+	var (
+		props    = g.doc.objectProperties(s)
+		required = make(map[string]bool)
+		keys     = make([]string, 0, len(props))
+		names    = make(map[string]bool)
+		fields   []*protoField
+	)
+	g.doc.collectRequired(s, required, make(map[*Schema]bool))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var prop = props[key]
+		var f = &protoField{Name: uniqueName(protoSnake(key), names), JSONName: key}
+		names[f.Name] = true
+		var t, err = g.typeOf(prop, goIdent(key), nested, indent)
+		if err != nil {
+			return nil, fmt.Errorf("property '%s': %w", key, err)
+		}
+		f.Type = t
+		f.Optional = t.Scalar && !t.Repeated && !required[key]
+		if prop != nil {
+			f.Doc, f.Deprecated = prop.Description, prop.Deprecated
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// Returns oneof fields of variants of a oneOf schema of message name.
+// Nested declarations are written to nested.
+func (g *protoGenerator) oneofFields(name string, variants []*Schema, nested *strings.Builder, indent string) ([]*protoField, error) {
+	var (
+		names  = make(map[string]bool)
+		fields []*protoField
+	)
+	for i, variant := range variants {
+		var hint = fmt.Sprintf("Option%d", i+1)
+		switch {
+		case variant != nil && variant.Ref != "":
+			hint = componentName(variant.Ref, "schemas")
+		case variant != nil && variant.Title != "":
+			hint = variant.Title
+		}
+		var t, err = g.typeOf(variant, goIdent(hint), nested, indent)
+		if err != nil {
+			return nil, err
+		}
+		if t.Repeated || strings.HasPrefix(t.Name, "map<") {
+			g.imports["google/protobuf/struct.proto"] = true
+			t = protoType{Name: "google.protobuf.Value"}
+		}
+		var fieldName = protoSnake(hint)
+		if variant != nil && variant.Ref == "" && variant.Title == "" && t.Scalar {
+			fieldName = protoSnake(t.Name) + "_value"
+		}
+		var f = &protoField{Name: uniqueName(fieldName, names), Type: t}
+		names[f.Name] = true
+		if variant != nil {
+			f.Doc = variant.Description
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// Returns numbers of fields with keys of the message or enum of s, and
+// reserved numbers and names of keys of s that are not in keys. Numbers are
+// read from ProtoFieldsExtension of s, other keys are numbered in name order.
+func (g *protoGenerator) fieldNumbers(s *Schema, keys []string) (map[string]int, []string, error) {
+	var (
+		numbers = make(map[string]int)
+		used    = make(map[int]string)
+		max     int
+	)
+	if m, ok := s.Extensions[ProtoFieldsExtension].(map[string]interface{}); ok {
+		var recorded = make([]string, 0, len(m))
+		for key := range m {
+			recorded = append(recorded, key)
+		}
+		sort.Strings(recorded)
+		for _, key := range recorded {
+			var n, ok = protoNumber(m[key])
+			if !ok || n <= 0 {
+				return nil, nil, fmt.Errorf("%s: invalid number of '%s'", ProtoFieldsExtension, key)
+			}
+			if other, dup := used[n]; dup {
+				return nil, nil, fmt.Errorf("%s: '%s' and '%s' have the same number %d", ProtoFieldsExtension, other, key, n)
+			}
+			numbers[key], used[n] = n, key
+			if n > max {
+				max = n
+			}
+		}
+	}
+	var (
+		present  = make(map[string]bool)
+		sorted   = append([]string(nil), keys...)
+		assigned bool
+	)
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		present[key] = true
+		if _, ok := numbers[key]; ok {
+			continue
+		}
+		max++
+		if max >= 19000 && max <= 19999 {
+			max = 20000
+		}
+		numbers[key], used[max] = max, key
+		assigned = true
+		g.assigned++
+	}
+	if g.assign && assigned {
+		var m = make(map[string]interface{}, len(numbers))
+		for key, n := range numbers {
+			m[key] = n
+		}
+		if s.Extensions == nil {
+			s.Extensions = make(map[string]interface{})
+		}
+		s.Extensions[ProtoFieldsExtension] = m
+	}
+	var (
+		numbersOut []int
+		namesOut   []string
+	)
+	for key, n := range numbers {
+		if !present[key] {
+			numbersOut = append(numbersOut, n)
+			namesOut = append(namesOut, strconv.Quote(key))
+		}
+	}
+	if len(numbersOut) == 0 {
+		return numbers, nil, nil
+	}
+	sort.Ints(numbersOut)
+	sort.Strings(namesOut)
+	var reserved = make([]string, 0, len(numbersOut)+len(namesOut))
+	for _, n := range numbersOut {
+		reserved = append(reserved, strconv.Itoa(n))
+	}
+	return numbers, append(reserved, namesOut...), nil
+}
+
+// Returns the integer value of a number decoded from JSON or set in Go.
+func protoNumber(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), n == float64(int(n))
+	case json.Number:
+		var i, err = strconv.Atoi(string(n))
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// Returns the field type of schema v which may be a Schema Object or a
+// Reference Object. Messages and enums for inline schemas are declared in
+// nested using hint as their name.
+func (g *protoGenerator) typeOf(v interface{}, hint string, nested *strings.Builder, indent string) (protoType, error) {
+	var s, err = g.schema(v)
+	if err != nil {
+		return protoType{}, err
+	}
+	if s == nil || len(s.Types()) == 0 && schemaGoKind(s) == "" && len(s.OneOf) == 0 &&
+		len(s.AllOf) == 0 && len(s.Properties) == 0 && s.Ref == "" {
+		g.imports["google/protobuf/struct.proto"] = true
+		return protoType{Name: "google.protobuf.Value"}, nil
+	}
+	if s.Ref != "" {
+		if name := componentName(s.Ref, "schemas"); name != "" {
+			if t, ok := g.types[name]; ok && t != "" {
+				var target, _ = g.schema(g.doc.Components.Schemas[name])
+				return protoType{Name: t, Scalar: target != nil && isProtoEnum(target)}, nil
+			}
+		}
+		var target *Schema
+		if err = g.doc.resolve(s, &target); err != nil {
+			return protoType{}, err
+		}
+		return g.typeOf(target, hint, nested, indent)
+	}
+	switch {
+	case len(unionVariants(s)) == 1:
+		return g.typeOf(unionVariants(s)[0], hint, nested, indent)
+	case len(s.AllOf) == 1 && s.Type == nil && len(s.Properties) == 0:
+		return g.typeOf(s.AllOf[0], hint, nested, indent)
+	case g.isMessage(s) || isProtoEnum(s):
+		var decl, err = g.declaration(hint, s, indent)
+		if err != nil {
+			return protoType{}, err
+		}
+		nested.WriteString(decl)
+		return protoType{Name: hint, Scalar: isProtoEnum(s)}, nil
+	}
+	switch schemaGoKind(s) {
+	case TypeString:
+		switch {
+		case s.Format == "date-time":
+			g.imports["google/protobuf/timestamp.proto"] = true
+			return protoType{Name: "google.protobuf.Timestamp"}, nil
+		case s.Format == "byte" || s.Format == "binary" || s.ContentEncoding == "base64":
+			return protoType{Name: "bytes", Scalar: true}, nil
+		}
+		return protoType{Name: "string", Scalar: true}, nil
+	case TypeInteger:
+		switch s.Format {
+		case "int32", "uint32":
+			return protoType{Name: s.Format, Scalar: true}, nil
+		case "uint64":
+			return protoType{Name: "uint64", Scalar: true}, nil
+		}
+		return protoType{Name: "int64", Scalar: true}, nil
+	case TypeNumber:
+		if s.Format == "float" {
+			return protoType{Name: "float", Scalar: true}, nil
+		}
+		return protoType{Name: "double", Scalar: true}, nil
+	case TypeBoolean:
+		return protoType{Name: "bool", Scalar: true}, nil
+	case TypeArray:
+		var item, err = g.typeOf(s.Items, hint+"Item", nested, indent)
+		if err != nil {
+			return protoType{}, err
+		}
+		switch {
+		case item.Repeated:
+			g.imports["google/protobuf/struct.proto"] = true
+			item = protoType{Name: "google.protobuf.ListValue"}
+		case strings.HasPrefix(item.Name, "map<"):
+			g.imports["google/protobuf/struct.proto"] = true
+			item = protoType{Name: "google.protobuf.Struct"}
+		}
+		item.Repeated, item.Scalar = true, false
+		return item, nil
+	case TypeObject:
+		var value, allowed = s.AdditionalPropertiesSchema()
+		if allowed && value != nil {
+			var t, err = g.typeOf(value, hint+"Value", nested, indent)
+			if err != nil {
+				return protoType{}, err
+			}
+			if !t.Repeated && !strings.HasPrefix(t.Name, "map<") && t.Name != "google.protobuf.Value" {
+				return protoType{Name: "map<string, " + t.Name + ">"}, nil
+			}
+		}
+		g.imports["google/protobuf/struct.proto"] = true
+		return protoType{Name: "google.protobuf.Struct"}, nil
+	}
+	g.imports["google/protobuf/struct.proto"] = true
+	return protoType{Name: "google.protobuf.Value"}, nil
+}
+
+// Declares the service of document operations and their request and
+// response messages.
+func (g *protoGenerator) declareService() error {
+	if g.doc.Paths == nil || len(g.doc.Paths.Items) == 0 {
+		return nil
+	}
+	var name = "API"
+	if g.doc.Info != nil && g.doc.Info.Title != "" {
+		name = g.doc.Info.Title
+	}
+	name = g.ident(name + " Service")
+	g.imports["google/api/annotations.proto"] = true
+	if g.doc.Info != nil && g.doc.Info.Description != "" {
+		g.service.WriteString(goComment(g.doc.Info.Description, ""))
+	}
+	fmt.Fprintf(&g.service, "service %s {\n", name)
+	var (
+		names = make(map[string]bool)
+		first = true
+	)
+	for _, path := range g.doc.Paths.Keys() {
+		var item = g.doc.Paths.Items[path]
+		for _, method := range Methods {
+			var op = item.Operation(method)
+			if op == nil {
+				continue
+			}
+			var rpc = op.OperationID
+			if rpc == "" {
+				rpc = operationName(method, path)
+			}
+			rpc = uniqueName(goIdent(rpc), names)
+			names[rpc] = true
+			if !first {
+				g.service.WriteString("\n")
+			}
+			first = false
+			if err := g.declareRPC(rpc, method, path, item, op); err != nil {
+				return fmt.Errorf("%s %s: %w", method, path, err)
+			}
+		}
+	}
+	g.service.WriteString("}\n\n")
+	return nil
+}
+
+// Declares the rpc named name of op.
+func (g *protoGenerator) declareRPC(name, method, path string, item *PathItem, op *Operation) error {
+	var params, err = g.doc.operationParameters(item, op)
+	if err != nil {
+		return err
+	}
+	var (
+		request = g.ident(name + "Request")
+		nested  strings.Builder
+		fields  []*protoField
+		names   = make(map[string]bool)
+		bound   = make(map[string]string)
+	)
+	for _, p := range params {
+		if p.In != "path" && p.In != "query" {
+			continue
+		}
+		var schema = p.Schema
+		if len(p.Content) > 0 {
+			schema = p.Content[sortedContentKeys(p.Content)[0]].Schema
+		}
+		var t, err = g.typeOf(schema, goIdent(p.Name), &nested, "  ")
+		if err != nil {
+			return fmt.Errorf("parameter '%s': %w", p.Name, err)
+		}
+		var f = &protoField{
+			Name:       uniqueName(protoSnake(p.Name), names),
+			JSONName:   p.Name,
+			Type:       t,
+			Optional:   t.Scalar && !t.Repeated && !p.Required && p.In != "path",
+			Doc:        p.Description,
+			Deprecated: p.Deprecated,
+		}
+		names[f.Name] = true
+		if p.In == "path" {
+			bound[p.Name] = f.Name
+		}
+		fields = append(fields, f)
+	}
+	var template = templateExpression.ReplaceAllStringFunc(path, func(expr string) string {
+		var param = expr[1 : len(expr)-1]
+		var field, ok = bound[param]
+		if !ok {
+			field = uniqueName(protoSnake(param), names)
+			names[field], bound[param] = true, field
+			fields = append(fields, &protoField{
+				Name:     field,
+				JSONName: param,
+				Type:     protoType{Name: "string", Scalar: true},
+			})
+		}
+		return "{" + field + "}"
+	})
+	var body string
+	if op.RequestBody != nil {
+		var rb *RequestBody
+		if err = g.doc.resolve(op.RequestBody, &rb); err != nil {
+			return err
+		}
+		if key, mt := preferredContent(rb.Content); mt != nil {
+			var t = protoType{Name: "bytes", Scalar: true}
+			if isJSONMediaType(key) {
+				if t, err = g.typeOf(mt.Schema, "Body", &nested, "  "); err != nil {
+					return fmt.Errorf("request body: %w", err)
+				}
+			}
+			body = uniqueName("body", names)
+			fields = append(fields, &protoField{Name: body, Type: t, Doc: rb.Description})
+		}
+	}
+	for i, f := range fields {
+		f.Number = i + 1
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "// %s is the request of %s.\nmessage %s {\n%s", request, name, request, nested.String())
+	for _, f := range fields {
+		writeProtoField(&sb, f, "  ")
+	}
+	sb.WriteString("}\n\n")
+
+	response, responseBody, err := g.responseMessage(name, op, &sb)
+	if err != nil {
+		return err
+	}
+	g.printf("%s", sb.String())
+
+	var text = strings.TrimSpace(op.Summary + "\n\n" + op.Description)
+	if text != "" {
+		g.service.WriteString(goComment(text, "  "))
+	}
+	fmt.Fprintf(&g.service, "  rpc %s(%s) returns (%s) {\n", name, request, response)
+	fmt.Fprintf(&g.service, "    option (google.api.http) = {\n")
+	switch method {
+	case "GET", "PUT", "POST", "DELETE", "PATCH":
+		fmt.Fprintf(&g.service, "      %s: %q\n", strings.ToLower(method), template)
+	default:
+		fmt.Fprintf(&g.service, "      custom: {\n        kind: %q\n        path: %q\n      }\n", method, template)
+	}
+	if body != "" {
+		fmt.Fprintf(&g.service, "      body: %q\n", body)
+	}
+	if responseBody != "" {
+		fmt.Fprintf(&g.service, "      response_body: %q\n", responseBody)
+	}
+	fmt.Fprintf(&g.service, "    };\n")
+	if op.Deprecated {
+		fmt.Fprintf(&g.service, "    option deprecated = true;\n")
+	}
+	fmt.Fprintf(&g.service, "  }\n")
+	return nil
+}
+
+// Returns the response message of rpc name for op and the name of its field
+// mapped to the response body, if any. A message declared for the response
+// is written to sb.
+func (g *protoGenerator) responseMessage(name string, op *Operation, sb *strings.Builder) (string, string, error) {
+	var v interface{}
+	if op.Responses != nil {
+		for _, key := range op.Responses.Keys() {
+			if strings.HasPrefix(key, "2") {
+				v = op.Responses.Codes[key]
+				break
+			}
+		}
+		if v == nil {
+			v = op.Responses.Default
+		}
+	}
+	var content map[string]*MediaType
+	if v != nil {
+		var resp *Response
+		if err := g.doc.resolve(v, &resp); err != nil {
+			return "", "", err
+		}
+		content = resp.Content
+	}
+	var key, mt = preferredContent(content)
+	if mt == nil {
+		g.imports["google/protobuf/empty.proto"] = true
+		return "google.protobuf.Empty", "", nil
+	}
+	var (
+		t      = protoType{Name: "bytes", Scalar: true}
+		nested strings.Builder
+		err    error
+	)
+	if isJSONMediaType(key) {
+		if t, err = g.typeOf(mt.Schema, "Value", &nested, "  "); err != nil {
+			return "", "", fmt.Errorf("response: %w", err)
+		}
+		if !t.Scalar && !t.Repeated && !strings.HasPrefix(t.Name, "map<") && nested.Len() == 0 &&
+			!strings.HasPrefix(t.Name, "google.protobuf.") {
+			return t.Name, "", nil
+		}
+	}
+	var message = g.ident(name + "Response")
+	fmt.Fprintf(sb, "// %s is the response of %s.\nmessage %s {\n%s", message, name, message, nested.String())
+	writeProtoField(sb, &protoField{Name: "value", Type: t, Number: 1}, "  ")
+	sb.WriteString("}\n\n")
+	return message, "value", nil
+}
+
+// Returns s in lower snake case, i.e. "petId" as "pet_id".
+func protoSnake(s string) string {
+	var words = identWords(s)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	var result = strings.Join(words, "_")
+	if result == "" {
+		return "field"
+	}
+	if unicode.IsDigit([]rune(result)[0]) {
+		return "field_" + result
+	}
+	return result
+}
+
+// Returns the default JSON name protoc derives from a field name.
+func protoJSONName(name string) string {
+	var (
+		sb    strings.Builder
+		upper bool
+	)
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGenerateProto(t *testing.T) {
+	var doc, err = FromJSON([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Pets", "version": "1.0"},
+		"paths": {
+			"/pets/{petId}": {
+				"get": {
+					"operationId": "getPet",
+					"parameters": [
+						{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer", "format": "int64"}},
+						{"name": "X-Trace", "in": "header", "schema": {"type": "string"}}
+					],
+					"responses": {"200": {"description": "A pet", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}
+				},
+				"delete": {
+					"operationId": "deletePet",
+					"responses": {"204": {"description": "Deleted"}}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"Pet": {
+					"oneOf": [{"$ref": "#/components/schemas/Cat"}, {"$ref": "#/components/schemas/Dog"}]
+				},
+				"Cat": {
+					"type": "object",
+					"required": ["name"],
+					"properties": {
+						"name": {"type": "string"},
+						"color": {"$ref": "#/components/schemas/Color"},
+						"birthDate": {"type": "string", "format": "date-time"}
+					},
+					"x-proto-fields": {"name": 1, "color": 2, "lives": 3}
+				},
+				"Dog": {
+					"type": "object",
+					"properties": {
+						"labels": {"type": "object", "additionalProperties": {"type": "integer", "format": "int32"}},
+						"tags": {"type": "array", "items": {"type": "string"}}
+					}
+				},
+				"Color": {"type": "string", "enum": ["black", "white"]}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := GenerateProto(doc, "pets.v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"import \"google/api/annotations.proto\";\nimport \"google/protobuf/empty.proto\";\nimport \"google/protobuf/timestamp.proto\";\n",
+		"  rpc GetPet(GetPetRequest) returns (Pet) {\n    option (google.api.http) = {\n      get: \"/pets/{pet_id}\"\n    };\n  }\n",
+		"  rpc DeletePet(DeletePetRequest) returns (google.protobuf.Empty) {\n",
+		"message Cat {\n  reserved 3, \"lives\";\n  string name = 1;\n  optional Color color = 2;\n  google.protobuf.Timestamp birth_date = 4;\n}\n",
+		"enum Color {\n  COLOR_UNSPECIFIED = 0;\n  COLOR_BLACK = 1;\n  COLOR_WHITE = 2;\n}\n",
+		"message Dog {\n  map<string, int32> labels = 1;\n  repeated string tags = 2;\n}\n",
+		"message Pet {\n  oneof pet {\n    Cat cat = 1;\n    Dog dog = 2;\n  }\n}\n",
+		"message DeletePetRequest {\n  string pet_id = 1;\n}\n",
+		"      delete: \"/pets/{pet_id}\"\n",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Fatalf("missing %q in:\n%s", want, src)
+		}
+	}
+}
+
+func TestAssignProtoFieldNumbers(t *testing.T) {
+	var doc, err = FromJSON([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Pets", "version": "1.0"},
+		"paths": {},
+		"components": {"schemas": {"Pet": {"type": "object", "properties": {"name": {"type": "string"}, "tag": {"type": "string"}}}}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := AssignProtoFieldNumbers(doc); err != nil || n != 2 {
+		t.Fatalf("assigned %d, %v", n, err)
+	}
+	// Fields added later are numbered after recorded ones regardless of name.
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc, err = FromJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	var pet = doc.Components.Schemas["Pet"].(map[string]interface{})
+	pet["properties"].(map[string]interface{})["age"] = map[string]interface{}{"type": "integer"}
+	if n, err := AssignProtoFieldNumbers(doc); err != nil || n != 1 {
+		t.Fatalf("assigned %d, %v", n, err)
+	}
+	src, err := GenerateProto(doc, "pets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "message Pet {\n  optional string name = 1;\n  optional string tag = 2;\n  optional int64 age = 3;\n}\n"; !strings.Contains(string(src), want) {
+		t.Fatalf("missing %q in:\n%s", want, src)
+	}
+}
@@ -0,0 +1,485 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// protoStatusSchema is the component name of the google.rpc.Status schema of
+// error responses of imported operations.
+const protoStatusSchema = "google.rpc.Status"
+
+// ImportProto builds a document titled title of version from Protocol
+// Buffers sources keyed by file name.
+//
+// Messages and enums are component schemas named by their name relative to
+// their package, or by their full name if the relative name is not unique.
+// Messages are objects whose properties are named by the JSON names of
+// fields and enums are strings of value names, following the proto3 JSON
+// mapping. Well known types are mapped to their JSON representation.
+//
+// Each rpc with a google.api.http option is an operation for each of its
+// bindings, identified by "<Service>_<Method>" and tagged with the service
+// name. Path template variables are path parameters, the request body is
+// the request message, the field named by body or, for "*" with path
+// parameters, the remaining fields. Fields of scalar types that are not
+// bound to the path or the body are query parameters. The response is the
+// response message or the field named by response_body, and a default
+// google.rpc.Status response. Streaming rpcs and rpcs without the option
+// are skipped.
+//
+// Imports are not followed; types that are not defined in sources are
+// referenced by their full name.
+func ImportProto(title, version string, sources map[string][]byte) (*OpenAPI, error) {
+	var names = make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var files []*pbFile
+	for _, name := range names {
+		var f, err = parseProto(name, sources[name])
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	var im = &protoImporter{
+		b:        New(title, version),
+		messages: make(map[string]*pbMessage),
+		enums:    make(map[string]*pbEnum),
+		names:    make(map[string]string),
+	}
+	im.index(files)
+	return im.build(files)
+}
+
+// ImportProtoFiles builds a document from .proto files. See ImportProto.
+func ImportProtoFiles(title, version string, filenames ...string) (*OpenAPI, error) {
+	var sources = make(map[string][]byte, len(filenames))
+	for _, name := range filenames {
+		var data, err = ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		sources[filepath.ToSlash(name)] = data
+	}
+	return ImportProto(title, version, sources)
+}
+
+// protoImporter builds a document from parsed .proto files.
+type protoImporter struct {
+	b *Builder
+	// messages and enums are declarations by full name.
+	messages map[string]*pbMessage
+	enums    map[string]*pbEnum
+	// names are component names by full name.
+	names map[string]string
+	// status is true if the status schema is registered.
+	status bool
+}
+
+// Indexes declarations of files and assigns component names.
+func (im *protoImporter) index(files []*pbFile) {
+	var (
+		relative = make(map[string]string)
+		count    = make(map[string]int)
+	)
+	var addMessages func(pkg string, messages []*pbMessage, enums []*pbEnum)
+	addMessages = func(pkg string, messages []*pbMessage, enums []*pbEnum) {
+		for _, m := range messages {
+			im.messages[m.FullName] = m
+			relative[m.FullName] = strings.TrimPrefix(m.FullName, pkg+".")
+			addMessages(pkg, m.Messages, m.Enums)
+		}
+		for _, e := range enums {
+			im.enums[e.FullName] = e
+			relative[e.FullName] = strings.TrimPrefix(e.FullName, pkg+".")
+		}
+	}
+	for _, f := range files {
+		addMessages(f.Package, f.Messages, f.Enums)
+	}
+	for _, rel := range relative {
+		count[rel]++
+	}
+	for full, rel := range relative {
+		if count[rel] > 1 {
+			rel = full
+		}
+		im.names[full] = rel
+	}
+}
+
+// Resolves type name referenced in scope, a package or message full name,
+// to a full name. Returns name without a leading '.' if it is not declared.
+func (im *protoImporter) resolveType(scope, name string) string {
+	if strings.HasPrefix(name, ".") {
+		return name[1:]
+	}
+	for {
+		var full = pbQualify(scope, name)
+		if _, ok := im.messages[full]; ok {
+			return full
+		}
+		if _, ok := im.enums[full]; ok {
+			return full
+		}
+		if scope == "" {
+			return name
+		}
+		if i := strings.LastIndex(scope, "."); i >= 0 {
+			scope = scope[:i]
+		} else {
+			scope = ""
+		}
+	}
+}
+
+// Registers schemas of files and builds operations of their services.
+func (im *protoImporter) build(files []*pbFile) (*OpenAPI, error) {
+	var full = make([]string, 0, len(im.names))
+	for name := range im.names {
+		full = append(full, name)
+	}
+	sort.Strings(full)
+	for _, name := range full {
+		var s *Schema
+		if m, ok := im.messages[name]; ok {
+			s = im.messageSchema(m, m.Fields)
+		} else {
+			s = im.enumSchema(im.enums[name])
+		}
+		im.b.Schema(im.names[name], s)
+	}
+	for _, f := range files {
+		for _, svc := range f.Services {
+			if svc.Doc != "" {
+				im.b.Tag(svc.Name, svc.Doc)
+			}
+			for _, m := range svc.Methods {
+				if m.HTTP == nil || m.ClientStreaming || m.ServerStreaming {
+					continue
+				}
+				var bindings = append([]*pbAggregate{m.HTTP}, m.HTTP.Aggregates("additional_bindings")...)
+				for _, binding := range bindings {
+					if err := im.operation(f.Package, svc, m, binding); err != nil {
+						return nil, fmt.Errorf("%s: rpc %s.%s: %w", f.Name, svc.Name, m.Name, err)
+					}
+				}
+			}
+		}
+	}
+	return im.b.Build()
+}
+
+// Returns the schema of message m with fields.
+func (im *protoImporter) messageSchema(m *pbMessage, fields []*pbField) *Schema {
+	var s = &Schema{
+		Type:        TypeObject,
+		Description: m.Doc,
+		Properties:  make(map[string]*Schema),
+	}
+	for _, f := range fields {
+		s.Properties[f.JSONName] = im.fieldSchema(m.FullName, f)
+		if f.Label == "required" {
+			s.Required = append(s.Required, f.JSONName)
+		}
+	}
+	return s
+}
+
+// Returns the schema of enum e.
+func (im *protoImporter) enumSchema(e *pbEnum) *Schema {
+	var s = &Schema{Type: TypeString, Description: e.Doc}
+	for _, v := range e.Values {
+		s.Enum = append(s.Enum, v)
+	}
+	return s
+}
+
+// Returns the schema of field f of a message in scope.
+func (im *protoImporter) fieldSchema(scope string, f *pbField) *Schema {
+	var s = im.typeSchema(scope, f.Type)
+	switch {
+	case f.MapKey != "":
+		s = &Schema{Type: TypeObject, AdditionalProperties: s}
+	case f.Label == "repeated":
+		s = &Schema{Type: TypeArray, Items: s}
+	}
+	if f.Doc != "" || f.Deprecated {
+		if s.Ref != "" {
+			s = &Schema{Ref: s.Ref}
+		}
+		s.Description, s.Deprecated = f.Doc, f.Deprecated
+	}
+	return s
+}
+
+// Returns the schema of a type referenced in scope.
+func (im *protoImporter) typeSchema(scope, name string) *Schema {
+	switch name {
+	case "double":
+		return &Schema{Type: TypeNumber, Format: "double"}
+	case "float":
+		return &Schema{Type: TypeNumber, Format: "float"}
+	case "int32", "sint32", "sfixed32":
+		return &Schema{Type: TypeInteger, Format: "int32"}
+	case "uint32", "fixed32":
+		var zero = 0.0
+		return &Schema{Type: TypeInteger, Format: "int64", Minimum: &zero}
+	case "int64", "sint64", "sfixed64":
+		return &Schema{Type: TypeString, Format: "int64"}
+	case "uint64", "fixed64":
+		return &Schema{Type: TypeString, Format: "uint64"}
+	case "bool":
+		return &Schema{Type: TypeBoolean}
+	case "string":
+		return &Schema{Type: TypeString}
+	case "bytes":
+		return &Schema{Type: TypeString, Format: "byte"}
+	}
+	var full = im.resolveType(scope, name)
+	if s := protoWellKnownSchema(full); s != nil {
+		return s
+	}
+	var component, ok = im.names[full]
+	if !ok {
+		component = full
+	}
+	return &Schema{Ref: componentsPrefix + "schemas/" + escapePointer(component)}
+}
+
+// Returns the schema of the JSON representation of a well known type or
+// nil if name is not a well known type.
+func protoWellKnownSchema(name string) *Schema {
+	switch name {
+	case "google.protobuf.Timestamp":
+		return &Schema{Type: TypeString, Format: "date-time"}
+	case "google.protobuf.Duration":
+		return &Schema{Type: TypeString, Pattern: `^-?[0-9]+(\.[0-9]+)?s$`}
+	case "google.protobuf.FieldMask":
+		return &Schema{Type: TypeString}
+	case "google.protobuf.Struct", "google.protobuf.Empty":
+		return &Schema{Type: TypeObject}
+	case "google.protobuf.Any":
+		return &Schema{
+			Type:                 TypeObject,
+			Properties:           map[string]*Schema{"@type": {Type: TypeString}},
+			AdditionalProperties: true,
+		}
+	case "google.protobuf.ListValue":
+		return &Schema{Type: TypeArray, Items: &Schema{}}
+	case "google.protobuf.Value":
+		return &Schema{}
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue":
+		return &Schema{Type: []string{TypeNumber, TypeNull}}
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value":
+		return &Schema{Type: []string{TypeInteger, TypeNull}}
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return &Schema{Type: []string{TypeString, TypeNull}, Format: "int64"}
+	case "google.protobuf.BoolValue":
+		return &Schema{Type: []string{TypeBoolean, TypeNull}}
+	case "google.protobuf.StringValue":
+		return &Schema{Type: []string{TypeString, TypeNull}}
+	case "google.protobuf.BytesValue":
+		return &Schema{Type: []string{TypeString, TypeNull}, Format: "byte"}
+	}
+	return nil
+}
+
+// Returns true if field f of a message in scope can be a query parameter.
+func (im *protoImporter) isQueryField(scope string, f *pbField) bool {
+	if f.MapKey != "" {
+		return false
+	}
+	var s = im.typeSchema(scope, f.Type)
+	if s.Ref != "" {
+		var _, ok = im.enums[im.resolveType(scope, f.Type)]
+		return ok
+	}
+	switch s.PrimaryType() {
+	case TypeString, TypeInteger, TypeNumber, TypeBoolean:
+		return true
+	}
+	return false
+}
+
+// Defines the operation of an http binding of rpc m of service svc.
+func (im *protoImporter) operation(pkg string, svc *pbService, m *pbMethod, binding *pbAggregate) error {
+	var method, pattern string
+	for _, verb := range []string{"get", "put", "post", "delete", "patch"} {
+		if path := binding.String(verb); path != "" {
+			method, pattern = strings.ToUpper(verb), path
+		}
+	}
+	if custom := binding.Aggregates("custom"); len(custom) > 0 {
+		method, pattern = strings.ToUpper(custom[0].String("kind")), custom[0].String("path")
+	}
+	if method == "" || pattern == "" {
+		return fmt.Errorf("http binding has no method and path")
+	}
+	var (
+		inputName  = im.resolveType(pkg, m.Input)
+		outputName = im.resolveType(pkg, m.Output)
+		input      = im.messages[inputName]
+		path, vars = protoPathTemplate(pattern)
+		body       = binding.String("body")
+		bound      = make(map[string]bool)
+		id         = svc.Name + "_" + m.Name
+	)
+	im.b.Path(path).Method(method, func(op *OperationBuilder) {
+		var taken = make(map[string]bool)
+		for _, existing := range im.b.doc.Paths.Items {
+			for _, o := range operationsOf(existing) {
+				taken[o.OperationID] = true
+			}
+		}
+		op.ID(uniqueName(id, taken)).Tags(svc.Name)
+		op.Summary(docSummary(strings.Split(m.Doc, "\n")))
+		op.Description(docDescription(strings.Split(m.Doc, "\n")))
+		if m.Deprecated {
+			op.Deprecated()
+		}
+		for _, v := range vars {
+			var s, doc = im.fieldPathSchema(input, v)
+			op.RequiredParam(v, "path", s, doc)
+			bound[strings.SplitN(v, ".", 2)[0]] = true
+		}
+		var fields []*pbField
+		if input != nil {
+			fields = input.Fields
+		}
+		switch body {
+		case "":
+		case "*":
+			if len(bound) == 0 {
+				op.JSONBody(im.typeSchema(pkg, m.Input))
+				break
+			}
+			var rest []*pbField
+			for _, f := range fields {
+				if !bound[f.Name] {
+					rest = append(rest, f)
+				}
+			}
+			op.JSONBody(im.messageSchema(&pbMessage{FullName: inputName}, rest))
+		default:
+			for _, f := range fields {
+				if f.Name == body {
+					var s = im.fieldSchema(inputName, f)
+					s.Description = ""
+					op.Body("application/json", s, true)
+					bound[f.Name] = true
+				}
+			}
+		}
+		if body != "*" {
+			for _, f := range fields {
+				if !bound[f.Name] && im.isQueryField(inputName, f) {
+					op.Param(f.JSONName, "query", im.fieldSchema(inputName, f), f.Doc)
+				}
+			}
+		}
+		var response = im.typeSchema(pkg, m.Output)
+		if field := binding.String("response_body"); field != "" {
+			if output := im.messages[outputName]; output != nil {
+				for _, f := range output.Fields {
+					if f.Name == field {
+						response = im.fieldSchema(outputName, f)
+						response.Description = ""
+					}
+				}
+			}
+		}
+		op.Response(200, "A successful response.", response)
+		op.Response(0, "An error response.", im.statusSchema())
+	})
+	return nil
+}
+
+// Returns the schema and doc of a field referenced by a dotted field path
+// in message m.
+func (im *protoImporter) fieldPathSchema(m *pbMessage, path string) (*Schema, string) {
+	var names = strings.Split(path, ".")
+	for i, name := range names {
+		if m == nil {
+			break
+		}
+		var field *pbField
+		for _, f := range m.Fields {
+			if f.Name == name {
+				field = f
+			}
+		}
+		if field == nil {
+			break
+		}
+		if i == len(names)-1 {
+			var s = im.typeSchema(m.FullName, field.Type)
+			return s, field.Doc
+		}
+		m = im.messages[im.resolveType(m.FullName, field.Type)]
+	}
+	return &Schema{Type: TypeString}, ""
+}
+
+// Returns a reference to the google.rpc.Status schema, registering it on
+// first use.
+func (im *protoImporter) statusSchema() *Schema {
+	var ref = &Schema{Ref: componentsPrefix + "schemas/" + protoStatusSchema}
+	if im.status {
+		return ref
+	}
+	im.status = true
+	if _, ok := im.names[protoStatusSchema]; ok {
+		return ref
+	}
+	var any = protoWellKnownSchema("google.protobuf.Any")
+	im.b.Schema(protoStatusSchema, &Schema{
+		Type: TypeObject,
+		Properties: map[string]*Schema{
+			"code":    {Type: TypeInteger, Format: "int32"},
+			"message": {Type: TypeString},
+			"details": {Type: TypeArray, Items: any},
+		},
+	})
+	return ref
+}
+
+// Converts an http rule path pattern to a path template and returns it with
+// the field paths of its variables, i.e. "/v1/{name=shelves/*}:get" to
+// "/v1/{name}:get" and "name".
+func protoPathTemplate(pattern string) (string, []string) {
+	var (
+		sb   strings.Builder
+		vars []string
+	)
+	for {
+		var open = strings.IndexByte(pattern, '{')
+		if open < 0 {
+			sb.WriteString(pattern)
+			break
+		}
+		var end = strings.IndexByte(pattern[open:], '}')
+		if end < 0 {
+			sb.WriteString(pattern)
+			break
+		}
+		var v = pattern[open+1 : open+end]
+		if i := strings.IndexByte(v, '='); i >= 0 {
+			v = v[:i]
+		}
+		vars = append(vars, v)
+		sb.WriteString(pattern[:open] + "{" + v + "}")
+		pattern = pattern[open+end+1:]
+	}
+	return sb.String(), vars
+}
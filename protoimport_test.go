@@ -0,0 +1,169 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const testProto = `
+syntax = "proto3";
+
+package library.v1;
+
+import "google/api/annotations.proto";
+import "google/protobuf/timestamp.proto";
+
+// Manages books.
+service Library {
+	// Returns a book.
+	//
+	// The book must exist.
+	rpc GetBook(GetBookRequest) returns (Book) {
+		option (google.api.http) = {
+			get: "/v1/{name=shelves/*/books/*}"
+			additional_bindings { get: "/v1/books/{name}" }
+		};
+	}
+	// Creates a book.
+	rpc CreateBook(CreateBookRequest) returns (Book) {
+		option (google.api.http) = {post: "/v1/{parent=shelves/*}/books" body: "book"};
+	}
+	rpc UpdateBook(Book) returns (Book) {
+		option (google.api.http) = {patch: "/v1/{name=shelves/*/books/*}" body: "*"};
+	}
+	rpc WatchBooks(GetBookRequest) returns (stream Book) {
+		option (google.api.http) = {get: "/v1/books:watch"};
+	}
+	rpc Internal(Book) returns (Book);
+}
+
+message GetBookRequest {
+	// The book name.
+	string name = 1;
+	int32 revision = 2;
+}
+
+message CreateBookRequest {
+	string parent = 1;
+	Book book = 2;
+	bool validate_only = 3;
+}
+
+message Book {
+	enum State {
+		STATE_UNSPECIFIED = 0;
+		AVAILABLE = 1;
+	}
+	message Author { string display_name = 1; }
+
+	string name = 1;
+	State state = 2;
+	repeated Author authors = 3;
+	map<string, int64> counts = 4 [json_name = "pageCounts"];
+	google.protobuf.Timestamp create_time = 5;
+}
+`
+
+func TestImportProto(t *testing.T) {
+	var doc, err = ImportProto("Library", "1.0", map[string][]byte{"library.proto": []byte(testProto)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want = map[string]string{
+		"/v1/{name}":         "Library_GetBook Library_UpdateBook",
+		"/v1/books/{name}":   "Library_GetBook2",
+		"/v1/{parent}/books": "Library_CreateBook",
+		"/v1/books:watch":    "",
+	}
+	for path, ids := range want {
+		var item = doc.Paths.Items[path]
+		if ids == "" {
+			if item != nil {
+				t.Errorf("%s: unexpected path", path)
+			}
+			continue
+		}
+		if item == nil {
+			t.Fatalf("%s: missing path", path)
+		}
+		var got string
+		for _, op := range operationsOf(item) {
+			if got != "" {
+				got += " "
+			}
+			got += op.OperationID
+		}
+		if got != ids {
+			t.Errorf("%s: got operations %q, want %q", path, got, ids)
+		}
+	}
+
+	var get = doc.Paths.Items["/v1/{name}"].Get
+	if get.Summary != "Returns a book." || get.Description != "The book must exist." {
+		t.Errorf("get: got summary %q and description %q", get.Summary, get.Description)
+	}
+	var params []string
+	for _, p := range get.Parameters {
+		var param = p.(*Parameter)
+		params = append(params, param.In+":"+param.Name)
+	}
+	if got, want := jsonString(t, params), `["path:name","query:revision"]`; got != want {
+		t.Errorf("get parameters: got %s, want %s", got, want)
+	}
+
+	var create = doc.Paths.Items["/v1/{parent}/books"].Post
+	for _, p := range create.Parameters {
+		if name := p.(*Parameter).Name; name != "parent" && name != "validateOnly" {
+			t.Errorf("create: unexpected parameter %s", name)
+		}
+	}
+	var body = create.RequestBody.(*RequestBody).Content["application/json"].Schema
+	if got, want := jsonString(t, body), `{"$ref":"#/components/schemas/Book"}`; got != want {
+		t.Errorf("create body: got %s, want %s", got, want)
+	}
+
+	var update = doc.Paths.Items["/v1/{name}"].Patch
+	body = update.RequestBody.(*RequestBody).Content["application/json"].Schema
+	if _, ok := body.(*Schema).Properties["name"]; ok {
+		t.Error("update body: path field name in body")
+	}
+
+	var schemas = map[string]string{
+		"Book.State":  `{"type":"string","enum":["STATE_UNSPECIFIED","AVAILABLE"]}`,
+		"Book.Author": `{"properties":{"displayName":{"type":"string"}},"type":"object"}`,
+		"Book": `{"properties":{` +
+			`"authors":{"items":{"$ref":"#/components/schemas/Book.Author"},"type":"array"},` +
+			`"createTime":{"type":"string","format":"date-time"},` +
+			`"name":{"type":"string"},` +
+			`"pageCounts":{"additionalProperties":{"type":"string","format":"int64"},"type":"object"},` +
+			`"state":{"$ref":"#/components/schemas/Book.State"}},"type":"object"}`,
+	}
+	for name, want := range schemas {
+		if got := jsonString(t, doc.Components.Schemas[name]); got != want {
+			t.Errorf("schema %s:\ngot  %s\nwant %s", name, got, want)
+		}
+	}
+	if doc.Components.Schemas[protoStatusSchema] == nil {
+		t.Errorf("missing %s schema", protoStatusSchema)
+	}
+}
+
+func TestProtoPathTemplate(t *testing.T) {
+	var path, vars = protoPathTemplate("/v1/{book.name=shelves/*/books/**}:publish")
+	if path != "/v1/{book.name}:publish" || len(vars) != 1 || vars[0] != "book.name" {
+		t.Errorf("got %s %v", path, vars)
+	}
+}
+
+func jsonString(t *testing.T, v interface{}) string {
+	t.Helper()
+	var data, err = json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,757 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pbFile is a parsed .proto file. Only declarations relevant to HTTP
+// mapping are retained.
+type pbFile struct {
+	Name     string
+	Package  string
+	Messages []*pbMessage
+	Enums    []*pbEnum
+	Services []*pbService
+}
+
+// pbMessage is a message declaration.
+type pbMessage struct {
+	// FullName is the package qualified name, i.e. "pkg.Outer.Inner".
+	FullName string
+	Doc      string
+	Fields   []*pbField
+	Messages []*pbMessage
+	Enums    []*pbEnum
+}
+
+// pbField is a field of a message.
+type pbField struct {
+	Name string
+	// Label is "repeated", "optional", "required" or empty.
+	Label string
+	// Type is the scalar or unresolved type name, or the value type of a
+	// map field.
+	Type string
+	// MapKey is the key type of a map field or empty.
+	MapKey     string
+	JSONName   string
+	Oneof      string
+	Number     int
+	Doc        string
+	Deprecated bool
+}
+
+// pbEnum is an enum declaration.
+type pbEnum struct {
+	FullName string
+	Doc      string
+	Values   []string
+}
+
+// pbService is a service declaration.
+type pbService struct {
+	Name    string
+	Doc     string
+	Methods []*pbMethod
+}
+
+// pbMethod is an rpc of a service.
+type pbMethod struct {
+	Name            string
+	Doc             string
+	Input           string
+	Output          string
+	ClientStreaming bool
+	ServerStreaming bool
+	Deprecated      bool
+	// HTTP is the value of the google.api.http option or nil.
+	HTTP *pbAggregate
+}
+
+// pbAggregate is an aggregate option value in text format.
+type pbAggregate struct {
+	Fields []*pbAggregateField
+}
+
+// pbAggregateField is a field of an aggregate value holding a string or an
+// *pbAggregate.
+type pbAggregateField struct {
+	Name  string
+	Value interface{}
+}
+
+// Returns the string value of the first field named name.
+func (a *pbAggregate) String(name string) string {
+	for _, f := range a.Fields {
+		if s, ok := f.Value.(string); ok && f.Name == name {
+			return s
+		}
+	}
+	return ""
+}
+
+// Returns aggregate values of fields named name.
+func (a *pbAggregate) Aggregates(name string) (result []*pbAggregate) {
+	for _, f := range a.Fields {
+		if v, ok := f.Value.(*pbAggregate); ok && f.Name == name {
+			result = append(result, v)
+		}
+	}
+	return
+}
+
+// pbToken kinds.
+const (
+	pbEOF = iota
+	pbIdent
+	pbString
+	pbNumber
+	pbSymbol
+)
+
+// pbToken is a token of a .proto file.
+type pbToken struct {
+	kind int
+	text string
+	line int
+	// doc are comments preceding the token.
+	doc string
+}
+
+// pbParser is a parser of .proto files.
+type pbParser struct {
+	name   string
+	tokens []pbToken
+	pos    int
+}
+
+// Parses the .proto source src of file name.
+func parseProto(name string, src []byte) (*pbFile, error) {
+	var tokens, err = lexProto(name, string(src))
+	if err != nil {
+		return nil, err
+	}
+	var p = &pbParser{name: name, tokens: tokens}
+	return p.file()
+}
+
+// Splits src into tokens. Comments preceding a token on lines of their own
+// are recorded as its doc; comments following a token on its line are
+// ignored.
+func lexProto(name, src string) ([]pbToken, error) {
+	var (
+		tokens    []pbToken
+		line      = 1
+		lineStart int
+		doc       []string
+		lastLine  int
+	)
+	var emit = func(kind int, text string) {
+		tokens = append(tokens, pbToken{kind: kind, text: text, line: line, doc: strings.Join(doc, "\n")})
+		doc, lastLine = nil, line
+	}
+	for i := 0; i < len(src); {
+		var c = src[i]
+		switch {
+		case c == '\n':
+			if strings.TrimSpace(src[lineStart:i]) == "" {
+				doc = nil
+			}
+			line++
+			i++
+			lineStart = i
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			var end = strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			if line != lastLine {
+				doc = append(doc, strings.TrimSpace(src[i+2:i+end]))
+			}
+			i += end
+		case strings.HasPrefix(src[i:], "/*"):
+			var end = strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%s:%d: unterminated comment", name, line)
+			}
+			var text = src[i+2 : i+2+end]
+			if line != lastLine {
+				for _, l := range strings.Split(text, "\n") {
+					l = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "*"))
+					if l != "" {
+						doc = append(doc, l)
+					}
+				}
+			}
+			line += strings.Count(text, "\n")
+			i += 2 + end + 2
+		case c == '"' || c == '\'':
+			var (
+				sb strings.Builder
+				j  = i + 1
+			)
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\n' {
+					return nil, fmt.Errorf("%s:%d: unterminated string", name, line)
+				}
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+					switch src[j] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					case 'r':
+						sb.WriteByte('\r')
+					default:
+						sb.WriteByte(src[j])
+					}
+					continue
+				}
+				sb.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("%s:%d: unterminated string", name, line)
+			}
+			emit(pbString, sb.String())
+			i = j + 1
+		case pbIsLetter(c) || c == '.' && i+1 < len(src) && pbIsLetter(src[i+1]):
+			var j = i + 1
+			for j < len(src) && (pbIsLetter(src[j]) || pbIsDigit(src[j]) || src[j] == '.') {
+				j++
+			}
+			emit(pbIdent, src[i:j])
+			i = j
+		case pbIsDigit(c):
+			var j = i + 1
+			for j < len(src) && (pbIsLetter(src[j]) || pbIsDigit(src[j]) || src[j] == '.' ||
+				(src[j] == '-' || src[j] == '+') && (src[j-1] == 'e' || src[j-1] == 'E')) {
+				j++
+			}
+			emit(pbNumber, src[i:j])
+			i = j
+		case strings.IndexByte("{}[]()<>;=,:-+/", c) >= 0:
+			emit(pbSymbol, string(c))
+			i++
+		default:
+			return nil, fmt.Errorf("%s:%d: unexpected character %q", name, line, c)
+		}
+	}
+	return append(tokens, pbToken{kind: pbEOF, line: line}), nil
+}
+
+// Returns true if c is an ASCII letter or '_'.
+func pbIsLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+// Returns true if c is an ASCII digit.
+func pbIsDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Returns the current token.
+func (p *pbParser) peek() pbToken {
+	return p.tokens[p.pos]
+}
+
+// Returns the token after the current token.
+func (p *pbParser) lookahead() pbToken {
+	if p.pos+1 < len(p.tokens) {
+		return p.tokens[p.pos+1]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+// Returns the current token and advances.
+func (p *pbParser) next() pbToken {
+	var t = p.tokens[p.pos]
+	if t.kind != pbEOF {
+		p.pos++
+	}
+	return t
+}
+
+// Advances if the current token is text.
+func (p *pbParser) accept(text string) bool {
+	if t := p.peek(); t.kind != pbString && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// Returns an error at the current token.
+func (p *pbParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", p.name, p.peek().line, fmt.Sprintf(format, args...))
+}
+
+// Consumes text or returns an error.
+func (p *pbParser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expected '%s', found '%s'", text, p.peek().text)
+	}
+	return nil
+}
+
+// Consumes an identifier.
+func (p *pbParser) ident() (string, error) {
+	if t := p.peek(); t.kind != pbIdent {
+		return "", p.errorf("expected identifier, found '%s'", t.text)
+	}
+	return p.next().text, nil
+}
+
+// Consumes an integer.
+func (p *pbParser) integer() (int, error) {
+	var negative = p.accept("-")
+	var t = p.next()
+	var n, err = strconv.ParseInt(t.text, 0, 64)
+	if t.kind != pbNumber || err != nil {
+		return 0, fmt.Errorf("%s:%d: expected integer, found '%s'", p.name, t.line, t.text)
+	}
+	if negative {
+		n = -n
+	}
+	return int(n), nil
+}
+
+// Skips a statement up to and including ';' or a balanced block.
+func (p *pbParser) skip() error {
+	for depth := 0; ; {
+		var t = p.next()
+		switch {
+		case t.kind == pbEOF:
+			return p.errorf("unexpected end of file")
+		case t.kind != pbSymbol:
+		case t.text == "{":
+			depth++
+		case t.text == "}":
+			if depth--; depth == 0 {
+				p.accept(";")
+				return nil
+			}
+		case t.text == ";" && depth == 0:
+			return nil
+		}
+	}
+}
+
+// Parses the file.
+func (p *pbParser) file() (*pbFile, error) {
+	var f = &pbFile{Name: p.name}
+	for p.peek().kind != pbEOF {
+		var t = p.peek()
+		var err error
+		switch {
+		case p.accept(";"):
+		case p.accept("package"):
+			if f.Package, err = p.ident(); err == nil {
+				err = p.expect(";")
+			}
+		case t.text == "message" && t.kind == pbIdent:
+			p.next()
+			var m *pbMessage
+			if m, err = p.message(f.Package, t.doc); err == nil {
+				f.Messages = append(f.Messages, m)
+			}
+		case t.text == "enum" && t.kind == pbIdent:
+			p.next()
+			var e *pbEnum
+			if e, err = p.enum(f.Package, t.doc); err == nil {
+				f.Enums = append(f.Enums, e)
+			}
+		case t.text == "service" && t.kind == pbIdent:
+			p.next()
+			var s *pbService
+			if s, err = p.service(t.doc); err == nil {
+				f.Services = append(f.Services, s)
+			}
+		case t.text == "option" && t.kind == pbIdent:
+			p.next()
+			_, _, err = p.option()
+		default:
+			err = p.skip()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Returns name qualified by scope.
+func pbQualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// Parses a message after the "message" keyword.
+func (p *pbParser) message(scope, doc string) (*pbMessage, error) {
+	var name, err = p.ident()
+	if err != nil {
+		return nil, err
+	}
+	var m = &pbMessage{FullName: pbQualify(scope, name), Doc: doc}
+	if err = p.expect("{"); err != nil {
+		return nil, err
+	}
+	if err = p.messageBody(m, ""); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Parses message body declarations up to and including '}'. Fields are
+// members of oneof if it is not empty.
+func (p *pbParser) messageBody(m *pbMessage, oneof string) error {
+	for !p.accept("}") {
+		var t = p.peek()
+		if t.kind == pbEOF {
+			return p.errorf("unexpected end of file")
+		}
+		var err error
+		switch {
+		case p.accept(";"):
+		case t.kind != pbIdent:
+			return p.errorf("unexpected '%s'", t.text)
+		case t.text == "message" && p.lookahead().kind == pbIdent:
+			p.next()
+			var nested *pbMessage
+			if nested, err = p.message(m.FullName, t.doc); err == nil {
+				m.Messages = append(m.Messages, nested)
+			}
+		case t.text == "enum" && p.lookahead().kind == pbIdent:
+			p.next()
+			var e *pbEnum
+			if e, err = p.enum(m.FullName, t.doc); err == nil {
+				m.Enums = append(m.Enums, e)
+			}
+		case t.text == "oneof" && p.lookahead().kind == pbIdent:
+			p.next()
+			var name string
+			if name, err = p.ident(); err == nil {
+				if err = p.expect("{"); err == nil {
+					err = p.messageBody(m, name)
+				}
+			}
+		case t.text == "option":
+			p.next()
+			_, _, err = p.option()
+		case t.text == "reserved" || t.text == "extensions" || t.text == "extend":
+			err = p.skip()
+		default:
+			var f *pbField
+			if f, err = p.field(); err == nil {
+				f.Oneof = oneof
+				m.Fields = append(m.Fields, f)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parses a field declaration.
+func (p *pbParser) field() (*pbField, error) {
+	var (
+		f   = &pbField{Doc: p.peek().doc}
+		err error
+	)
+	switch p.peek().text {
+	case "repeated", "optional", "required":
+		f.Label = p.next().text
+	}
+	if p.peek().text == "map" && p.lookahead().text == "<" {
+		p.pos += 2
+		if f.MapKey, err = p.ident(); err != nil {
+			return nil, err
+		}
+		if err = p.expect(","); err != nil {
+			return nil, err
+		}
+		if f.Type, err = p.ident(); err != nil {
+			return nil, err
+		}
+		if err = p.expect(">"); err != nil {
+			return nil, err
+		}
+	} else if f.Type, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if f.Name, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if err = p.expect("="); err != nil {
+		return nil, err
+	}
+	if f.Number, err = p.integer(); err != nil {
+		return nil, err
+	}
+	if p.accept("[") {
+		for {
+			var name, value, err = p.optionAssignment()
+			if err != nil {
+				return nil, err
+			}
+			switch name {
+			case "json_name":
+				f.JSONName, _ = value.(string)
+			case "deprecated":
+				f.Deprecated = value == "true"
+			}
+			if p.accept("]") {
+				break
+			}
+			if err = p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if f.JSONName == "" {
+		f.JSONName = protoJSONName(f.Name)
+	}
+	return f, p.expect(";")
+}
+
+// Parses an enum after the "enum" keyword.
+func (p *pbParser) enum(scope, doc string) (*pbEnum, error) {
+	var name, err = p.ident()
+	if err != nil {
+		return nil, err
+	}
+	var e = &pbEnum{FullName: pbQualify(scope, name), Doc: doc}
+	if err = p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.accept("}") {
+		var t = p.peek()
+		switch {
+		case t.kind == pbEOF:
+			return nil, p.errorf("unexpected end of file")
+		case p.accept(";"):
+		case t.text == "option" && t.kind == pbIdent:
+			p.next()
+			if _, _, err = p.option(); err != nil {
+				return nil, err
+			}
+		case t.text == "reserved" && t.kind == pbIdent:
+			if err = p.skip(); err != nil {
+				return nil, err
+			}
+		default:
+			var value string
+			if value, err = p.ident(); err != nil {
+				return nil, err
+			}
+			e.Values = append(e.Values, value)
+			if err = p.skip(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return e, nil
+}
+
+// Parses a service after the "service" keyword.
+func (p *pbParser) service(doc string) (*pbService, error) {
+	var name, err = p.ident()
+	if err != nil {
+		return nil, err
+	}
+	var s = &pbService{Name: name, Doc: doc}
+	if err = p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.accept("}") {
+		var t = p.peek()
+		switch {
+		case t.kind == pbEOF:
+			return nil, p.errorf("unexpected end of file")
+		case p.accept(";"):
+		case t.text == "rpc" && t.kind == pbIdent:
+			p.next()
+			var m *pbMethod
+			if m, err = p.method(t.doc); err != nil {
+				return nil, err
+			}
+			s.Methods = append(s.Methods, m)
+		case t.text == "option" && t.kind == pbIdent:
+			p.next()
+			if _, _, err = p.option(); err != nil {
+				return nil, err
+			}
+		default:
+			if err = p.skip(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// Parses an rpc after the "rpc" keyword.
+func (p *pbParser) method(doc string) (*pbMethod, error) {
+	var (
+		m   = &pbMethod{Doc: doc}
+		err error
+	)
+	if m.Name, err = p.ident(); err != nil {
+		return nil, err
+	}
+	var messageType = func(stream *bool) (string, error) {
+		if err := p.expect("("); err != nil {
+			return "", err
+		}
+		if p.peek().text == "stream" && p.lookahead().kind == pbIdent {
+			p.next()
+			*stream = true
+		}
+		var name, err = p.ident()
+		if err != nil {
+			return "", err
+		}
+		return name, p.expect(")")
+	}
+	if m.Input, err = messageType(&m.ClientStreaming); err != nil {
+		return nil, err
+	}
+	if err = p.expect("returns"); err != nil {
+		return nil, err
+	}
+	if m.Output, err = messageType(&m.ServerStreaming); err != nil {
+		return nil, err
+	}
+	if p.accept(";") {
+		return m, nil
+	}
+	if err = p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.accept("}") {
+		switch t := p.peek(); {
+		case t.kind == pbEOF:
+			return nil, p.errorf("unexpected end of file")
+		case p.accept(";"):
+		case t.text == "option" && t.kind == pbIdent:
+			p.next()
+			var name, value, err = p.option()
+			if err != nil {
+				return nil, err
+			}
+			switch name {
+			case "(google.api.http)":
+				m.HTTP, _ = value.(*pbAggregate)
+			case "deprecated":
+				m.Deprecated = value == "true"
+			}
+		default:
+			if err = p.skip(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	p.accept(";")
+	return m, nil
+}
+
+// Parses an option statement after the "option" keyword.
+func (p *pbParser) option() (string, interface{}, error) {
+	var name, value, err = p.optionAssignment()
+	if err != nil {
+		return "", nil, err
+	}
+	return name, value, p.expect(";")
+}
+
+// Parses "name = value" of an option.
+func (p *pbParser) optionAssignment() (string, interface{}, error) {
+	var name strings.Builder
+	for !p.accept("=") {
+		var t = p.next()
+		if t.kind == pbEOF || t.text == ";" {
+			return "", nil, fmt.Errorf("%s:%d: invalid option", p.name, t.line)
+		}
+		name.WriteString(t.text)
+	}
+	var value, err = p.value()
+	return name.String(), value, err
+}
+
+// Parses a constant or an aggregate value. Scalars are returned as strings
+// and aggregates as *pbAggregate.
+func (p *pbParser) value() (interface{}, error) {
+	if p.accept("{") {
+		return p.aggregate("}")
+	}
+	var t = p.next()
+	switch {
+	case t.kind == pbString:
+		var s = t.text
+		for p.peek().kind == pbString {
+			s += p.next().text
+		}
+		return s, nil
+	case t.text == "-" || t.text == "+":
+		return t.text + p.next().text, nil
+	case t.kind == pbIdent || t.kind == pbNumber:
+		return t.text, nil
+	}
+	return nil, fmt.Errorf("%s:%d: invalid value '%s'", p.name, t.line, t.text)
+}
+
+// Parses fields of an aggregate value in text format up to and including
+// end.
+func (p *pbParser) aggregate(end string) (*pbAggregate, error) {
+	var a = &pbAggregate{}
+	for !p.accept(end) {
+		if p.accept(",") || p.accept(";") {
+			continue
+		}
+		var t = p.next()
+		if t.kind != pbIdent {
+			return nil, fmt.Errorf("%s:%d: unexpected '%s' in aggregate value", p.name, t.line, t.text)
+		}
+		var (
+			f   = &pbAggregateField{Name: t.text}
+			err error
+		)
+		p.accept(":")
+		switch {
+		case p.accept("{"):
+			f.Value, err = p.aggregate("}")
+		case p.accept("<"):
+			f.Value, err = p.aggregate(">")
+		case p.accept("["):
+			for err == nil && !p.accept("]") {
+				if p.accept(",") {
+					continue
+				}
+				var v interface{}
+				if v, err = p.value(); err == nil {
+					a.Fields = append(a.Fields, &pbAggregateField{Name: t.text, Value: v})
+				}
+			}
+			continue
+		default:
+			f.Value, err = p.value()
+		}
+		if err != nil {
+			return nil, err
+		}
+		a.Fields = append(a.Fields, f)
+	}
+	return a, nil
+}
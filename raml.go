@@ -0,0 +1,804 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ImportRAML imports a RAML 1.0 API definition in YAML as an OpenAPI 3.1
+// document.
+//
+// baseUri is a server whose baseUriParameters are server variables.
+// Resources and their methods are path items and operations, uriParameters
+// are path parameters and queryParameters and headers are query and header
+// parameters. types are component schemas: properties are required unless
+// their name ends with "?" or they declare required false, type expressions
+// such as "Pet[]" or "Cat | Dog" are arrays and anyOf schemas, inheritance
+// is an allOf and a discriminator maps discriminatorValues, or type names, of
+// subtypes. Bodies of a method or response are described for each media type,
+// or for the default mediaType of the API.
+//
+// Query parameters and headers of traits are component parameters named
+// "<trait>.<parameter>" and are referenced by the methods the traits are
+// applied to. Responses of traits are added to those methods.
+//
+// securitySchemes of OAuth 2.0, Basic Authentication, Digest Authentication
+// and Pass Through types are security schemes and securedBy are security
+// requirements.
+//
+// Unsupported constructs, such as resourceTypes, libraries, annotations and
+// trait bodies, are dropped and the document is returned with a note for
+// each of them.
+func ImportRAML(data []byte) (*OpenAPI, []*ConversionNote, error) {
+	var header = string(bytes.TrimSpace(bytes.SplitN(data, []byte("\n"), 2)[0]))
+	if !strings.HasPrefix(header, "#%RAML") {
+		return nil, nil, errors.New("raml: missing #%RAML header")
+	}
+	if header != "#%RAML 1.0" {
+		return nil, nil, fmt.Errorf("raml: unsupported document '%s'", strings.TrimPrefix(header, "#%"))
+	}
+	var src, err = decodeRaw(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var c = &ramlConverter{
+		src:        src,
+		mediaTypes: rawStrings(src["mediaType"]),
+		schemes:    make(map[string]bool),
+		subtypes:   make(map[string]map[string]string),
+	}
+	if s, ok := src["mediaType"].(string); ok {
+		c.mediaTypes = []string{s}
+	}
+	if len(c.mediaTypes) == 0 {
+		c.mediaTypes = []string{"application/json"}
+	}
+	if data, err = json.Marshal(c.document()); err != nil {
+		return nil, nil, err
+	}
+	var doc *OpenAPI
+	if doc, err = FromJSON(data); err != nil {
+		return nil, nil, err
+	}
+	return doc, c.notes, nil
+}
+
+// ramlConverter converts a raw RAML 1.0 document.
+type ramlConverter struct {
+	converter
+	src map[string]interface{}
+	// mediaTypes are default media types of bodies.
+	mediaTypes []string
+	// schemes are names of converted security schemes.
+	schemes map[string]bool
+	// subtypes maps names of types to discriminator values of their
+	// subtypes to subtype names.
+	subtypes map[string]map[string]string
+	paths    map[string]interface{}
+}
+
+// ramlMethods are RAML method names.
+var ramlMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// Returns the converted document.
+func (c *ramlConverter) document() map[string]interface{} {
+	var (
+		info       = map[string]interface{}{"title": c.src["title"]}
+		out        = map[string]interface{}{"openapi": Version, "info": info}
+		components = make(map[string]interface{})
+	)
+	c.paths = make(map[string]interface{})
+	if version, ok := c.src["version"]; ok {
+		info["version"] = fmt.Sprint(version)
+	}
+	if description, ok := c.src["description"]; ok {
+		info["description"] = description
+	}
+	if server := c.server(); server != nil {
+		out["servers"] = []interface{}{server}
+	}
+	var types = rawObject(c.src["types"])
+	if types == nil {
+		types = rawObject(c.src["schemas"])
+	}
+	if len(types) > 0 {
+		var schemas = make(map[string]interface{})
+		for _, name := range sortedKeys(types) {
+			var pointer = "/types/" + escapePointer(name)
+			if base, ok := rawObject(types[name])["type"].(string); ok {
+				c.subtype(name, base, rawObject(types[name]))
+			}
+			schemas[name] = c.schema(pointer, types[name])
+		}
+		for _, name := range sortedKeys(schemas) {
+			var d = rawObject(rawObject(schemas[name])["discriminator"])
+			if d == nil || len(c.subtypes[name]) == 0 {
+				continue
+			}
+			var mapping = make(map[string]interface{})
+			for value, subtype := range c.subtypes[name] {
+				mapping[value] = componentsPrefix + "schemas/" + escapePointer(subtype)
+			}
+			d["mapping"] = mapping
+		}
+		components["schemas"] = schemas
+	}
+	if params := c.traits(); len(params) > 0 {
+		components["parameters"] = params
+	}
+	if schemes := c.securitySchemes(); len(schemes) > 0 {
+		components["securitySchemes"] = schemes
+	}
+	if security, ok := c.src["securedBy"]; ok {
+		out["security"] = c.security("/securedBy", security)
+	}
+	for _, key := range sortedKeys(c.src) {
+		var pointer = "/" + escapePointer(key)
+		switch {
+		case strings.HasPrefix(key, "/"):
+			c.resource(pointer, key, c.src[key], nil)
+		case strings.HasPrefix(key, "("):
+			c.note(pointer, "annotation dropped")
+		}
+		switch key {
+		case "uses", "resourceTypes", "annotationTypes", "documentation", "protocols":
+			c.note(pointer, "%s not supported; dropped", key)
+		}
+	}
+	out["paths"] = c.paths
+	if len(components) > 0 {
+		out["components"] = components
+	}
+	return out
+}
+
+// Returns the server of baseUri or nil if the document has none.
+func (c *ramlConverter) server() map[string]interface{} {
+	var base, _ = c.src["baseUri"].(string)
+	if base == "" {
+		return nil
+	}
+	if version, ok := c.src["version"]; ok {
+		base = strings.ReplaceAll(base, "{version}", fmt.Sprint(version))
+	}
+	var (
+		server    = map[string]interface{}{"url": base}
+		params    = rawObject(c.src["baseUriParameters"])
+		variables = make(map[string]interface{})
+	)
+	for _, m := range templateExpression.FindAllStringSubmatch(base, -1) {
+		var (
+			p        = rawObject(params[m[1]])
+			variable = map[string]interface{}{"default": ""}
+		)
+		switch {
+		case p["default"] != nil:
+			variable["default"] = fmt.Sprint(p["default"])
+		case p["example"] != nil:
+			variable["default"] = fmt.Sprint(p["example"])
+		case len(rawList(p["enum"])) > 0:
+			variable["default"] = fmt.Sprint(rawList(p["enum"])[0])
+		}
+		if enum := rawStrings(p["enum"]); len(enum) > 0 {
+			variable["enum"] = enum
+		}
+		if d, ok := p["description"]; ok {
+			variable["description"] = d
+		}
+		variables[m[1]] = variable
+	}
+	if len(variables) > 0 {
+		server["variables"] = variables
+	}
+	return server
+}
+
+// Records type name with declaration decl of base type base as a subtype
+// for discriminator mapping.
+func (c *ramlConverter) subtype(name, base string, decl map[string]interface{}) {
+	if _, builtin := ramlTypes[base]; builtin || strings.ContainsAny(base, "|[({") {
+		return
+	}
+	var value = name
+	if v, ok := decl["discriminatorValue"]; ok {
+		value = fmt.Sprint(v)
+	}
+	if c.subtypes[base] == nil {
+		c.subtypes[base] = make(map[string]string)
+	}
+	c.subtypes[base][value] = name
+}
+
+// ramlTypes are schemas of RAML built-in types.
+var ramlTypes = map[string]map[string]interface{}{
+	"any":           {},
+	"string":        {"type": TypeString},
+	"number":        {"type": TypeNumber},
+	"integer":       {"type": TypeInteger},
+	"boolean":       {"type": TypeBoolean},
+	"object":        {"type": TypeObject},
+	"array":         {"type": TypeArray},
+	"nil":           {"type": TypeNull},
+	"date-only":     {"type": TypeString, "format": "date"},
+	"time-only":     {"type": TypeString, "format": "time"},
+	"datetime":      {"type": TypeString, "format": "date-time"},
+	"datetime-only": {"type": TypeString},
+	"file":          {"type": TypeString, "contentMediaType": "application/octet-stream"},
+}
+
+// ramlFacets are facets of type declarations copied to schemas as is.
+var ramlFacets = map[string]bool{
+	"enum": true, "pattern": true, "minLength": true, "maxLength": true,
+	"minimum": true, "maximum": true, "multipleOf": true, "format": true,
+	"minItems": true, "maxItems": true, "uniqueItems": true,
+	"minProperties": true, "maxProperties": true, "default": true,
+	"description": true, "additionalProperties": true,
+}
+
+// Returns the schema of type expression expr at pointer.
+func (c *ramlConverter) typeExpression(pointer, expr string) map[string]interface{} {
+	expr = strings.TrimSpace(expr)
+	switch {
+	case strings.HasPrefix(expr, "{"):
+		var s map[string]interface{}
+		if err := json.Unmarshal([]byte(expr), &s); err != nil {
+			c.note(pointer, "invalid JSON schema dropped: %v", err)
+			return map[string]interface{}{}
+		}
+		delete(s, "$schema")
+		return s
+	case strings.HasPrefix(expr, "<"):
+		c.note(pointer, "XML schema not supported; dropped")
+		return map[string]interface{}{}
+	}
+	if variants := splitRAMLUnion(expr); len(variants) > 1 {
+		var list []interface{}
+		for _, variant := range variants {
+			list = append(list, c.typeExpression(pointer, variant))
+		}
+		return map[string]interface{}{"anyOf": list}
+	}
+	if strings.HasSuffix(expr, "[]") {
+		return map[string]interface{}{
+			"type":  TypeArray,
+			"items": c.typeExpression(pointer, strings.TrimSuffix(expr, "[]")),
+		}
+	}
+	if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+		return c.typeExpression(pointer, expr[1:len(expr)-1])
+	}
+	if builtin, ok := ramlTypes[expr]; ok {
+		var result = make(map[string]interface{}, len(builtin))
+		for key, value := range builtin {
+			result[key] = value
+		}
+		return result
+	}
+	return map[string]interface{}{"$ref": componentsPrefix + "schemas/" + escapePointer(expr)}
+}
+
+// Returns variants of union type expression expr, split at "|" outside
+// parentheses.
+func splitRAMLUnion(expr string) (result []string) {
+	var depth, last int
+	for i, r := range expr {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '|':
+			if depth == 0 {
+				result = append(result, strings.TrimSpace(expr[last:i]))
+				last = i + 1
+			}
+		}
+	}
+	return append(result, strings.TrimSpace(expr[last:]))
+}
+
+// Returns the schema of RAML type declaration v at pointer.
+func (c *ramlConverter) schema(pointer string, v interface{}) interface{} {
+	switch t := v.(type) {
+	case nil:
+		return map[string]interface{}{}
+	case string:
+		return c.typeExpression(pointer, t)
+	}
+	var decl = rawObject(v)
+	if decl == nil {
+		c.note(pointer, "invalid type declaration dropped")
+		return map[string]interface{}{}
+	}
+	var base = decl["type"]
+	if base == nil {
+		base = decl["schema"]
+	}
+	if base == nil {
+		base = "string"
+		if _, ok := decl["properties"]; ok {
+			base = "object"
+		} else if _, ok := decl["items"]; ok {
+			base = "array"
+		}
+	}
+	var (
+		out   = make(map[string]interface{})
+		list  = rawList(base)
+		bases []interface{}
+	)
+	if list == nil {
+		list = []interface{}{base}
+	}
+	for i, b := range list {
+		if len(list) > 1 {
+			bases = append(bases, c.schema(fmt.Sprintf("%s/type/%d", pointer, i), b))
+		} else {
+			bases = append(bases, c.schema(pointer+"/type", b))
+		}
+	}
+	if len(bases) == 1 && rawObject(bases[0])["$ref"] == nil && rawObject(bases[0])["anyOf"] == nil {
+		out = rawObject(bases[0])
+	} else {
+		out["allOf"] = bases
+	}
+	for _, key := range sortedKeys(decl) {
+		var value, kp = decl[key], pointer + "/" + escapePointer(key)
+		switch {
+		case ramlFacets[key]:
+			if key == "additionalProperties" {
+				if _, ok := value.(bool); !ok {
+					value = c.schema(kp, value)
+				}
+			}
+			out[key] = value
+		case key == "displayName":
+			out["title"] = value
+		case key == "properties":
+			var (
+				props    = make(map[string]interface{})
+				required []string
+			)
+			for _, name := range sortedKeys(rawObject(value)) {
+				var (
+					prop     = rawObject(value)[name]
+					optional = strings.HasSuffix(name, "?")
+				)
+				if r, ok := rawObject(prop)["required"].(bool); ok {
+					optional = !r
+				} else {
+					name = strings.TrimSuffix(name, "?")
+				}
+				props[name] = c.schema(kp+"/"+escapePointer(name), prop)
+				if !optional {
+					required = append(required, name)
+				}
+			}
+			out["properties"] = props
+			if len(required) > 0 {
+				out["required"] = required
+			}
+		case key == "items":
+			out["items"] = c.schema(kp, value)
+		case key == "example":
+			out["examples"] = []interface{}{value}
+		case key == "examples":
+			var examples []interface{}
+			for _, name := range sortedKeys(rawObject(value)) {
+				var example = rawObject(value)[name]
+				if m := rawObject(example); m != nil && m["value"] != nil {
+					example = m["value"]
+				}
+				examples = append(examples, example)
+			}
+			out["examples"] = examples
+		case key == "discriminator":
+			out["discriminator"] = map[string]interface{}{"propertyName": value}
+		case key == "type", key == "schema", key == "required", key == "discriminatorValue":
+		case strings.HasPrefix(key, "("):
+			c.note(kp, "annotation dropped")
+		default:
+			c.note(kp, "facet %s not supported; dropped", key)
+		}
+	}
+	return out
+}
+
+// Returns component parameters of query parameters and headers of traits.
+func (c *ramlConverter) traits() map[string]interface{} {
+	var (
+		traits = rawObject(c.src["traits"])
+		result = make(map[string]interface{})
+	)
+	for _, name := range sortedKeys(traits) {
+		var (
+			pointer = "/traits/" + escapePointer(name)
+			trait   = rawObject(traits[name])
+		)
+		for _, key := range sortedKeys(trait) {
+			var kp = pointer + "/" + escapePointer(key)
+			switch key {
+			case "queryParameters", "headers":
+				var in = "query"
+				if key == "headers" {
+					in = "header"
+				}
+				for _, p := range c.parameters(kp, in, rawObject(trait[key])) {
+					result[name+"."+p["name"].(string)] = p
+				}
+			case "responses", "usage", "description", "displayName":
+			default:
+				c.note(kp, "trait %s not supported; dropped", key)
+			}
+		}
+	}
+	return result
+}
+
+// Returns parameters in location in of RAML parameter declarations params at
+// pointer in name order.
+func (c *ramlConverter) parameters(pointer, in string, params map[string]interface{}) []map[string]interface{} {
+	var result []map[string]interface{}
+	for _, key := range sortedKeys(params) {
+		var (
+			decl     = rawObject(params[key])
+			name     = strings.TrimSuffix(key, "?")
+			required = in == "path" || !strings.HasSuffix(key, "?")
+			kp       = pointer + "/" + escapePointer(key)
+		)
+		if r, ok := decl["required"].(bool); ok && in != "path" {
+			required = r
+		}
+		var p = map[string]interface{}{"name": name, "in": in, "schema": c.schema(kp, params[key])}
+		if required {
+			p["required"] = true
+		}
+		if d, ok := decl["description"]; ok {
+			p["description"] = d
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// Returns security schemes of securitySchemes.
+func (c *ramlConverter) securitySchemes() map[string]interface{} {
+	var (
+		schemes = rawObject(c.src["securitySchemes"])
+		result  = make(map[string]interface{})
+	)
+	for _, name := range sortedKeys(schemes) {
+		var (
+			pointer  = "/securitySchemes/" + escapePointer(name)
+			s        = rawObject(schemes[name])
+			settings = rawObject(s["settings"])
+			out      = make(map[string]interface{})
+		)
+		if d, ok := s["description"]; ok {
+			out["description"] = d
+		}
+		switch s["type"] {
+		case "OAuth 2.0":
+			var (
+				flows  = make(map[string]interface{})
+				scopes = make(map[string]interface{})
+			)
+			for _, scope := range rawStrings(settings["scopes"]) {
+				scopes[scope] = ""
+			}
+			for _, grant := range rawStrings(settings["authorizationGrants"]) {
+				var flow = map[string]interface{}{"scopes": scopes}
+				switch grant {
+				case "authorization_code":
+					flow["authorizationUrl"], flow["tokenUrl"] = settings["authorizationUri"], settings["accessTokenUri"]
+					flows["authorizationCode"] = flow
+				case "implicit":
+					flow["authorizationUrl"] = settings["authorizationUri"]
+					flows["implicit"] = flow
+				case "password":
+					flow["tokenUrl"] = settings["accessTokenUri"]
+					flows["password"] = flow
+				case "client_credentials":
+					flow["tokenUrl"] = settings["accessTokenUri"]
+					flows["clientCredentials"] = flow
+				default:
+					c.note(pointer+"/settings/authorizationGrants", "grant %s not supported; dropped", grant)
+				}
+			}
+			out["type"], out["flows"] = "oauth2", flows
+		case "Basic Authentication":
+			out["type"], out["scheme"] = "http", "basic"
+		case "Digest Authentication":
+			out["type"], out["scheme"] = "http", "digest"
+		case "Pass Through":
+			var described = rawObject(s["describedBy"])
+			for _, in := range []string{"headers", "queryParameters"} {
+				if names := sortedKeys(rawObject(described[in])); len(names) > 0 && out["type"] == nil {
+					out["type"], out["name"], out["in"] = "apiKey", strings.TrimSuffix(names[0], "?"), "header"
+					if in == "queryParameters" {
+						out["in"] = "query"
+					}
+				}
+			}
+			if out["type"] == nil {
+				c.note(pointer, "Pass Through scheme without headers or queryParameters dropped")
+				continue
+			}
+		default:
+			c.note(pointer, "security scheme type %v not supported; dropped", s["type"])
+			continue
+		}
+		c.schemes[name] = true
+		result[name] = out
+	}
+	return result
+}
+
+// Returns security requirements of securedBy value v at pointer.
+func (c *ramlConverter) security(pointer string, v interface{}) []interface{} {
+	var result = []interface{}{}
+	for i, item := range rawList(v) {
+		var (
+			name   string
+			scopes = []string{}
+		)
+		switch t := item.(type) {
+		case nil:
+			result = append(result, map[string]interface{}{})
+			continue
+		case string:
+			name = t
+		default:
+			for _, key := range sortedKeys(rawObject(t)) {
+				name = key
+				scopes = append(scopes, rawStrings(rawObject(rawObject(t)[key])["scopes"])...)
+			}
+		}
+		if !c.schemes[name] {
+			c.note(fmt.Sprintf("%s/%d", pointer, i), "requirement of unsupported scheme %s dropped", name)
+			continue
+		}
+		result = append(result, map[string]interface{}{name: scopes})
+	}
+	return result
+}
+
+// Converts resource v of relative path at pointer with its nested
+// resources. parent holds the path, uri parameters, traits and security of
+// the parent resource, if any.
+func (c *ramlConverter) resource(pointer, path string, v interface{}, parent *ramlResource) {
+	var (
+		res  = rawObject(v)
+		r    = &ramlResource{path: path, uriParams: make(map[string]map[string]interface{})}
+		item = make(map[string]interface{})
+	)
+	if parent != nil {
+		r.path = parent.path + path
+		r.traits = append(r.traits, parent.traits...)
+		r.securedBy = parent.securedBy
+		for name, p := range parent.uriParams {
+			r.uriParams[name] = p
+		}
+	}
+	for _, p := range c.parameters(pointer+"/uriParameters", "path", rawObject(res["uriParameters"])) {
+		r.uriParams[p["name"].(string)] = p
+	}
+	r.traits = append(r.traits, c.traitNames(pointer+"/is", res["is"])...)
+	if s, ok := res["securedBy"]; ok {
+		r.securedBy = c.security(pointer+"/securedBy", s)
+	}
+	var params []interface{}
+	for _, m := range templateExpression.FindAllStringSubmatch(r.path, -1) {
+		var p = r.uriParams[m[1]]
+		if p == nil {
+			p = map[string]interface{}{"name": m[1], "in": "path", "required": true, "schema": map[string]interface{}{"type": TypeString}}
+		}
+		params = append(params, p)
+	}
+	if len(params) > 0 {
+		item["parameters"] = params
+	}
+	for _, key := range sortedKeys(res) {
+		var kp = pointer + "/" + escapePointer(key)
+		switch {
+		case strings.HasPrefix(key, "/"):
+			c.resource(kp, key, res[key], r)
+		case ramlMethods[key]:
+			item[key] = c.method(kp, rawObject(res[key]), r)
+		case key == "displayName":
+			item["summary"] = res[key]
+		case key == "description":
+			item["description"] = res[key]
+		case key == "uriParameters", key == "is", key == "securedBy":
+		case strings.HasPrefix(key, "("):
+			c.note(kp, "annotation dropped")
+		default:
+			c.note(kp, "resource %s not supported; dropped", key)
+		}
+	}
+	if len(item) > 0 {
+		c.paths[r.path] = item
+	}
+}
+
+// ramlResource holds inherited properties of a resource.
+type ramlResource struct {
+	path      string
+	uriParams map[string]map[string]interface{}
+	traits    []string
+	securedBy []interface{}
+}
+
+// Returns names of traits applied by is value v at pointer.
+func (c *ramlConverter) traitNames(pointer string, v interface{}) (result []string) {
+	for i, item := range rawList(v) {
+		switch t := item.(type) {
+		case string:
+			result = append(result, t)
+		default:
+			for _, name := range sortedKeys(rawObject(t)) {
+				c.note(fmt.Sprintf("%s/%d", pointer, i), "parameters of trait %s dropped", name)
+				result = append(result, name)
+			}
+		}
+	}
+	return
+}
+
+// Returns the operation of method m at pointer of resource r.
+func (c *ramlConverter) method(pointer string, m map[string]interface{}, r *ramlResource) map[string]interface{} {
+	var (
+		op     = make(map[string]interface{})
+		traits = append(append([]string{}, r.traits...), c.traitNames(pointer+"/is", m["is"])...)
+		params []interface{}
+	)
+	if s, ok := m["securedBy"]; ok {
+		op["security"] = c.security(pointer+"/securedBy", s)
+	} else if r.securedBy != nil {
+		op["security"] = r.securedBy
+	}
+	var allTraits = rawObject(c.src["traits"])
+	for _, name := range traits {
+		var trait = rawObject(allTraits[name])
+		if trait == nil {
+			c.note(pointer+"/is", "unknown trait %s dropped", name)
+			continue
+		}
+		for _, key := range []string{"queryParameters", "headers"} {
+			for _, p := range sortedKeys(rawObject(trait[key])) {
+				var ref = componentsPrefix + "parameters/" + escapePointer(name+"."+strings.TrimSuffix(p, "?"))
+				params = append(params, map[string]interface{}{"$ref": ref})
+			}
+		}
+	}
+	for _, key := range sortedKeys(m) {
+		var value, kp = m[key], pointer + "/" + escapePointer(key)
+		switch key {
+		case "displayName":
+			op["summary"] = value
+		case "description":
+			op["description"] = value
+		case "queryParameters":
+			for _, p := range c.parameters(kp, "query", rawObject(value)) {
+				params = append(params, p)
+			}
+		case "headers":
+			for _, p := range c.parameters(kp, "header", rawObject(value)) {
+				params = append(params, p)
+			}
+		case "body":
+			op["requestBody"] = map[string]interface{}{"content": c.content(kp, value), "required": true}
+		case "responses":
+			op["responses"] = c.responses(kp, rawObject(value))
+		case "is", "securedBy":
+		default:
+			if strings.HasPrefix(key, "(") {
+				c.note(kp, "annotation dropped")
+			} else {
+				c.note(kp, "method %s not supported; dropped", key)
+			}
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	var responses = rawObject(op["responses"])
+	for _, name := range traits {
+		var kp = "/traits/" + escapePointer(name) + "/responses"
+		for code, resp := range c.responses(kp, rawObject(rawObject(allTraits[name])["responses"])) {
+			if responses == nil {
+				responses = make(map[string]interface{})
+			}
+			if _, exists := responses[code]; !exists {
+				responses[code] = resp
+			}
+		}
+	}
+	if len(responses) == 0 {
+		responses = map[string]interface{}{"default": map[string]interface{}{"description": "Default response"}}
+	}
+	op["responses"] = responses
+	return op
+}
+
+// Returns the content of body v at pointer, a map of media types to type
+// declarations or a type declaration of the default media types.
+func (c *ramlConverter) content(pointer string, v interface{}) map[string]interface{} {
+	var (
+		body   = rawObject(v)
+		result = make(map[string]interface{})
+	)
+	var byMediaType = body != nil
+	for key := range body {
+		if !strings.Contains(key, "/") {
+			byMediaType = false
+		}
+	}
+	if !byMediaType {
+		var schema = c.schema(pointer, v)
+		for _, mt := range c.mediaTypes {
+			result[mt] = map[string]interface{}{"schema": schema}
+		}
+		return result
+	}
+	for _, mt := range sortedKeys(body) {
+		result[mt] = map[string]interface{}{"schema": c.schema(pointer+"/"+escapePointer(mt), body[mt])}
+	}
+	return result
+}
+
+// Returns responses of RAML responses at pointer.
+func (c *ramlConverter) responses(pointer string, responses map[string]interface{}) map[string]interface{} {
+	var result = make(map[string]interface{})
+	for _, code := range sortedKeys(responses) {
+		var (
+			kp   = pointer + "/" + escapePointer(code)
+			resp = rawObject(responses[code])
+			out  = make(map[string]interface{})
+		)
+		out["description"] = resp["description"]
+		if out["description"] == nil {
+			out["description"] = "Response"
+			if status, err := strconv.Atoi(code); err == nil && http.StatusText(status) != "" {
+				out["description"] = http.StatusText(status)
+			}
+		}
+		if body, ok := resp["body"]; ok {
+			out["content"] = c.content(kp+"/body", body)
+		}
+		if headers := rawObject(resp["headers"]); len(headers) > 0 {
+			var result = make(map[string]interface{})
+			for _, p := range c.parameters(kp+"/headers", "header", headers) {
+				var header = map[string]interface{}{"schema": p["schema"]}
+				for _, key := range []string{"description", "required"} {
+					if v, ok := p[key]; ok {
+						header[key] = v
+					}
+				}
+				result[p["name"].(string)] = header
+			}
+			out["headers"] = result
+		}
+		for _, key := range sortedKeys(resp) {
+			switch key {
+			case "description", "body", "headers":
+			default:
+				c.note(kp+"/"+escapePointer(key), "response %s not supported; dropped", key)
+			}
+		}
+		result[code] = out
+	}
+	return result
+}
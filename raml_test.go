@@ -0,0 +1,105 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import "testing"
+
+func TestImportRAML(t *testing.T) {
+	var doc, warnings, err = ImportRAML([]byte(`#%RAML 1.0
+title: Pets
+version: v1
+baseUri: https://{region}.example.com/{version}
+baseUriParameters:
+  region:
+    enum: [eu, us]
+mediaType: application/json
+securitySchemes:
+  oauth:
+    type: OAuth 2.0
+    settings:
+      accessTokenUri: https://example.com/token
+      authorizationGrants: [client_credentials]
+      scopes: [read]
+  legacy:
+    type: OAuth 1.0
+securedBy: [oauth: {scopes: [read]}, legacy]
+traits:
+  paged:
+    queryParameters:
+      limit?: integer
+    responses:
+      400:
+        description: Bad page
+resourceTypes:
+  collection: {}
+types:
+  Pet:
+    type: object
+    discriminator: kind
+    properties:
+      kind: string
+      name:
+        type: string
+        example: Rex
+      tags?: string[]
+  Cat:
+    type: Pet
+    discriminatorValue: cat
+    properties:
+      indoor: boolean
+/pets:
+  is: [paged]
+  get:
+    responses:
+      200:
+        body: Pet[]
+  /{petId}:
+    uriParameters:
+      petId: integer
+    delete:
+      (audit): true
+      responses:
+        204:
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var checks = []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"servers", doc.Servers, `[{"url":"https://{region}.example.com/v1","variables":{"region":{"enum":["eu","us"],"default":"eu"}}}]`},
+		{"security", doc.Security, `[{"oauth":["read"]}]`},
+		{"pets", doc.Paths.Items["/pets"].Get, `{"parameters":[{"$ref":"#/components/parameters/paged.limit"}],` +
+			`"responses":{"200":{"content":{"application/json":{"schema":{"items":{"$ref":"#/components/schemas/Pet"},"type":"array"}}},"description":"OK"},` +
+			`"400":{"description":"Bad page"}}}`},
+		{"pet", doc.Paths.Items["/pets/{petId}"], `{"delete":{"parameters":[{"$ref":"#/components/parameters/paged.limit"}],` +
+			`"responses":{"204":{"description":"No Content"},"400":{"description":"Bad page"}}},` +
+			`"parameters":[{"in":"path","name":"petId","required":true,"schema":{"type":"integer"}}]}`},
+		{"limit", doc.Components.Parameters["paged.limit"], `{"in":"query","name":"limit","schema":{"type":"integer"}}`},
+		{"Pet", doc.Components.Schemas["Pet"], `{"discriminator":{"mapping":{"cat":"#/components/schemas/Cat"},"propertyName":"kind"},` +
+			`"properties":{"kind":{"type":"string"},"name":{"examples":["Rex"],"type":"string"},` +
+			`"tags":{"items":{"type":"string"},"type":"array"}},"required":["kind","name"],"type":"object"}`},
+		{"Cat", doc.Components.Schemas["Cat"], `{"allOf":[{"$ref":"#/components/schemas/Pet"}],` +
+			`"properties":{"indoor":{"type":"boolean"}},"required":["indoor"]}`},
+	}
+	for _, check := range checks {
+		if got := jsonString(t, check.v); got != check.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", check.name, got, check.want)
+		}
+	}
+	var report []string
+	for _, note := range warnings {
+		report = append(report, note.String())
+	}
+	var want = `["#/securitySchemes/legacy: security scheme type OAuth 1.0 not supported; dropped",` +
+		`"#/securedBy/1: requirement of unsupported scheme legacy dropped",` +
+		`"#/~1pets/~1{petId}/delete/(audit): annotation dropped",` +
+		`"#/resourceTypes: resourceTypes not supported; dropped"]`
+	if got := jsonString(t, report); got != want {
+		t.Errorf("report:\ngot  %s\nwant %s", got, want)
+	}
+}
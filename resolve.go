@@ -0,0 +1,191 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrUnresolvedReference is returned when a reference cannot be resolved.
+var ErrUnresolvedReference = errors.New("unresolved reference")
+
+// componentsPrefix is the prefix of references to objects in Components.
+const componentsPrefix = "#/components/"
+
+// Returns the reference string if v is a Reference Object or an object decoded
+// from JSON that holds a $ref key, or an empty string otherwise.
+func refOf(v interface{}) string {
+	switch t := v.(type) {
+	case *Reference:
+		if t != nil {
+			return t.Ref
+		}
+	case Reference:
+		return t.Ref
+	case *Schema:
+		if t != nil {
+			return t.Ref
+		}
+	case map[string]interface{}:
+		if ref, ok := t["$ref"].(string); ok {
+			return ref
+		}
+	}
+	return ""
+}
+
+// Returns the name of the component referenced by ref in Components under kind
+// i.e. "schemas", or an empty string if ref does not point to that section.
+func componentName(ref, kind string) string {
+	var prefix = componentsPrefix + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return ""
+	}
+	return unescapePointer(strings.TrimPrefix(ref, prefix))
+}
+
+// Unescapes a JSON pointer reference token as defined by RFC6901.
+func unescapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+// Escapes a JSON pointer reference token as defined by RFC6901.
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// Returns the Components map holding objects of kind i.e. "parameters".
+func (c *Components) section(kind string) map[string]interface{} {
+	if c == nil {
+		return nil
+	}
+	switch kind {
+	case "schemas":
+		return c.Schemas
+	case "responses":
+		return c.Responses
+	case "parameters":
+		return c.Parameters
+	case "examples":
+		return c.Examples
+	case "requestBodies":
+		return c.RequestBodies
+	case "headers":
+		return c.Headers
+	case "securitySchemes":
+		return c.SecuritySchemes
+	case "links":
+		return c.Links
+	case "callbacks":
+		return c.Callbacks
+	case "pathItems":
+		return c.PathItems
+	}
+	return nil
+}
+
+// Follows references in v until a non-reference value is reached and returns
+// it. Only references to objects in the document Components are supported.
+func (o *OpenAPI) deref(v interface{}) (interface{}, error) {
+	var seen = make(map[string]bool)
+	for {
+		var ref = refOf(v)
+		if ref == "" {
+			return v, nil
+		}
+		if seen[ref] {
+			return nil, fmt.Errorf("%w: circular reference '%s'", ErrUnresolvedReference, ref)
+		}
+		seen[ref] = true
+		if !strings.HasPrefix(ref, componentsPrefix) {
+			return nil, fmt.Errorf("%w: '%s'", ErrUnresolvedReference, ref)
+		}
+		var path = strings.SplitN(strings.TrimPrefix(ref, componentsPrefix), "/", 2)
+		if len(path) != 2 {
+			return nil, fmt.Errorf("%w: '%s'", ErrUnresolvedReference, ref)
+		}
+		var next, ok = o.Components.section(path[0])[unescapePointer(path[1])]
+		if !ok {
+			return nil, fmt.Errorf("%w: '%s'", ErrUnresolvedReference, ref)
+		}
+		v = next
+	}
+}
+
+// Resolves v which may be a Reference Object, a typed object or an object
+// decoded from JSON into an interface{} and stores the result in out which
+// must be a pointer to a pointer of the target type, e.g. **Parameter.
+// If v is nil out is set to nil.
+func (o *OpenAPI) resolve(v interface{}, out interface{}) error {
+	var dst = reflect.ValueOf(out).Elem()
+	var val, err = o.deref(v)
+	if err != nil {
+		return err
+	}
+	if val == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	var src = reflect.ValueOf(val)
+	if src.Type() == dst.Type() {
+		dst.Set(src)
+		return nil
+	}
+	var ptr = reflect.New(dst.Type().Elem())
+	if src.Type() == dst.Type().Elem() {
+		ptr.Elem().Set(src)
+		dst.Set(ptr)
+		return nil
+	}
+	if err = remarshal(val, ptr.Interface()); err != nil {
+		return err
+	}
+	dst.Set(ptr)
+	return nil
+}
+
+// Converts in to out by marshaling in to JSON and unmarshaling it into out.
+func remarshal(in, out interface{}) error {
+	var data, err = json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// Returns the value in v, a value decoded from JSON, referenced by a JSON
+// pointer as defined by RFC6901. An empty pointer references v.
+func pointerGet(v interface{}, pointer string) (interface{}, error) {
+	if pointer == "" {
+		return v, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid json pointer '%s'", pointer)
+	}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = unescapePointer(token)
+		switch t := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = t[token]; !ok {
+				return nil, fmt.Errorf("json pointer '%s': key '%s' not found", pointer, token)
+			}
+		case []interface{}:
+			var i, err = strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, fmt.Errorf("json pointer '%s': invalid index '%s'", pointer, token)
+			}
+			v = t[i]
+		default:
+			return nil, fmt.Errorf("json pointer '%s': cannot index into '%s'", pointer, token)
+		}
+	}
+	return v, nil
+}
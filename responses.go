@@ -4,6 +4,13 @@
 
 package openapi
 
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 // Responses Object
 // A container for the expected responses of an operation. The container maps 
 // a HTTP response code to the expected response.
@@ -40,11 +47,77 @@ type Responses struct {
 	// definitions are allowed: 1XX, 2XX, 3XX, 4XX, and 5XX. If a response is
 	// defined using an explicit code, the explicit code definition takes
 	// precedence over the range definition for that code.
+	//
 	// HTTP_STATUS_CODE interface{}
+	Codes map[string]interface{} `json:"-"`
 	
 	// This object MAY be extended with Specification Extensions.
 }
 
+// Returns the status code keys of Codes sorted lexically.
+func (r *Responses) Keys() (result []string) {
+	result = make([]string, 0, len(r.Codes))
+	for key := range r.Codes {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return
+}
+
+// Returns the response for status code and the key it is defined under.
+// An explicit code takes precedence over a range definition such as "2XX"
+// which takes precedence over Default whose key is "default". If no response
+// is defined nil and an empty key are returned.
+func (r *Responses) Find(code int) (key string, response interface{}) {
+	key = strconv.Itoa(code)
+	if v, ok := r.Codes[key]; ok {
+		return key, v
+	}
+	key = key[:1] + "XX"
+	for k, v := range r.Codes {
+		if strings.ToUpper(k) == key {
+			return k, v
+		}
+	}
+	if r.Default != nil {
+		return "default", r.Default
+	}
+	return "", nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *Responses) MarshalJSON() ([]byte, error) {
+	var m = make(map[string]interface{}, len(r.Codes)+1)
+	for key, val := range r.Codes {
+		m[key] = val
+	}
+	if r.Default != nil {
+		m["default"] = r.Default
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// Specification Extensions are skipped.
+func (r *Responses) UnmarshalJSON(data []byte) error {
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	r.Default = nil
+	r.Codes = make(map[string]interface{}, len(m))
+	for key, val := range m {
+		switch {
+		case key == "default":
+			r.Default = val
+		case strings.HasPrefix(key, "x-"):
+		default:
+			r.Codes[key] = val
+		}
+	}
+	return nil
+}
+
 // Responses Object Example
 // A 200 response for a successful operation and a default response for others (implying an error):
 
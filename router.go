@@ -0,0 +1,264 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrRouteNotFound is returned when no path matches a request.
+	ErrRouteNotFound = errors.New("route not found")
+	// ErrMethodNotAllowed is returned when a path matches a request but does
+	// not define an operation for the request method.
+	ErrMethodNotAllowed = errors.New("method not allowed")
+)
+
+// Route is an Operation matched to a request by a Router.
+type Route struct {
+	// Path is the templated path under which the PathItem is defined.
+	Path string
+	// Method is the uppercase HTTP method of the Operation.
+	Method string
+	// PathItem is the PathItem that defines the Operation.
+	PathItem *PathItem
+	// Operation is the matched Operation.
+	Operation *Operation
+}
+
+// Router matches requests to Operations defined in an OpenAPI document.
+//
+// Request paths are cleaned of duplicate slashes, dot segments and a
+// trailing slash and are matched with or without a base path of a server
+// that applies to the operation, i.e. one defined by the Operation, else by
+// the PathItem, else by the OpenAPI document. Variables of a server path
+// match their enum values or any value. Concrete paths are matched before
+// their templated counterparts. HEAD requests match the GET operation of a
+// path that defines no HEAD operation.
+type Router struct {
+	routes []*pathRoute
+}
+
+// pathRoute is a compiled path template.
+type pathRoute struct {
+	path     string
+	item     *PathItem
+	names    []string
+	regexp   *regexp.Regexp
+	literals int
+	// bases are base paths of servers of the path item.
+	bases []*regexp.Regexp
+	// methodBases are base paths of servers of operations that define
+	// servers keyed by method.
+	methodBases map[string][]*regexp.Regexp
+}
+
+// templateExpression matches template expressions in a path template.
+var templateExpression = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// Returns a new Router for the doc.
+func NewRouter(doc *OpenAPI) *Router {
+	var r = &Router{}
+	if doc.Paths == nil {
+		return r
+	}
+	var bases = serverBases(doc.Servers)
+	for _, path := range doc.Paths.Keys() {
+		var item = doc.Paths.Items[path]
+		if item == nil {
+			continue
+		}
+		var route = compilePath(path, item)
+		route.bases = bases
+		if len(item.Servers) > 0 {
+			route.bases = serverBases(item.Servers)
+		}
+		for _, method := range Methods {
+			if op := item.Operation(method); op != nil && len(op.Servers) > 0 {
+				if route.methodBases == nil {
+					route.methodBases = make(map[string][]*regexp.Regexp)
+				}
+				route.methodBases[method] = serverBases(op.Servers)
+			}
+		}
+		r.routes = append(r.routes, route)
+	}
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		if len(r.routes[i].names) != len(r.routes[j].names) {
+			return len(r.routes[i].names) < len(r.routes[j].names)
+		}
+		return r.routes[i].literals > r.routes[j].literals
+	})
+	return r
+}
+
+// Returns compiled base paths of servers that are not the root path.
+func serverBases(servers []*Server) (result []*regexp.Regexp) {
+	for _, server := range servers {
+		if server == nil {
+			continue
+		}
+		if base := compileServerBase(server); base != nil {
+			result = append(result, base)
+		}
+	}
+	return
+}
+
+// Compiles the path component of a server url to a regular expression that
+// matches a request path starting with it and captures the remainder in
+// its last group. Returns nil if the server url has no path other than the
+// root path.
+func compileServerBase(server *Server) *regexp.Regexp {
+	var template = server.resolvedTemplate()
+	template = template[urlPathStart(template):]
+	if i := strings.IndexAny(template, "?#"); i >= 0 {
+		template = template[:i]
+	}
+	template = strings.TrimSuffix(template, "/")
+	if !strings.HasPrefix(template, "/") {
+		return nil
+	}
+	var (
+		expr strings.Builder
+		last int
+	)
+	expr.WriteString("^")
+	for _, loc := range templateExpression.FindAllStringSubmatchIndex(template, -1) {
+		expr.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		var v = server.Variables[template[loc[2]:loc[3]]]
+		switch {
+		case v != nil && len(v.Enum) > 0:
+			var alts []string
+			for _, e := range v.Enum {
+				alts = append(alts, regexp.QuoteMeta(e))
+			}
+			expr.WriteString("(?:" + strings.Join(alts, "|") + ")")
+		case v != nil:
+			expr.WriteString("[^/]*" + strings.Repeat("/[^/]*", strings.Count(v.Default, "/")))
+		default:
+			expr.WriteString("[^/]*")
+		}
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(template[last:]))
+	expr.WriteString("(/.*)$")
+	var re, err = regexp.Compile(expr.String())
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+// Compiles a path template to a pathRoute.
+func compilePath(template string, item *PathItem) *pathRoute {
+	var (
+		result = &pathRoute{path: template, item: item}
+		path   = cleanPath(template)
+		expr   strings.Builder
+		last   int
+	)
+	expr.WriteString("^")
+	for _, loc := range templateExpression.FindAllStringSubmatchIndex(path, -1) {
+		expr.WriteString(regexp.QuoteMeta(path[last:loc[0]]))
+		expr.WriteString("([^/]+)")
+		result.literals += loc[0] - last
+		result.names = append(result.names, path[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(path[last:]))
+	expr.WriteString("$")
+	result.literals += len(path) - last
+	result.regexp = regexp.MustCompile(expr.String())
+	return result
+}
+
+// Returns p with duplicate slashes, dot segments and a trailing slash
+// removed.
+func cleanPath(p string) string {
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return path.Clean(p)
+}
+
+// Returns the submatches of the path template of the route in request path
+// p or in p stripped of one of the base paths, or nil if none match.
+func (pr *pathRoute) match(p string, bases []*regexp.Regexp) []string {
+	if match := pr.regexp.FindStringSubmatch(p); match != nil {
+		return match
+	}
+	for _, base := range bases {
+		if m := base.FindStringSubmatch(p); m != nil {
+			if match := pr.regexp.FindStringSubmatch(m[len(m)-1]); match != nil {
+				return match
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the Route matching the request method and path and the values of
+// path parameters extracted from the request path, unescaped.
+//
+// If no path matches ErrRouteNotFound is returned. If a path matches but
+// defines no operation for the method ErrMethodNotAllowed is returned.
+func (r *Router) FindRoute(method, path string) (*Route, map[string]string, error) {
+	method = strings.ToUpper(method)
+	path = cleanPath(path)
+	var err = ErrRouteNotFound
+	for _, route := range r.routes {
+		var (
+			opMethod = method
+			op       = route.item.Operation(method)
+		)
+		if op == nil && method == http.MethodHead && route.item.Get != nil {
+			opMethod, op = http.MethodGet, route.item.Get
+		}
+		var bases = route.bases
+		if b, ok := route.methodBases[opMethod]; ok {
+			bases = b
+		}
+		var match = route.match(path, bases)
+		if match == nil {
+			continue
+		}
+		if op == nil {
+			err = ErrMethodNotAllowed
+			continue
+		}
+		var params = make(map[string]string, len(route.names))
+		for i, name := range route.names {
+			var value, uerr = url.PathUnescape(match[i+1])
+			if uerr != nil {
+				value = match[i+1]
+			}
+			params[name] = value
+		}
+		return &Route{
+			Path:      route.path,
+			Method:    opMethod,
+			PathItem:  route.item,
+			Operation: op,
+		}, params, nil
+	}
+	return nil, nil, err
+}
+
+// Returns the Route matching the request and path parameter values.
+// See FindRoute.
+func (r *Router) Match(req *http.Request) (*Route, map[string]string, error) {
+	var path = req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return r.FindRoute(req.Method, path)
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"testing"
+)
+
+func TestRouterFindRoute(t *testing.T) {
+	var doc, err = FromJSON([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Pets", "version": "1.0.0"},
+		"servers": [{
+			"url": "https://{env}.example.com/{version}",
+			"variables": {"env": {"default": "api"}, "version": {"default": "v1", "enum": ["v1", "v2"]}}
+		}],
+		"paths": {
+			"/pets": {"get": {"operationId": "listPets"}},
+			"/pets/{id}": {
+				"servers": [{"url": "/items"}],
+				"get": {"operationId": "getPet"},
+				"delete": {"operationId": "deletePet", "servers": [{"url": "https://admin.example.com/admin"}]}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Paths.Items["/owners"] = nil
+	var router = NewRouter(doc)
+	var tests = []struct {
+		method, path, want string
+	}{
+		{"GET", "/pets", "listPets"},
+		{"GET", "/v1/pets", "listPets"},
+		{"GET", "/v2/pets/", "listPets"},
+		{"GET", "//v1//pets", "listPets"},
+		{"HEAD", "/v1/pets", "listPets"},
+		{"GET", "/v3/pets", "route not found"},
+		{"GET", "/items/pets/1", "getPet"},
+		{"GET", "/v1/pets/1", "route not found"},
+		{"DELETE", "/admin/pets/1", "deletePet"},
+		{"DELETE", "/items/pets/1", "route not found"},
+		{"POST", "/v1/pets", "method not allowed"},
+		{"GET", "/owners", "route not found"},
+	}
+	for _, test := range tests {
+		var got string
+		if route, _, err := router.FindRoute(test.method, test.path); err != nil {
+			got = err.Error()
+		} else {
+			got = route.Operation.OperationID
+		}
+		if got != test.want {
+			t.Errorf("%s %s: got %q, want %q", test.method, test.path, got, test.want)
+		}
+	}
+}
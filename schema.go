@@ -4,6 +4,12 @@
 
 package openapi
 
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
 // Schema Object
 // The Schema Object allows the definition of input and output data types. These types can be objects, but also primitives and arrays. This object is a superset of the JSON Schema Specification Draft 2020-12.
 //
@@ -24,6 +30,154 @@ package openapi
 //
 // The OpenAPI Specification's base vocabulary is comprised of the following keywords:
 type Schema struct {
+	// JSON Schema Core vocabulary.
+
+	// The dialect of the schema. MAY be present in any root Schema Object.
+	Schema string `json:"$schema,omitempty"`
+	// The canonical URI of the schema resource.
+	ID string `json:"$id,omitempty"`
+	// A reference to a schema which is applied to the instance in addition to
+	// sibling keywords.
+	Ref string `json:"$ref,omitempty"`
+	// A plain name fragment identifier of the schema.
+	Anchor string `json:"$anchor,omitempty"`
+	// A reference resolved dynamically at evaluation time.
+	DynamicRef string `json:"$dynamicRef,omitempty"`
+	// A plain name fragment identifier used as a target of DynamicRef.
+	DynamicAnchor string `json:"$dynamicAnchor,omitempty"`
+	// A comment for maintainers of the schema.
+	Comment string `json:"$comment,omitempty"`
+	// A map of re-usable schemas local to this schema.
+	Defs map[string]*Schema `json:"$defs,omitempty"`
+
+	// JSON Schema Applicator vocabulary.
+
+	// An instance MUST validate against all of the schemas.
+	AllOf []*Schema `json:"allOf,omitempty"`
+	// An instance MUST validate against at least one of the schemas.
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	// An instance MUST validate against exactly one of the schemas.
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	// An instance MUST NOT validate against the schema.
+	Not *Schema `json:"not,omitempty"`
+	// If an instance validates against If it MUST validate against Then,
+	// otherwise it MUST validate against Else.
+	If   *Schema `json:"if,omitempty"`
+	Then *Schema `json:"then,omitempty"`
+	Else *Schema `json:"else,omitempty"`
+	// Schemas applied to an object instance if it has the property named by
+	// the key.
+	DependentSchemas map[string]*Schema `json:"dependentSchemas,omitempty"`
+	// Schemas applied to array items by position.
+	PrefixItems []*Schema `json:"prefixItems,omitempty"`
+	// Schema applied to array items not covered by PrefixItems.
+	Items *Schema `json:"items,omitempty"`
+	// An array instance MUST contain an item that validates against the
+	// schema.
+	Contains *Schema `json:"contains,omitempty"`
+	// Schemas applied to object properties by name.
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	// Schemas applied to object properties whose names match the regular
+	// expression keys.
+	PatternProperties map[string]*Schema `json:"patternProperties,omitempty"`
+	// Holds bool | Schema Object
+	//
+	// Schema applied to object properties not matched by Properties or
+	// PatternProperties.
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+	// Schema applied to property names of an object instance.
+	PropertyNames *Schema `json:"propertyNames,omitempty"`
+
+	// JSON Schema Unevaluated Locations vocabulary.
+
+	// Holds bool | Schema Object
+	//
+	// Schema applied to array items not evaluated by other keywords.
+	UnevaluatedItems interface{} `json:"unevaluatedItems,omitempty"`
+	// Holds bool | Schema Object
+	//
+	// Schema applied to object properties not evaluated by other keywords.
+	UnevaluatedProperties interface{} `json:"unevaluatedProperties,omitempty"`
+
+	// JSON Schema Validation vocabulary.
+
+	// Holds string | []string
+	//
+	// The type or a list of types of the instance. One of "null", "boolean",
+	// "object", "array", "number", "string" or "integer".
+	Type interface{} `json:"type,omitempty"`
+	// An instance MUST be equal to one of the values.
+	Enum []interface{} `json:"enum,omitempty"`
+	// An instance MUST be equal to the value.
+	Const interface{} `json:"const,omitempty"`
+	// A numeric instance MUST be a multiple of the value.
+	MultipleOf *float64 `json:"multipleOf,omitempty"`
+	// A numeric instance MUST be less than or equal to the value.
+	Maximum *float64 `json:"maximum,omitempty"`
+	// A numeric instance MUST be less than the value.
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+	// A numeric instance MUST be greater than or equal to the value.
+	Minimum *float64 `json:"minimum,omitempty"`
+	// A numeric instance MUST be greater than the value.
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	// Maximum length of a string instance.
+	MaxLength *int `json:"maxLength,omitempty"`
+	// Minimum length of a string instance.
+	MinLength *int `json:"minLength,omitempty"`
+	// A regular expression a string instance MUST match.
+	Pattern string `json:"pattern,omitempty"`
+	// Maximum number of items of an array instance.
+	MaxItems *int `json:"maxItems,omitempty"`
+	// Minimum number of items of an array instance.
+	MinItems *int `json:"minItems,omitempty"`
+	// Items of an array instance MUST be unique.
+	UniqueItems bool `json:"uniqueItems,omitempty"`
+	// Maximum number of items matching Contains.
+	MaxContains *int `json:"maxContains,omitempty"`
+	// Minimum number of items matching Contains.
+	MinContains *int `json:"minContains,omitempty"`
+	// Maximum number of properties of an object instance.
+	MaxProperties *int `json:"maxProperties,omitempty"`
+	// Minimum number of properties of an object instance.
+	MinProperties *int `json:"minProperties,omitempty"`
+	// Names of properties an object instance MUST have.
+	Required []string `json:"required,omitempty"`
+	// Properties an object instance MUST have if it has the property named
+	// by the key.
+	DependentRequired map[string][]string `json:"dependentRequired,omitempty"`
+
+	// JSON Schema Format and Content vocabularies.
+
+	// Semantic format of the instance. See Data Type Formats.
+	Format string `json:"format,omitempty"`
+	// Encoding of string instance content, i.e. "base64".
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	// Media type of string instance content.
+	ContentMediaType string `json:"contentMediaType,omitempty"`
+	// Schema of decoded string instance content.
+	ContentSchema *Schema `json:"contentSchema,omitempty"`
+
+	// JSON Schema Meta-Data vocabulary.
+
+	// A short title of the instance.
+	Title string `json:"title,omitempty"`
+	// A description of the instance. CommonMark syntax MAY be used for rich
+	// text representation.
+	Description string `json:"description,omitempty"`
+	// The default value of the instance.
+	Default interface{} `json:"default,omitempty"`
+	// The instance is deprecated.
+	Deprecated bool `json:"deprecated,omitempty"`
+	// The instance is managed by the owning authority and modifications
+	// SHOULD be ignored.
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// The instance is never present when retrieved from the owning authority.
+	WriteOnly bool `json:"writeOnly,omitempty"`
+	// Sample instances.
+	Examples []interface{} `json:"examples,omitempty"`
+
+	// OpenAPI base vocabulary.
+
 	// Adds support for polymorphism. The discriminator is an object name that
 	// is used to differentiate between other schemas which may satisfy the
 	// payload description. See Composition and Inheritance for more details.
@@ -43,10 +197,163 @@ type Schema struct {
 	// JSON Schema examples keyword. Use of example is discouraged, and later 
 	// versions of this specification may remove it.
 	Example interface{} `json:"example,omitempty"`
+	// A true value adds "null" to the allowed types. This is the OpenAPI 3.0
+	// way of declaring a nullable schema, replaced by a "null" type in 3.1.
+	//
+	// Deprecated: Use a "null" type instead. It is retained to support
+	// documents of earlier versions.
+	Nullable bool `json:"nullable,omitempty"`
+	// Specification Extensions keyed by names that start with "x-". They
+	// are encoded as properties of the schema.
+	Extensions map[string]interface{} `json:"-"`
 
 	// This object MAY be extended with Specification Extensions, though as noted, additional properties MAY omit the x- prefix within this object.
 }
 
+// Schema instance types.
+const (
+	TypeNull    = "null"
+	TypeBoolean = "boolean"
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeNumber  = "number"
+	TypeString  = "string"
+	TypeInteger = "integer"
+)
+
+// Returns the types from the Type keyword.
+func (s *Schema) Types() (result []string) {
+	switch t := s.Type.(type) {
+	case string:
+		result = []string{t}
+	case []string:
+		result = t
+	case []interface{}:
+		for _, v := range t {
+			if str, ok := v.(string); ok {
+				result = append(result, str)
+			}
+		}
+	}
+	return
+}
+
+// Returns true if the Type keyword includes t.
+func (s *Schema) HasType(t string) bool {
+	for _, v := range s.Types() {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the schema allows null, either by a "null" type or the
+// Nullable keyword.
+func (s *Schema) IsNullable() bool {
+	return s.Nullable || s.HasType(TypeNull)
+}
+
+// Returns the first type from the Type keyword that is not "null" or an
+// empty string if none.
+func (s *Schema) PrimaryType() string {
+	for _, v := range s.Types() {
+		if v != TypeNull {
+			return v
+		}
+	}
+	return ""
+}
+
+// Returns true if the schema describes binary content, either as a string with
+// format "binary" or content with a media type and no encoding, without a type
+// or of type string as upgraded from format "binary" by UpgradeV30.
+func (s *Schema) IsBinary() bool {
+	if s.Format == "binary" {
+		return true
+	}
+	if s.ContentMediaType == "" || s.ContentEncoding != "" {
+		return false
+	}
+	var t = s.PrimaryType()
+	return t == "" || t == TypeString
+}
+
+// Returns the schema of additional properties and true if the
+// AdditionalProperties keyword allows additional properties.
+// If it holds a bool true the returned Schema is nil.
+func (s *Schema) AdditionalPropertiesSchema() (*Schema, bool) {
+	return boolOrSchema(s.AdditionalProperties)
+}
+
+// schemaFields is a Schema without JSON methods.
+type schemaFields Schema
+
+// MarshalJSON implements json.Marshaler.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	var data, err = json.Marshal(schemaFields(s))
+	if err != nil || len(s.Extensions) == 0 {
+		return data, err
+	}
+	ext, err := json.Marshal(s.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 2 {
+		return ext, nil
+	}
+	return append(append(data[:len(data)-1], ','), ext[1:]...), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*schemaFields)(s)); err != nil {
+		return err
+	}
+	s.Extensions = nil
+	if !bytes.Contains(data, []byte(`"x-`)) {
+		return nil
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	for key, raw := range m {
+		if !strings.HasPrefix(key, "x-") {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if s.Extensions == nil {
+			s.Extensions = make(map[string]interface{})
+		}
+		s.Extensions[key] = v
+	}
+	return nil
+}
+
+// Converts a value holding bool | Schema Object to a Schema. Returns false if
+// v is false.
+func boolOrSchema(v interface{}) (*Schema, bool) {
+	switch t := v.(type) {
+	case nil:
+		return nil, true
+	case bool:
+		return nil, t
+	case *Schema:
+		return t, true
+	case Schema:
+		return &t, true
+	}
+	var result = &Schema{}
+	if err := remarshal(v, result); err != nil {
+		return nil, true
+	}
+	return result, true
+}
+
 // Composition and Inheritance (Polymorphism)
 // The OpenAPI Specification allows combining and extending model definitions using the allOf property of JSON Schema, in effect offering model composition. allOf takes an array of object definitions that are validated independently but together compose a single object.
 
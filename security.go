@@ -0,0 +1,357 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Security scheme types.
+const (
+	SecurityTypeAPIKey        = "apiKey"
+	SecurityTypeHTTP          = "http"
+	SecurityTypeMutualTLS     = "mutualTLS"
+	SecurityTypeOAuth2        = "oauth2"
+	SecurityTypeOpenIDConnect = "openIdConnect"
+)
+
+var (
+	// ErrNoCredentials is returned when a request carries no credentials for
+	// a security scheme.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrNoAuthenticator is returned when no Authenticator is registered for
+	// a security scheme.
+	ErrNoAuthenticator = errors.New("no authenticator")
+	// ErrInsufficientScope is returned when an authenticated identity is not
+	// granted all of the scopes required by a security requirement.
+	ErrInsufficientScope = errors.New("insufficient scope")
+	// ErrUnsupportedScheme is returned for security schemes of unknown type.
+	ErrUnsupportedScheme = errors.New("unsupported security scheme")
+)
+
+// Credentials are extracted from a request for a single SecurityScheme.
+type Credentials struct {
+	// Name is the name of the SecurityScheme in Components.
+	Name string
+	// Scheme is the SecurityScheme the Credentials were extracted for.
+	Scheme *SecurityScheme
+	// Scopes are the scopes required by the SecurityRequirement being
+	// evaluated.
+	Scopes []string
+
+	// APIKey is the key value for "apiKey" schemes.
+	APIKey string
+	// Username and Password are set for "http" schemes using "basic".
+	Username, Password string
+	// Token is the credential part of the Authorization header for "http"
+	// schemes other than "basic" and the bearer token for "oauth2" and
+	// "openIdConnect" schemes.
+	Token string
+	// Certificates are the client certificates for "mutualTLS", the leaf of
+	// each chain in the VerifiedChains of the TLS connection state. A server
+	// must verify client certificates, using a tls.Config ClientAuth of
+	// tls.VerifyClientCertIfGiven or tls.RequireAndVerifyClientCert, for
+	// any to be present; unverified peer certificates are never used.
+	Certificates []*x509.Certificate
+}
+
+// Identity is the result of a successful authentication.
+type Identity struct {
+	// Principal is an application defined authenticated entity.
+	Principal interface{}
+	// Scopes are the scopes granted to the Principal.
+	Scopes []string
+}
+
+// Authenticator authenticates Credentials extracted from a request.
+//
+// It should return an error if the credentials are invalid. Returned Identity
+// scopes are checked against scopes required by the security requirement.
+type Authenticator func(r *http.Request, c *Credentials) (*Identity, error)
+
+// SecurityError describes a failed authorization of a request.
+type SecurityError struct {
+	// Errors holds errors of each evaluated Security Requirement.
+	Errors []error
+}
+
+// Error implements error.
+func (se *SecurityError) Error() string {
+	var s []string
+	for _, err := range se.Errors {
+		s = append(s, err.Error())
+	}
+	return "unauthorized: " + strings.Join(s, "; ")
+}
+
+// Forbidden returns true if a credential was valid but the identity lacked
+// required scopes, meaning the request should be answered with 403.
+func (se *SecurityError) Forbidden() bool {
+	for _, err := range se.Errors {
+		if errors.Is(err, ErrInsufficientScope) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the Security Requirements that apply to op.
+// Operation requirements override those defined at document level.
+func (o *OpenAPI) OperationSecurity(op *Operation) []*SecurityRequirement {
+	if op != nil && op.Security != nil {
+		return op.Security
+	}
+	return o.Security
+}
+
+// SecurityEnforcer authorizes requests to Operations according to Security
+// Requirements that apply to them by dispatching credentials extracted for
+// each Security Scheme to registered Authenticators.
+//
+// A list of Security Requirements is satisfied if any of the requirements is
+// satisfied and a Security Requirement is satisfied if all of its schemes are.
+type SecurityEnforcer struct {
+	// OnError, if not nil, is called to write a response when a request is
+	// not authorized. If nil, a 401 or 403 status is written.
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+	// PassUnmatched, if true, passes requests that do not match an operation
+	// of the document to next unchecked instead of rejecting them with a 404
+	// or 405 status.
+	PassUnmatched bool
+
+	doc            *OpenAPI
+	router         *Router
+	authenticators map[string]Authenticator
+}
+
+// Returns a new SecurityEnforcer for the doc.
+func NewSecurityEnforcer(doc *OpenAPI) *SecurityEnforcer {
+	return &SecurityEnforcer{
+		doc:            doc,
+		router:         NewRouter(doc),
+		authenticators: make(map[string]Authenticator),
+	}
+}
+
+// Registers an Authenticator for a Security Scheme named name in Components.
+func (se *SecurityEnforcer) Register(name string, a Authenticator) {
+	se.authenticators[name] = a
+}
+
+// Handler returns a http.Handler that authorizes requests to operations
+// before passing them to next.
+//
+// Requests that do not match an operation, because the router returns
+// ErrRouteNotFound or ErrMethodNotAllowed, are not covered by any security
+// requirement and are rejected unless PassUnmatched is set, in which case
+// they are passed to next unchecked and handlers behind the enforcer that
+// serve them must secure them themselves.
+//
+// Identities of authenticated schemes are stored in the request context and
+// can be retrieved using IdentitiesFromContext.
+func (se *SecurityEnforcer) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var route, _, err = se.router.Match(r)
+		if err != nil {
+			switch {
+			case se.PassUnmatched:
+				next.ServeHTTP(w, r)
+			case errors.Is(err, ErrMethodNotAllowed):
+				http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			default:
+				http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			}
+			return
+		}
+		ids, err := se.Authorize(r, route.Operation)
+		if err != nil {
+			se.fail(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identitiesKey{}, ids)))
+	})
+}
+
+// Writes an authorization failure response.
+func (se *SecurityEnforcer) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if se.OnError != nil {
+		se.OnError(w, r, err)
+		return
+	}
+	var serr *SecurityError
+	if errors.As(err, &serr) && serr.Forbidden() {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// Authorize evaluates Security Requirements that apply to op against r and
+// returns the identities of authenticated schemes of the first satisfied
+// requirement keyed by scheme name. If no requirement is satisfied the
+// returned error is a *SecurityError.
+func (se *SecurityEnforcer) Authorize(r *http.Request, op *Operation) (map[string]*Identity, error) {
+	var reqs = se.doc.OperationSecurity(op)
+	if len(reqs) == 0 {
+		return map[string]*Identity{}, nil
+	}
+	var serr = &SecurityError{}
+	for _, req := range reqs {
+		var ids, err = se.satisfy(r, req)
+		if err == nil {
+			return ids, nil
+		}
+		serr.Errors = append(serr.Errors, err)
+	}
+	return nil, serr
+}
+
+// Checks if all schemes of a security requirement authenticate r.
+func (se *SecurityEnforcer) satisfy(r *http.Request, req *SecurityRequirement) (map[string]*Identity, error) {
+	var ids = make(map[string]*Identity)
+	if req == nil {
+		return ids, nil
+	}
+	var names = make([]string, 0, len(req.Schemes))
+	for name := range req.Schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var id, err = se.authenticate(r, name, req.Schemes[name])
+		if err != nil {
+			return nil, fmt.Errorf("security scheme '%s': %w", name, err)
+		}
+		ids[name] = id
+	}
+	return ids, nil
+}
+
+// Authenticates r against a named security scheme and checks scopes.
+func (se *SecurityEnforcer) authenticate(r *http.Request, name string, scopes []string) (*Identity, error) {
+	var (
+		scheme *SecurityScheme
+		v, ok  = se.doc.Components.section("securitySchemes")[name]
+	)
+	if !ok {
+		return nil, fmt.Errorf("%w: undefined", ErrUnresolvedReference)
+	}
+	if err := se.doc.resolve(v, &scheme); err != nil {
+		return nil, err
+	}
+	var auth = se.authenticators[name]
+	if auth == nil {
+		return nil, ErrNoAuthenticator
+	}
+	var cred, err = ExtractCredentials(r, scheme)
+	if err != nil {
+		return nil, err
+	}
+	cred.Name = name
+	cred.Scopes = scopes
+	id, err := auth(r, cred)
+	if err != nil {
+		return nil, err
+	}
+	if id == nil {
+		id = &Identity{}
+	}
+	for _, scope := range scopes {
+		if !containsString(id.Scopes, scope) {
+			return nil, fmt.Errorf("%w: '%s'", ErrInsufficientScope, scope)
+		}
+	}
+	return id, nil
+}
+
+// Extracts credentials for a security scheme from a request.
+// Returns ErrNoCredentials if the request carries none.
+func ExtractCredentials(r *http.Request, scheme *SecurityScheme) (*Credentials, error) {
+	var cred = &Credentials{Scheme: scheme}
+	switch scheme.Type {
+	case SecurityTypeAPIKey:
+		switch strings.ToLower(scheme.In) {
+		case "header":
+			cred.APIKey = r.Header.Get(scheme.Name)
+		case "query":
+			cred.APIKey = r.URL.Query().Get(scheme.Name)
+		case "cookie":
+			if c, err := r.Cookie(scheme.Name); err == nil {
+				cred.APIKey = c.Value
+			}
+		default:
+			return nil, fmt.Errorf("%w: apiKey in '%s'", ErrUnsupportedScheme, scheme.In)
+		}
+		if cred.APIKey == "" {
+			return nil, ErrNoCredentials
+		}
+	case SecurityTypeHTTP:
+		if strings.EqualFold(scheme.Scheme, "basic") {
+			var ok bool
+			if cred.Username, cred.Password, ok = r.BasicAuth(); !ok {
+				return nil, ErrNoCredentials
+			}
+			break
+		}
+		if cred.Token = authorizationToken(r, scheme.Scheme); cred.Token == "" {
+			return nil, ErrNoCredentials
+		}
+	case SecurityTypeOAuth2, SecurityTypeOpenIDConnect:
+		if cred.Token = authorizationToken(r, "bearer"); cred.Token == "" {
+			return nil, ErrNoCredentials
+		}
+	case SecurityTypeMutualTLS:
+		if r.TLS == nil {
+			return nil, ErrNoCredentials
+		}
+		for _, chain := range r.TLS.VerifiedChains {
+			if len(chain) > 0 {
+				cred.Certificates = append(cred.Certificates, chain[0])
+			}
+		}
+		if len(cred.Certificates) == 0 {
+			return nil, ErrNoCredentials
+		}
+	default:
+		return nil, fmt.Errorf("%w: '%s'", ErrUnsupportedScheme, scheme.Type)
+	}
+	return cred, nil
+}
+
+// Returns the credentials from the Authorization header of r if it uses the
+// authorization scheme, case insensitive.
+func authorizationToken(r *http.Request, scheme string) string {
+	var header = strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(header) <= len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) || header[len(scheme)] != ' ' {
+		return ""
+	}
+	return strings.TrimSpace(header[len(scheme)+1:])
+}
+
+// identitiesKey is the context key for authenticated identities.
+type identitiesKey struct{}
+
+// Returns identities stored in ctx by SecurityEnforcer keyed by security
+// scheme name or nil if none.
+func IdentitiesFromContext(ctx context.Context) map[string]*Identity {
+	var ids, _ = ctx.Value(identitiesKey{}).(map[string]*Identity)
+	return ids
+}
+
+// Returns true if s is in a.
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
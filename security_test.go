@@ -0,0 +1,167 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecurityEnforcer(t *testing.T) {
+	const data string = `{
+		"openapi": "3.1.0",
+		"info": {"title": "Pets", "version": "1.0.0"},
+		"servers": [{"url": "https://example.com/v1"}],
+		"security": [{"api_key": []}, {"petstore_auth": ["read:pets"]}],
+		"paths": {
+			"/pets/{id}": {
+				"get": {"operationId": "getPet"},
+				"delete": {
+					"operationId": "deletePet",
+					"security": [{"petstore_auth": ["write:pets"], "basic": []}]
+				}
+			},
+			"/public": {
+				"get": {"operationId": "public", "security": []}
+			}
+		},
+		"components": {
+			"securitySchemes": {
+				"api_key": {"type": "apiKey", "name": "X-API-Key", "in": "header"},
+				"basic": {"type": "http", "scheme": "basic"},
+				"petstore_auth": {
+					"type": "oauth2",
+					"flows": {"implicit": {"authorizationUrl": "https://example.com/auth", "scopes": {}}}
+				}
+			}
+		}
+	}`
+	doc, err := FromJSON([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var enforcer = NewSecurityEnforcer(doc)
+	enforcer.Register("api_key", func(r *http.Request, c *Credentials) (*Identity, error) {
+		if c.APIKey != "secret" {
+			return nil, errors.New("invalid key")
+		}
+		return &Identity{Principal: "key"}, nil
+	})
+	enforcer.Register("basic", func(r *http.Request, c *Credentials) (*Identity, error) {
+		if c.Username != "admin" || c.Password != "admin" {
+			return nil, errors.New("invalid password")
+		}
+		return &Identity{Principal: c.Username}, nil
+	})
+	enforcer.Register("petstore_auth", func(r *http.Request, c *Credentials) (*Identity, error) {
+		switch c.Token {
+		case "reader":
+			return &Identity{Scopes: []string{"read:pets"}}, nil
+		case "writer":
+			return &Identity{Scopes: []string{"read:pets", "write:pets"}}, nil
+		}
+		return nil, errors.New("invalid token")
+	})
+	var handler = enforcer.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	var tests = []struct {
+		method, path string
+		header       map[string]string
+		basic        bool
+		status       int
+	}{
+		{"GET", "/v1/pets/1", nil, false, http.StatusUnauthorized},
+		{"GET", "/v1/pets/1", map[string]string{"X-API-Key": "secret"}, false, http.StatusNoContent},
+		{"GET", "/v1/pets/1", map[string]string{"X-API-Key": "wrong"}, false, http.StatusUnauthorized},
+		{"GET", "/v1/pets/1", map[string]string{"Authorization": "Bearer reader"}, false, http.StatusNoContent},
+		{"DELETE", "/v1/pets/1", map[string]string{"Authorization": "Bearer writer"}, false, http.StatusUnauthorized},
+		{"DELETE", "/v1/pets/1", map[string]string{"X-API-Key": "secret"}, true, http.StatusUnauthorized},
+		{"GET", "/v1/public", nil, false, http.StatusNoContent},
+		{"GET", "/v1/unknown", nil, false, http.StatusNotFound},
+		{"PUT", "/v1/pets/1", nil, false, http.StatusMethodNotAllowed},
+		{"GET", "/v1/pets/1/", nil, false, http.StatusUnauthorized},
+		{"GET", "//v1//pets/1", nil, false, http.StatusUnauthorized},
+		{"HEAD", "/v1/pets/1", nil, false, http.StatusUnauthorized},
+		{"HEAD", "/v1/pets/1", map[string]string{"X-API-Key": "secret"}, false, http.StatusNoContent},
+	}
+	for _, test := range tests {
+		var req = httptest.NewRequest(test.method, test.path, nil)
+		for key, val := range test.header {
+			req.Header.Set(key, val)
+		}
+		if test.basic {
+			req.SetBasicAuth("admin", "admin")
+		}
+		var rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != test.status {
+			t.Errorf("%s %s: expected %d, got %d", test.method, test.path, test.status, rec.Code)
+		}
+	}
+
+	enforcer.PassUnmatched = true
+	for path, status := range map[string]int{"/v1/unknown": http.StatusNoContent, "/v1/pets/1": http.StatusUnauthorized} {
+		var rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != status {
+			t.Errorf("passing unmatched GET %s: expected %d, got %d", path, status, rec.Code)
+		}
+	}
+
+	var req = httptest.NewRequest("GET", "/v1/pets/1", nil)
+	req.Header.Set("Authorization", "Bearer nobody")
+	if _, err = enforcer.Authorize(req, doc.Paths.Items["/pets/{id}"].Delete); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSecurityEnforcerScopes(t *testing.T) {
+	var doc = &OpenAPI{
+		Security: []*SecurityRequirement{{Schemes: map[string][]string{"oauth": {"admin"}}}},
+		Paths: &Paths{Items: map[string]*PathItem{
+			"/admin": {Get: &Operation{}},
+		}},
+		Components: &Components{SecuritySchemes: map[string]interface{}{
+			"oauth": &SecurityScheme{Type: SecurityTypeOAuth2},
+		}},
+	}
+	var enforcer = NewSecurityEnforcer(doc)
+	enforcer.Register("oauth", func(r *http.Request, c *Credentials) (*Identity, error) {
+		return &Identity{Principal: c.Token, Scopes: []string{"user"}}, nil
+	})
+	var rec = httptest.NewRecorder()
+	var req = httptest.NewRequest("GET", "/admin", nil)
+	req.Header.Set("Authorization", "bearer token")
+	enforcer.Handler(http.NotFoundHandler()).ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestExtractCredentialsMutualTLS(t *testing.T) {
+	var (
+		scheme = &SecurityScheme{Type: SecurityTypeMutualTLS}
+		leaf   = &x509.Certificate{}
+		req    = httptest.NewRequest("GET", "/", nil)
+	)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}
+	if _, err := ExtractCredentials(req, scheme); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("unverified certificate: got error %v, want ErrNoCredentials", err)
+	}
+	req.TLS.VerifiedChains = [][]*x509.Certificate{{leaf, {}}}
+	var cred, err = ExtractCredentials(req, scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cred.Certificates) != 1 || cred.Certificates[0] != leaf {
+		t.Errorf("got certificates %v, want the verified leaf", cred.Certificates)
+	}
+}
@@ -4,6 +4,8 @@
 
 package openapi
 
+import "encoding/json"
+
 // Security Requirement Object
 // Lists the required security schemes to execute this operation. The name used
 // for each property MUST correspond to a security scheme declared in the 
@@ -24,7 +26,31 @@ type SecurityRequirement struct {
 	// names required for the execution, and the list MAY be empty if 
 	// authorization does not require a specified scope. For other security 
 	// scheme types, the array MUST be empty.
+	//
 	// {name} []string
+	Schemes map[string][]string `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (sr *SecurityRequirement) MarshalJSON() ([]byte, error) {
+	var m = make(map[string][]string, len(sr.Schemes))
+	for name, scopes := range sr.Schemes {
+		if scopes == nil {
+			scopes = []string{}
+		}
+		m[name] = scopes
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (sr *SecurityRequirement) UnmarshalJSON(data []byte) error {
+	var m map[string][]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	sr.Schemes = m
+	return nil
 }
 
 // Security Requirement Object Examples
@@ -13,7 +13,7 @@ package openapi
 type SecurityScheme struct {
 	// Applies to Any.
 	// REQUIRED. The type of the security scheme. Valid values are "apiKey",
-	// "http", "oauth2", "openIdConnect".
+	// "http", "mutualTLS", "oauth2", "openIdConnect".
 	Type string `json:"type,omitempty"`
 	// Applies to Any.
 	// A short description for security scheme. CommonMark syntax MAY be used
@@ -4,6 +4,14 @@
 
 package openapi
 
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
 // Server Object
 // An object representing a Server.
 type Server struct {
@@ -18,9 +26,203 @@ type Server struct {
 	// A map between a variable name and its value. The value is used for
 	// substitution in the server's URL template.
 	Variables map[string]*ServerVariable `json:"variables,omitempty"`
+	// Base is the location of the document the server is defined in, used to
+	// resolve a relative URL. It is not a part of the specification.
+	Base *url.URL `json:"-"`
 	// This object MAY be extended with Specification Extensions.
 }
 
+// ErrInvalidServerVariable is returned when a server variable value is
+// invalid or a variable is undefined.
+var ErrInvalidServerVariable = errors.New("invalid server variable")
+
+// Returns the server URL with variables substituted by values from vars or
+// their default values if not given in vars. Values of variables that define
+// an enum must be one of the enum values. Values in the path are escaped
+// per path segment, slashes in them separating segments, and values in the
+// query are query escaped. Values before the path, e.g. in the host, must
+// not contain any of "/?#@:". If the URL is relative it is resolved against
+// Base, if set.
+func (s *Server) Expand(vars map[string]string) (*url.URL, error) {
+	var (
+		result strings.Builder
+		path   = urlPathStart(s.URL)
+		query  = strings.IndexAny(s.URL, "?#")
+		last   int
+	)
+	for _, loc := range templateExpression.FindAllStringSubmatchIndex(s.URL, -1) {
+		result.WriteString(s.URL[last:loc[0]])
+		var value, err = s.variableValue(s.URL[loc[2]:loc[3]], vars)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case query >= 0 && loc[0] > query:
+			value = url.QueryEscape(value)
+		case loc[0] >= path:
+			value = escapeSegments(value)
+		case strings.ContainsAny(value, "/?#@:"):
+			return nil, fmt.Errorf("%w: '%s' value '%s' not valid in the host", ErrInvalidServerVariable, s.URL[loc[2]:loc[3]], value)
+		}
+		result.WriteString(value)
+		last = loc[1]
+	}
+	result.WriteString(s.URL[last:])
+	var u, err = url.Parse(result.String())
+	if err != nil {
+		return nil, err
+	}
+	if !u.IsAbs() && s.Base != nil {
+		u = s.Base.ResolveReference(u)
+	}
+	return u, nil
+}
+
+// Returns the index at which the path of URL template u starts, after its
+// scheme and authority, or 0 if u has no authority.
+func urlPathStart(u string) int {
+	var i int
+	switch {
+	case strings.Contains(u, "://"):
+		i = strings.Index(u, "://") + 3
+	case strings.HasPrefix(u, "//"):
+		i = 2
+	default:
+		return 0
+	}
+	if j := strings.IndexAny(u[i:], "/?#"); j >= 0 {
+		return i + j
+	}
+	return len(u)
+}
+
+// Returns path segments of s separated by slashes, each path escaped.
+func escapeSegments(s string) string {
+	var segments = strings.Split(s, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// Returns the value of a variable from vars or its default value.
+func (s *Server) variableValue(name string, vars map[string]string) (string, error) {
+	var (
+		variable     = s.Variables[name]
+		value, given = vars[name]
+	)
+	if !given {
+		if variable == nil {
+			return "", fmt.Errorf("%w: '%s' undefined", ErrInvalidServerVariable, name)
+		}
+		value = variable.Default
+	}
+	if variable != nil && len(variable.Enum) > 0 && !containsString(variable.Enum, value) {
+		return "", fmt.Errorf("%w: '%s' value '%s' not in enum", ErrInvalidServerVariable, name, value)
+	}
+	return value, nil
+}
+
+// Matches u against the server URL and returns values of server variables
+// extracted from u. The server URL must be a prefix of u ending at a path
+// segment boundary, i.e. u may contain a path of an operation. Values of
+// variables that define an enum must be one of the enum values. If the URL
+// is relative it is resolved against Base, if set, and matched against the
+// full u, otherwise it is matched against the path of u.
+//
+// A variable in the host matches a part of a host name label. A variable in
+// the path matches as many path segments as its default value has, e.g. a
+// variable with a default of "v1/api" matches two segments, as a server URL
+// alone does not tell where the server path ends and the operation path
+// begins. Values in the path are unescaped.
+func (s *Server) Match(u *url.URL) (map[string]string, bool) {
+	var (
+		template = s.resolvedTemplate()
+		target   = u.EscapedPath()
+		expr     strings.Builder
+		names    []string
+		paths    []bool
+		last     int
+	)
+	if strings.Contains(template, "://") || strings.HasPrefix(template, "//") {
+		target = "//" + u.Host + target
+		if u.Scheme != "" {
+			target = u.Scheme + ":" + target
+		}
+		if strings.HasPrefix(template, "//") && u.Scheme != "" {
+			template = u.Scheme + ":" + template
+		}
+	}
+	expr.WriteString("^")
+	var path = urlPathStart(template)
+	for _, loc := range templateExpression.FindAllStringSubmatchIndex(template, -1) {
+		expr.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		var name = template[loc[2]:loc[3]]
+		if v := s.Variables[name]; v != nil && len(v.Enum) > 0 {
+			var alts []string
+			for _, e := range v.Enum {
+				alts = append(alts, regexp.QuoteMeta(e))
+			}
+			expr.WriteString("(" + strings.Join(alts, "|") + ")")
+		} else if v != nil && loc[0] >= path {
+			var segments = strings.Count(v.Default, "/")
+			expr.WriteString("([^/?#]*?" + strings.Repeat("/[^/?#]*?", segments) + ")")
+		} else if loc[0] < path {
+			expr.WriteString("([^/?#.:@]*?)")
+		} else {
+			expr.WriteString("([^/?#]*?)")
+		}
+		names = append(names, name)
+		paths = append(paths, loc[0] >= path)
+		last = loc[1]
+	}
+	var tail = strings.TrimSuffix(template[last:], "/")
+	expr.WriteString(regexp.QuoteMeta(tail))
+	expr.WriteString("(?:/.*)?$")
+	var re, err = regexp.Compile(expr.String())
+	if err != nil {
+		return nil, false
+	}
+	var match = re.FindStringSubmatch(target)
+	if match == nil {
+		return nil, false
+	}
+	var result = make(map[string]string, len(names))
+	for i, name := range names {
+		result[name] = match[i+1]
+		if paths[i] {
+			var value, err = url.PathUnescape(match[i+1])
+			if err != nil {
+				return nil, false
+			}
+			result[name] = value
+		}
+	}
+	return result, true
+}
+
+// Returns the server URL template resolved against Base if it is relative.
+// Template expressions are preserved.
+func (s *Server) resolvedTemplate() string {
+	if s.Base == nil || strings.Contains(s.URL, "://") {
+		return s.URL
+	}
+	var names []string
+	var marked = templateExpression.ReplaceAllStringFunc(s.URL, func(expr string) string {
+		names = append(names, expr)
+		return fmt.Sprintf("openapiservervariable%dx", len(names)-1)
+	})
+	var u, err = url.Parse(marked)
+	if err != nil {
+		return s.URL
+	}
+	var result = s.Base.ResolveReference(u).String()
+	for i, name := range names {
+		result = strings.Replace(result, fmt.Sprintf("openapiservervariable%dx", i), name, 1)
+	}
+	return result
+}
+
 // Server Object Example
 // A single server would be described as:
 
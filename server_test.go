@@ -0,0 +1,120 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestServerExpand(t *testing.T) {
+	var server = &Server{
+		URL: "https://{username}.gigantic-server.com:{port}/{basePath}",
+		Variables: map[string]*ServerVariable{
+			"username": {Default: "demo"},
+			"port":     {Enum: []string{"8443", "443"}, Default: "8443"},
+			"basePath": {Default: "v2"},
+		},
+	}
+	u, err := server.Expand(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := u.String(); s != "https://demo.gigantic-server.com:8443/v2" {
+		t.Fatalf("unexpected default expansion: %s", s)
+	}
+	if u, err = server.Expand(map[string]string{"username": "john", "port": "443"}); err != nil {
+		t.Fatal(err)
+	}
+	if s := u.String(); s != "https://john.gigantic-server.com:443/v2" {
+		t.Fatalf("unexpected expansion: %s", s)
+	}
+	if _, err = server.Expand(map[string]string{"port": "80"}); !errors.Is(err, ErrInvalidServerVariable) {
+		t.Fatalf("expected enum error, got %v", err)
+	}
+	for _, value := range []string{"evil.com/x?", "evil.com#", "user@evil.com", "evil.com:80"} {
+		if _, err = server.Expand(map[string]string{"username": value}); !errors.Is(err, ErrInvalidServerVariable) {
+			t.Errorf("%q: expected host value error, got %v", value, err)
+		}
+	}
+
+	target, _ := url.Parse("https://jane.gigantic-server.com:443/v3/pets/1")
+	vars, ok := server.Match(target)
+	if !ok {
+		t.Fatal("expected match")
+	}
+	if vars["username"] != "jane" || vars["port"] != "443" || vars["basePath"] != "v3" {
+		t.Fatalf("unexpected variables: %v", vars)
+	}
+	target, _ = url.Parse("https://jane.gigantic-server.com:80/v3")
+	if _, ok = server.Match(target); ok {
+		t.Fatal("expected no match for port outside of enum")
+	}
+	target, _ = url.Parse("https://jane.evil.com.gigantic-server.com:443/v3")
+	if _, ok = server.Match(target); ok {
+		t.Fatal("expected no match for host variable across labels")
+	}
+}
+
+func TestServerRelative(t *testing.T) {
+	var doc = &OpenAPI{
+		Servers: []*Server{{
+			URL:       "/api/{version}",
+			Variables: map[string]*ServerVariable{"version": {Default: "v1"}},
+		}},
+	}
+	if err := doc.SetLocation("https://example.com/docs/openapi.json"); err != nil {
+		t.Fatal(err)
+	}
+	u, err := doc.Servers[0].Expand(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := u.String(); s != "https://example.com/api/v1" {
+		t.Fatalf("unexpected expansion: %s", s)
+	}
+	target, _ := url.Parse("https://example.com/api/v2/pets")
+	vars, ok := doc.Servers[0].Match(target)
+	if !ok || vars["version"] != "v2" {
+		t.Fatalf("unexpected match: %v %v", vars, ok)
+	}
+	target, _ = url.Parse("https://other.com/api/v2/pets")
+	if _, ok = doc.Servers[0].Match(target); ok {
+		t.Fatal("expected no match for a different host")
+	}
+}
+
+func TestServerEscaping(t *testing.T) {
+	var server = &Server{
+		URL: "https://{host}.example.com/{basePath}/items?tag={tag}",
+		Variables: map[string]*ServerVariable{
+			"host":     {Default: "api"},
+			"basePath": {Default: "v1/api"},
+			"tag":      {Default: "a&b"},
+		},
+	}
+	u, err := server.Expand(map[string]string{"basePath": "v 2/a?b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := u.String(); s != "https://api.example.com/v%202/a%3Fb/items?tag=a%26b" {
+		t.Fatalf("unexpected expansion: %s", s)
+	}
+
+	server.URL = "https://{host}.example.com/{basePath}"
+	target, _ := url.Parse("https://api.example.com/v%202/a%3Fb/pets/1")
+	vars, ok := server.Match(target)
+	if !ok {
+		t.Fatal("expected match")
+	}
+	if vars["host"] != "api" || vars["basePath"] != "v 2/a?b" {
+		t.Fatalf("unexpected variables: %v", vars)
+	}
+	target, _ = url.Parse("https://api.example.com/v1")
+	if _, ok = server.Match(target); ok {
+		t.Fatal("expected no match for a single segment base path")
+	}
+}
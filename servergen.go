@@ -0,0 +1,518 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GenerateServer generates the source of a Go package named pkg that
+// implements a server for operations defined in doc.
+//
+// The package declares:
+//
+//   - Types for component schemas and inline request and response schemas.
+//   - A <Operation>Params struct for each operation with parameters.
+//   - A ServerInterface with a method for each operation named from its
+//     OperationID that receives the request, its decoded parameters and
+//     its decoded JSON body if one is defined, and returns a *Response.
+//   - New<Operation><Code>Response constructors of typed responses.
+//   - A Handler that implements http.Handler, routes requests to operations,
+//     decodes parameters and bodies and dispatches to a ServerInterface.
+//
+// Request bodies that are not JSON are not decoded and are left in the
+// request. JSON bodies are rejected with status 415 if their content type is
+// not one of the JSON media types of the operation and with status 413 if
+// they exceed Handler.MaxBodyBytes. Object parameters must be serialized as
+// JSON.
+func GenerateServer(doc *OpenAPI, pkg string) ([]byte, error) {
+	var g, err = newGoGenerator(doc, pkg,
+		"ServerInterface", "Response", "Handler", "NewHandler", "ErrorHandler",
+		"DefaultErrorHandler", "DefaultMaxBodyBytes")
+	if err != nil {
+		return nil, err
+	}
+	if err = g.declareComponents(); err != nil {
+		return nil, err
+	}
+	ops, err := g.operations()
+	if err != nil {
+		return nil, err
+	}
+	var params = make(map[*goOperation]string)
+	for _, op := range ops {
+		if len(op.Params) > 0 {
+			params[op] = g.declareParams(op)
+		}
+	}
+	g.declareServerInterface(ops, params)
+	g.declareResponses(ops)
+	g.declareHandler(ops, params)
+	for _, path := range []string{
+		"bytes", "encoding", "encoding/base64", "encoding/json", "fmt", "io",
+		"io/ioutil", "mime", "net/http", "net/url", "reflect", "regexp",
+		"strconv", "strings",
+	} {
+		g.use(path)
+	}
+	g.printf("%s", serverRuntime)
+	return g.source()
+}
+
+// Declares the ServerInterface.
+func (g *goGenerator) declareServerInterface(ops []*goOperation, params map[*goOperation]string) {
+	var title = "the API"
+	if g.doc.Info != nil && g.doc.Info.Title != "" {
+		title = g.doc.Info.Title
+	}
+	g.printf("// ServerInterface is implemented by servers of %s.\n", title)
+	g.printf("//\n// A method that returns an error responds with status 500.\n")
+	g.printf("type ServerInterface interface {\n")
+	for i, op := range ops {
+		if i > 0 {
+			g.printf("\n")
+		}
+		g.printf("%s\t%s(r *http.Request%s) (*Response, error)\n",
+			operationDoc(op, "handles", "\t"), op.Name, serverArgs(op, params[op]))
+	}
+	g.printf("}\n\n")
+}
+
+// Returns the params and body arguments of an operation method.
+func serverArgs(op *goOperation, params string) string {
+	var sb strings.Builder
+	if params != "" {
+		sb.WriteString(", params *" + params)
+	}
+	if op.Body != nil && op.Body.Type != "" {
+		sb.WriteString(", body " + pointerType(op.Body.Type))
+	}
+	return sb.String()
+}
+
+// Declares response constructors of operations.
+func (g *goGenerator) declareResponses(ops []*goOperation) {
+	for _, op := range ops {
+		for _, resp := range op.Responses {
+			var (
+				name   = g.ident("New" + op.Name + codeIdent(resp.Code) + "Response")
+				args   []string
+				fields []string
+				text   = fmt.Sprintf("%s returns a %s response of %s.", name, resp.Code, op.Name)
+			)
+			if resp.Description != "" {
+				text += "\n\n" + resp.Description
+			}
+			if _, err := strconv.Atoi(resp.Code); err == nil {
+				fields = append(fields, "StatusCode: "+resp.Code)
+			} else {
+				args = append(args, "status int")
+				fields = append(fields, "StatusCode: status")
+			}
+			if resp.ContentType != "" {
+				args = append(args, "body "+resp.Type)
+				fields = append(fields, fmt.Sprintf("ContentType: %q", resp.ContentType), "Body: body")
+			}
+			g.printf("%sfunc %s(%s) *Response {\n\treturn &Response{%s}\n}\n\n",
+				goComment(text, ""), name, strings.Join(args, ", "), strings.Join(fields, ", "))
+		}
+	}
+}
+
+// Declares the Handler constructor and operation handlers.
+func (g *goGenerator) declareHandler(ops []*goOperation, params map[*goOperation]string) {
+	var (
+		router = NewRouter(&OpenAPI{Paths: g.doc.Paths})
+		byPath = make(map[string]*goOperation)
+	)
+	for _, op := range ops {
+		byPath[op.Method+" "+op.Path] = op
+	}
+	g.printf("// NewHandler returns a Handler that dispatches requests to server.\n")
+	g.printf("//\n// Request paths are matched without a server base path which should\n")
+	g.printf("// be stripped, i.e. using http.StripPrefix.\n")
+	g.printf("func NewHandler(server ServerInterface) *Handler {\n")
+	g.printf("\tvar h = &Handler{server: server}\n\th.routes = []route{\n")
+	var names = make(map[*goOperation][]string)
+	for _, route := range router.routes {
+		for _, method := range Methods {
+			var op = byPath[method+" "+route.path]
+			if op == nil {
+				continue
+			}
+			names[op] = route.names
+			g.printf("\t\t{%q, regexp.MustCompile(%q), h.handle%s},\n", method, route.regexp.String(), op.Name)
+		}
+	}
+	g.printf("\t}\n\treturn h\n}\n\n")
+	for _, op := range ops {
+		g.declareOperationHandler(op, params[op], names[op])
+	}
+}
+
+// Declares the handler of op that decodes the request and calls the server.
+func (g *goGenerator) declareOperationHandler(op *goOperation, params string, pathNames []string) {
+	g.printf("func (h *Handler) handle%s(w http.ResponseWriter, r *http.Request, path []string) {\n", op.Name)
+	var call = []string{"r"}
+	if params != "" {
+		g.printf("\tvar params %s\n", params)
+		for _, p := range op.Params {
+			if p.In == "query" {
+				g.printf("\tvar query = r.URL.Query()\n")
+				break
+			}
+		}
+		for _, p := range op.Params {
+			var values string
+			switch p.In {
+			case "path":
+				values = "nil"
+				for i, name := range pathNames {
+					if name == p.Name {
+						values = fmt.Sprintf("path[%d:%d]", i, i+1)
+					}
+				}
+			case "query":
+				values = fmt.Sprintf("query[%q]", p.Name)
+			case "header":
+				values = fmt.Sprintf("r.Header.Values(%q)", p.Name)
+			case "cookie":
+				values = fmt.Sprintf("cookieValues(r, %q)", p.Name)
+			default:
+				continue
+			}
+			g.printf("\tif err := bindParam(&params.%s, %q, %s, %q, %t, %t); err != nil {\n",
+				p.Field, p.Name, values, p.Style, p.Explode, p.Required)
+			g.printf("\t\th.fail(w, r, err, http.StatusBadRequest)\n\t\treturn\n\t}\n")
+		}
+		call = append(call, "&params")
+	}
+	if op.Body != nil && op.Body.Type != "" {
+		var t = op.Body.Type
+		if strings.HasPrefix(t, "*") {
+			t = t[1:]
+		}
+		g.printf("\tvar body = new(%s)\n", t)
+		g.printf("\tif ok, status, err := h.decodeBody(w, r, body, %t, %#v); err != nil {\n", op.Body.Required, op.Body.JSONTypes)
+		g.printf("\t\th.fail(w, r, err, status)\n\t\treturn\n")
+		g.printf("\t} else if !ok {\n\t\tbody = nil\n\t}\n")
+		call = append(call, "body")
+	}
+	g.printf("\tvar resp, err = h.server.%s(%s)\n", op.Name, strings.Join(call, ", "))
+	g.printf("\th.respond(w, r, resp, err)\n}\n\n")
+}
+
+// serverRuntime is the part of a generated server that does not depend on
+// the document.
+const serverRuntime = `// Response is a response returned by ServerInterface methods.
+type Response struct {
+	// StatusCode is the response status code, 200 if zero.
+	StatusCode int
+	// Header holds additional response headers.
+	Header http.Header
+	// ContentType is the response content type. If empty and Body is
+	// encoded as JSON "application/json" is used.
+	ContentType string
+	// Body is the response body. A []byte or io.Reader is written as is,
+	// other values are encoded as JSON.
+	Body interface{}
+}
+
+// ErrorHandler writes the response to a request that failed with err.
+// Status is http.StatusBadRequest for invalid requests,
+// http.StatusUnsupportedMediaType or http.StatusRequestEntityTooLarge for
+// request bodies of an unsupported content type or size,
+// http.StatusNotFound or http.StatusMethodNotAllowed for requests that do
+// not match an operation and http.StatusInternalServerError for errors
+// returned by ServerInterface methods.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error, status int)
+
+// DefaultErrorHandler writes status and the error message as plain text.
+// The message of internal server errors is not disclosed.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error, status int) {
+	var message = err.Error()
+	if status == http.StatusInternalServerError {
+		message = http.StatusText(status)
+	}
+	http.Error(w, message, status)
+}
+
+// DefaultMaxBodyBytes is the default maximum size of a request body.
+const DefaultMaxBodyBytes = 10 << 20
+
+// Handler is a http.Handler that decodes requests and dispatches them to a
+// ServerInterface.
+type Handler struct {
+	// ErrorHandler handles errors. If nil DefaultErrorHandler is used.
+	ErrorHandler ErrorHandler
+	// MaxBodyBytes is the maximum size of a decoded request body in bytes.
+	// If zero DefaultMaxBodyBytes is used.
+	MaxBodyBytes int64
+
+	server ServerInterface
+	routes []route
+}
+
+// route is an operation path and method.
+type route struct {
+	method  string
+	pattern *regexp.Regexp
+	handle  func(w http.ResponseWriter, r *http.Request, path []string)
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var allowed []string
+	for _, rt := range h.routes {
+		var m = rt.pattern.FindStringSubmatch(r.URL.EscapedPath())
+		if m == nil {
+			continue
+		}
+		if rt.method != r.Method {
+			allowed = append(allowed, rt.method)
+			continue
+		}
+		var path = m[1:]
+		for i, s := range path {
+			var err error
+			if path[i], err = url.PathUnescape(s); err != nil {
+				h.fail(w, r, err, http.StatusBadRequest)
+				return
+			}
+		}
+		rt.handle(w, r, path)
+		return
+	}
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		h.fail(w, r, fmt.Errorf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	h.fail(w, r, fmt.Errorf("path %s not found", r.URL.Path), http.StatusNotFound)
+}
+
+// Handles an error.
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, err error, status int) {
+	var handler = h.ErrorHandler
+	if handler == nil {
+		handler = DefaultErrorHandler
+	}
+	handler(w, r, err, status)
+}
+
+// Writes resp or handles err.
+func (h *Handler) respond(w http.ResponseWriter, r *http.Request, resp *Response, err error) {
+	if err != nil {
+		h.fail(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	for key, values := range resp.Header {
+		w.Header()[key] = values
+	}
+	var status = resp.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if resp.ContentType != "" {
+		w.Header().Set("Content-Type", resp.ContentType)
+	}
+	switch body := resp.Body.(type) {
+	case nil:
+		w.WriteHeader(status)
+	case []byte:
+		w.WriteHeader(status)
+		w.Write(body)
+	case io.Reader:
+		w.WriteHeader(status)
+		io.Copy(w, body)
+	default:
+		var data, err = json.Marshal(body)
+		if err != nil {
+			h.fail(w, r, err, http.StatusInternalServerError)
+			return
+		}
+		if resp.ContentType == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(status)
+		w.Write(data)
+	}
+}
+
+// Decodes a JSON request body of one of contentTypes into dst. Returns false
+// if the body is empty and not required, or the status of an error.
+func (h *Handler) decodeBody(w http.ResponseWriter, r *http.Request, dst interface{}, required bool, contentTypes []string) (bool, int, error) {
+	var data []byte
+	if r.Body != nil {
+		var limit = h.MaxBodyBytes
+		if limit <= 0 {
+			limit = DefaultMaxBodyBytes
+		}
+		var err error
+		if data, err = ioutil.ReadAll(http.MaxBytesReader(w, r.Body, limit)); err != nil {
+			if int64(len(data)) >= limit {
+				return false, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", limit)
+			}
+			return false, http.StatusBadRequest, err
+		}
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		if required {
+			return false, http.StatusBadRequest, fmt.Errorf("missing request body")
+		}
+		return false, 0, nil
+	}
+	if !matchContentType(r.Header.Get("Content-Type"), contentTypes) {
+		return false, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content type '%s'", r.Header.Get("Content-Type"))
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return false, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err)
+	}
+	return true, 0, nil
+}
+
+// Returns true if the media type of contentType is one of those of
+// contentTypes.
+func matchContentType(contentType string, contentTypes []string) bool {
+	var mt, _, err = mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, ct := range contentTypes {
+		if t, _, err := mime.ParseMediaType(ct); err == nil && t == mt {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns values of request cookies named name.
+func cookieValues(r *http.Request, name string) (result []string) {
+	for _, cookie := range r.Cookies() {
+		if cookie.Name == name {
+			result = append(result, cookie.Value)
+		}
+	}
+	return
+}
+
+// Decodes values of parameter name serialized in style into dst which must
+// be a pointer.
+func bindParam(dst interface{}, name string, values []string, style string, explode, required bool) error {
+	if len(values) == 0 {
+		if required {
+			return fmt.Errorf("missing required parameter '%s'", name)
+		}
+		return nil
+	}
+	var v = reflect.ValueOf(dst).Elem()
+	if v.Kind() == reflect.Ptr {
+		var elem = reflect.New(v.Type().Elem())
+		if err := bindValue(elem.Elem(), name, values, style, explode); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	return bindValue(v, name, values, style, explode)
+}
+
+// Decodes values of parameter name into v.
+func bindValue(v reflect.Value, name string, values []string, style string, explode bool) error {
+	var s = values[0]
+	switch style {
+	case "content":
+		if err := json.Unmarshal([]byte(s), v.Addr().Interface()); err != nil {
+			return fmt.Errorf("invalid parameter '%s': %w", name, err)
+		}
+		return nil
+	case "label":
+		s = strings.TrimPrefix(s, ".")
+	case "matrix":
+		s = strings.TrimPrefix(s, ";"+name+"=")
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		var items []string
+		switch {
+		case explode && (style == "form" || style == "spaceDelimited" || style == "pipeDelimited"):
+			items = values
+		case explode && style == "label":
+			items = strings.Split(s, ".")
+		case explode && style == "matrix":
+			items = strings.Split(s, ";"+name+"=")
+		case style == "spaceDelimited":
+			items = strings.Split(s, " ")
+		case style == "pipeDelimited":
+			items = strings.Split(s, "|")
+		default:
+			items = strings.Split(s, ",")
+		}
+		var slice = reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setString(slice.Index(i), item); err != nil {
+				return fmt.Errorf("invalid parameter '%s': %w", name, err)
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	if err := setString(v, s); err != nil {
+		return fmt.Errorf("invalid parameter '%s': %w", name, err)
+	}
+	return nil
+}
+
+// Sets v to the value parsed from s.
+func setString(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		var b, err = strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n, err = strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n, err = strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		var f, err = strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var data, err = base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(data)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(s))
+	default:
+		return json.Unmarshal([]byte(s), v.Addr().Interface())
+	}
+	return nil
+}
+`
@@ -0,0 +1,187 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// petStore is a document used by generator tests.
+const petStore = `{
+	"openapi": "3.1.0",
+	"info": {"title": "Pet Store", "version": "1.0"},
+	"servers": [{
+		"url": "https://{env}.example.com/v1",
+		"variables": {"env": {"default": "api", "enum": ["api", "staging"]}}
+	}],
+	"paths": {
+		"/pets": {
+			"get": {
+				"operationId": "listPets",
+				"parameters": [
+					{"name": "limit", "in": "query", "schema": {"type": "integer", "format": "int32"}},
+					{"name": "tags", "in": "query", "schema": {"type": "array", "items": {"type": "string"}}},
+					{"name": "ids", "in": "query", "explode": false, "schema": {"type": "array", "items": {"type": "integer"}}}
+				],
+				"responses": {
+					"200": {"description": "Pets", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}}}},
+					"default": {"description": "Error", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+				}
+			},
+			"post": {
+				"operationId": "createPet",
+				"requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/NewPet"}}}},
+				"responses": {
+					"201": {"description": "Created", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}
+				}
+			}
+		},
+		"/pets/{petId}": {
+			"parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer", "format": "int64"}}],
+			"get": {
+				"operationId": "getPet",
+				"security": [{"api_key": []}, {"bearer": []}],
+				"parameters": [{"name": "X-Request-ID", "in": "header", "schema": {"type": "string"}}],
+				"responses": {
+					"200": {"description": "A pet", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+					"404": {"description": "Not found"}
+				}
+			}
+		}
+	},
+	"components": {
+		"securitySchemes": {
+			"api_key": {"type": "apiKey", "name": "X-API-Key", "in": "header"},
+			"bearer": {"type": "http", "scheme": "bearer"}
+		},
+		"schemas": {
+			"Pet": {
+				"allOf": [
+					{"$ref": "#/components/schemas/NewPet"},
+					{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer", "format": "int64"}}}
+				]
+			},
+			"NewPet": {
+				"type": "object",
+				"required": ["name"],
+				"properties": {
+					"name": {"type": "string"},
+					"tag": {"type": ["string", "null"]}
+				}
+			},
+			"Error": {
+				"type": "object",
+				"required": ["message"],
+				"properties": {"message": {"type": "string"}}
+			}
+		}
+	}
+}`
+
+// Runs "go test" in a temporary module holding files. The test is skipped
+// if the go command is not available.
+func goTestFiles(t *testing.T, files map[string][]byte) {
+	var goBin, err = exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not available")
+	}
+	var dir = t.TempDir()
+	files["go.mod"] = []byte("module generated\n\ngo 1.16\n")
+	for name, data := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var cmd = exec.Command(goBin, "test", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GO111MODULE=on", "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+}
+
+func TestGenerateServer(t *testing.T) {
+	var doc, err = FromJSON([]byte(petStore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := GenerateServer(doc, "generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goTestFiles(t, map[string][]byte{
+		"server.go": src,
+		"server_test.go": []byte(`package generated
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type server struct{}
+
+func (server) ListPets(r *http.Request, params *ListPetsParams) (*Response, error) {
+	return NewListPets200Response([]Pet{{ID: int64(*params.Limit), Name: strings.Join(params.Tags, ",")}}), nil
+}
+
+func (server) CreatePet(r *http.Request, body *NewPet) (*Response, error) {
+	return NewCreatePet201Response(Pet{ID: 1, Name: body.Name, Tag: body.Tag}), nil
+}
+
+func (server) GetPet(r *http.Request, params *GetPetParams) (*Response, error) {
+	if params.PetID != 1 {
+		return NewGetPet404Response(), nil
+	}
+	return NewGetPet200Response(Pet{ID: params.PetID, Name: *params.XRequestID}), nil
+}
+
+func TestServer(t *testing.T) {
+	var h = NewHandler(server{})
+	h.MaxBodyBytes = 64
+	var s = httptest.NewServer(h)
+	defer s.Close()
+	for _, test := range []struct {
+		method, path, contentType, body string
+		status                          int
+		response                        string
+	}{
+		{"GET", "/pets?limit=3&tags=a&tags=b", "application/json", "", 200, ` + "`" + `[{"id":3,"name":"a,b"}]` + "`" + `},
+		{"GET", "/pets?limit=x", "application/json", "", 400, ""},
+		{"POST", "/pets", "application/json", ` + "`" + `{"name":"rex","tag":"dog"}` + "`" + `, 201, ` + "`" + `{"id":1,"name":"rex","tag":"dog"}` + "`" + `},
+		{"POST", "/pets", "application/json", "", 400, ""},
+		{"POST", "/pets", "text/plain", "rex", 415, ""},
+		{"POST", "/pets", "application/json", ` + "`" + `{"name":"` + "`" + ` + strings.Repeat("x", 64) + ` + "`" + `"}` + "`" + `, 413, ""},
+		{"GET", "/pets/1", "application/json", "", 200, ` + "`" + `{"id":1,"name":"rid"}` + "`" + `},
+		{"GET", "/pets/2", "application/json", "", 404, ""},
+		{"DELETE", "/pets/1", "application/json", "", 405, ""},
+		{"GET", "/toys", "application/json", "", 404, ""},
+	} {
+		var req, _ = http.NewRequest(test.method, s.URL+test.path, strings.NewReader(test.body))
+		req.Header.Set("X-Request-ID", "rid")
+		req.Header.Set("Content-Type", test.contentType)
+		var resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data, _ = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Fatalf("%s %s: expected status %d, got %d: %s", test.method, test.path, test.status, resp.StatusCode, data)
+		}
+		if test.response != "" && strings.TrimSpace(string(data)) != test.response {
+			t.Fatalf("%s %s: unexpected response %s", test.method, test.path, data)
+		}
+	}
+}
+`),
+	})
+}
@@ -0,0 +1,527 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TrafficEntry is an observed HTTP request and its response.
+type TrafficEntry struct {
+	Method         string
+	URL            string
+	RequestHeader  http.Header
+	RequestBody    []byte
+	Status         int
+	ResponseHeader http.Header
+	ResponseBody   []byte
+}
+
+// Returns entries of a HAR 1.2 archive. Entries without a response status,
+// such as aborted requests, are skipped.
+func ParseHAR(data []byte) ([]*TrafficEntry, error) {
+	type harHeader struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	var har struct {
+		Log *struct {
+			Entries []struct {
+				Request struct {
+					Method   string      `json:"method"`
+					URL      string      `json:"url"`
+					Headers  []harHeader `json:"headers"`
+					PostData *struct {
+						MimeType string `json:"mimeType"`
+						Text     string `json:"text"`
+					} `json:"postData"`
+				} `json:"request"`
+				Response struct {
+					Status  int         `json:"status"`
+					Headers []harHeader `json:"headers"`
+					Content struct {
+						MimeType string `json:"mimeType"`
+						Text     string `json:"text"`
+						Encoding string `json:"encoding"`
+					} `json:"content"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, err
+	}
+	if har.Log == nil {
+		return nil, errors.New("har: missing log")
+	}
+	var headers = func(list []harHeader) http.Header {
+		var h = make(http.Header)
+		for _, header := range list {
+			h.Add(header.Name, header.Value)
+		}
+		return h
+	}
+	var result []*TrafficEntry
+	for i, e := range har.Log.Entries {
+		if e.Response.Status == 0 {
+			continue
+		}
+		var entry = &TrafficEntry{
+			Method:         e.Request.Method,
+			URL:            e.Request.URL,
+			RequestHeader:  headers(e.Request.Headers),
+			Status:         e.Response.Status,
+			ResponseHeader: headers(e.Response.Headers),
+			ResponseBody:   []byte(e.Response.Content.Text),
+		}
+		if pd := e.Request.PostData; pd != nil {
+			entry.RequestBody = []byte(pd.Text)
+			if pd.MimeType != "" && entry.RequestHeader.Get("Content-Type") == "" {
+				entry.RequestHeader.Set("Content-Type", pd.MimeType)
+			}
+		}
+		if e.Response.Content.Encoding == "base64" {
+			var body, err = base64.StdEncoding.DecodeString(e.Response.Content.Text)
+			if err != nil {
+				return nil, fmt.Errorf("har: entry %d: %w", i, err)
+			}
+			entry.ResponseBody = body
+		}
+		if mt := e.Response.Content.MimeType; mt != "" && entry.ResponseHeader.Get("Content-Type") == "" {
+			entry.ResponseHeader.Set("Content-Type", mt)
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+// Returns entries of a traffic log of JSON objects, one per line:
+//
+//	{"method": "POST", "url": "https://example.com/pets",
+//	 "requestHeaders": {"Content-Type": "application/json"},
+//	 "requestBody": {"name": "Rex"}, "status": 201,
+//	 "responseHeaders": {"Content-Type": "application/json"},
+//	 "responseBody": {"id": 1, "name": "Rex"}}
+//
+// Bodies are JSON values, or strings holding the body text. Empty lines are
+// skipped.
+func ParseTrafficLog(data []byte) ([]*TrafficEntry, error) {
+	var (
+		result  []*TrafficEntry
+		scanner = bufio.NewScanner(bytes.NewReader(data))
+		line    int
+	)
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var record struct {
+			Method          string            `json:"method"`
+			URL             string            `json:"url"`
+			RequestHeaders  map[string]string `json:"requestHeaders"`
+			RequestBody     json.RawMessage   `json:"requestBody"`
+			Status          int               `json:"status"`
+			ResponseHeaders map[string]string `json:"responseHeaders"`
+			ResponseBody    json.RawMessage   `json:"responseBody"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if record.Method == "" || record.URL == "" {
+			return nil, fmt.Errorf("line %d: method and url are required", line)
+		}
+		var entry = &TrafficEntry{
+			Method:         record.Method,
+			URL:            record.URL,
+			RequestHeader:  make(http.Header),
+			RequestBody:    logBody(record.RequestBody),
+			Status:         record.Status,
+			ResponseHeader: make(http.Header),
+			ResponseBody:   logBody(record.ResponseBody),
+		}
+		for name, value := range record.RequestHeaders {
+			entry.RequestHeader.Set(name, value)
+		}
+		for name, value := range record.ResponseHeaders {
+			entry.ResponseHeader.Set(name, value)
+		}
+		result = append(result, entry)
+	}
+	return result, scanner.Err()
+}
+
+// Returns body of a traffic log record which is a JSON value or a string
+// holding the body text.
+func logBody(raw json.RawMessage) []byte {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return []byte(text)
+	}
+	if bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+	return raw
+}
+
+// InferFromTraffic returns a draft document with title and version inferred
+// from observed traffic entries.
+//
+// Path segments that look like identifiers, i.e. numbers, UUIDs, long hex
+// strings or long tokens mixing letters and digits, are replaced by template
+// expressions named after the preceding segment, e.g. "/users/42" is
+// "/users/{userId}". Entries of the same method and templated path are
+// merged into an operation.
+//
+// Path, query and X- prefixed header parameters are inferred from their
+// observed values, and are required if present in every request. JSON and
+// form request and response bodies are described by schemas inferred from
+// all observed bodies as by InferSchema. Responses are defined per observed
+// status code and content type. Origins of absolute URLs are servers in order
+// of appearance.
+//
+// Entries of methods other than Methods, such as WebDAV methods, are skipped
+// and the document is returned with a note for each of them whose Pointer is
+// the index of the entry, e.g. "/3".
+func InferFromTraffic(title, version string, entries []*TrafficEntry) (*OpenAPI, []*ConversionNote, error) {
+	var (
+		b       = New(title, version)
+		c       = &converter{}
+		origins = make(map[string]bool)
+		ops     = make(map[string]*trafficOperation)
+		order   []*trafficOperation
+	)
+	for i, entry := range entries {
+		var u, err = url.Parse(entry.URL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		if !containsString(Methods, strings.ToUpper(entry.Method)) {
+			c.note(fmt.Sprintf("/%d", i), "unsupported method '%s'; entry skipped", entry.Method)
+			continue
+		}
+		if u.Scheme != "" && u.Host != "" {
+			var origin = u.Scheme + "://" + u.Host
+			if !origins[origin] {
+				origins[origin] = true
+				b.Server(origin, "")
+			}
+		}
+		var (
+			path, values = templatePath(u.Path)
+			method       = strings.ToUpper(entry.Method)
+			key          = method + " " + path
+			op           = ops[key]
+		)
+		if op == nil {
+			op = newTrafficOperation(method, path)
+			ops[key] = op
+			order = append(order, op)
+		}
+		op.observe(entry, u.Query(), values)
+	}
+	for _, op := range order {
+		var pb = b.Path(op.path)
+		for _, p := range op.pathParams {
+			if !hasParameter(pb.item.Parameters, p.name, "path") {
+				pb.item.Parameters = append(pb.item.Parameters, p.parameter(op.count))
+			}
+		}
+		pb.Method(op.method, op.build)
+	}
+	var doc, err = b.Build()
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, c.notes, nil
+}
+
+var (
+	hexSegment   = regexp.MustCompile(`^[0-9a-fA-F]{8,}$`)
+	tokenSegment = regexp.MustCompile(`^[0-9A-Za-z_-]{16,}$`)
+)
+
+// Returns true if path segment s looks like an identifier.
+func isIDSegment(s string) bool {
+	if s == "" {
+		return false
+	}
+	if _, err := strconv.ParseUint(s, 10, 64); err == nil || uuidPattern.MatchString(s) {
+		return true
+	}
+	var (
+		digits  = strings.IndexAny(s, "0123456789") >= 0
+		letters = strings.IndexFunc(s, func(r rune) bool { return r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' }) >= 0
+	)
+	return digits && (hexSegment.MatchString(s) || letters && tokenSegment.MatchString(s))
+}
+
+// Returns path with identifier segments replaced by template expressions and
+// the values of the replaced segments in path order.
+func templatePath(path string) (string, []string) {
+	var (
+		segments = strings.Split(strings.Trim(path, "/"), "/")
+		names    = make(map[string]bool)
+		values   []string
+	)
+	for i, segment := range segments {
+		if !isIDSegment(segment) {
+			continue
+		}
+		var name = "id"
+		if i > 0 && !strings.HasPrefix(segments[i-1], "{") {
+			name = lowerIdent(singular(segments[i-1])) + "Id"
+		}
+		name = uniqueName(name, names)
+		names[name] = true
+		segments[i] = "{" + name + "}"
+		values = append(values, segment)
+	}
+	return "/" + strings.Join(segments, "/"), values
+}
+
+// Returns the singular of English plural noun s, e.g. "user" for "users".
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies") && len(s) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss"):
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+// trafficOperation accumulates observations of an operation.
+type trafficOperation struct {
+	method, path string
+	// count is the number of observed requests.
+	count      int
+	pathParams []*trafficParam
+	params     []*trafficParam
+	byKey      map[string]*trafficParam
+	// bodies is the number of requests with a body.
+	bodies    int
+	request   *trafficContent
+	responses map[int]*trafficContent
+}
+
+// trafficParam accumulates observed values of a parameter.
+type trafficParam struct {
+	name, in string
+	// count is the number of requests the parameter was present in.
+	count  int
+	values []string
+	// multi is true if the parameter was repeated in a request.
+	multi bool
+}
+
+// trafficContent accumulates observed bodies by media type.
+type trafficContent struct {
+	samples map[string][]interface{}
+}
+
+// Returns a new trafficOperation of method and templated path.
+func newTrafficOperation(method, path string) *trafficOperation {
+	var op = &trafficOperation{
+		method:    method,
+		path:      path,
+		byKey:     make(map[string]*trafficParam),
+		request:   &trafficContent{samples: make(map[string][]interface{})},
+		responses: make(map[int]*trafficContent),
+	}
+	for _, m := range templateExpression.FindAllStringSubmatch(path, -1) {
+		op.pathParams = append(op.pathParams, &trafficParam{name: m[1], in: "path"})
+	}
+	return op
+}
+
+// Returns the parameter of name and location, adding it if it was not
+// observed yet.
+func (op *trafficOperation) param(name, in string) *trafficParam {
+	var key = in + ":" + name
+	var p = op.byKey[key]
+	if p == nil {
+		p = &trafficParam{name: name, in: in}
+		op.byKey[key] = p
+		op.params = append(op.params, p)
+	}
+	return p
+}
+
+// Records entry with query and values of path parameters.
+func (op *trafficOperation) observe(entry *TrafficEntry, query url.Values, values []string) {
+	op.count++
+	for i, value := range values {
+		op.pathParams[i].count++
+		op.pathParams[i].values = append(op.pathParams[i].values, value)
+	}
+	var names = make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var p = op.param(name, "query")
+		p.count++
+		p.values = append(p.values, query[name]...)
+		p.multi = p.multi || len(query[name]) > 1
+	}
+	names = names[:0]
+	for name := range entry.RequestHeader {
+		if strings.HasPrefix(strings.ToLower(name), "x-") {
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var p = op.param(name, "header")
+		p.count++
+		p.values = append(p.values, entry.RequestHeader.Values(name)...)
+	}
+	if len(entry.RequestBody) > 0 {
+		op.bodies++
+		op.request.observe(entry.RequestHeader.Get("Content-Type"), entry.RequestBody)
+	}
+	if entry.Status > 0 {
+		var resp = op.responses[entry.Status]
+		if resp == nil {
+			resp = &trafficContent{samples: make(map[string][]interface{})}
+			op.responses[entry.Status] = resp
+		}
+		if len(entry.ResponseBody) > 0 {
+			resp.observe(entry.ResponseHeader.Get("Content-Type"), entry.ResponseBody)
+		}
+	}
+}
+
+// Builds the operation from observations.
+func (op *trafficOperation) build(ob *OperationBuilder) {
+	var o = ob.Operation()
+	for _, p := range op.params {
+		o.Parameters = append(o.Parameters, p.parameter(op.count))
+	}
+	if op.bodies > 0 {
+		o.RequestBody = &RequestBody{
+			Content:  op.request.content(),
+			Required: op.bodies == op.count,
+		}
+	}
+	if len(op.responses) == 0 {
+		return
+	}
+	o.Responses = &Responses{Codes: make(map[string]interface{})}
+	for status, c := range op.responses {
+		var description = http.StatusText(status)
+		if description == "" {
+			description = "Status " + strconv.Itoa(status)
+		}
+		var resp = &Response{Description: description}
+		if len(c.samples) > 0 {
+			resp.Content = c.content()
+		}
+		o.Responses.Codes[strconv.Itoa(status)] = resp
+	}
+}
+
+// Returns the parameter of observations of an operation observed count
+// times.
+func (p *trafficParam) parameter(count int) *Parameter {
+	var (
+		schema = valueSchema(p.values)
+		result = &Parameter{Name: p.name, In: p.in, Required: p.in == "path" || p.count == count}
+	)
+	if p.multi {
+		schema = &Schema{Type: TypeArray, Items: schema}
+	}
+	result.Schema = schema
+	return result
+}
+
+// Returns a schema of string values of parameters: integer, number or
+// boolean if all values are, otherwise a string with uuid format if all
+// values are UUIDs.
+func valueSchema(values []string) *Schema {
+	var ints, numbers, bools, uuids = true, true, true, true
+	for _, v := range values {
+		if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+			ints = false
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			numbers = false
+		}
+		if v != "true" && v != "false" {
+			bools = false
+		}
+		if !uuidPattern.MatchString(v) {
+			uuids = false
+		}
+	}
+	switch {
+	case len(values) == 0:
+		return &Schema{Type: TypeString}
+	case ints:
+		return &Schema{Type: TypeInteger}
+	case numbers:
+		return &Schema{Type: TypeNumber}
+	case bools:
+		return &Schema{Type: TypeBoolean}
+	case uuids:
+		return &Schema{Type: TypeString, Format: "uuid"}
+	}
+	return &Schema{Type: TypeString}
+}
+
+// Records body of content type ct. JSON and form bodies are decoded as
+// samples of their schema.
+func (c *trafficContent) observe(ct string, body []byte) {
+	var mediaType, _, err = mime.ParseMediaType(ct)
+	if err != nil || mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	var samples = c.samples[mediaType]
+	switch {
+	case isJSONMediaType(mediaType):
+		var v interface{}
+		if json.Unmarshal(body, &v) == nil {
+			samples = append(samples, v)
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			var form = make(map[string]interface{})
+			for name := range values {
+				form[name] = values.Get(name)
+			}
+			samples = append(samples, form)
+		}
+	}
+	c.samples[mediaType] = samples
+}
+
+// Returns content of observed bodies. Media types without decoded samples
+// have no schema.
+func (c *trafficContent) content() map[string]*MediaType {
+	var result = make(map[string]*MediaType)
+	for mediaType, samples := range c.samples {
+		var mt = &MediaType{}
+		if len(samples) > 0 {
+			mt.Schema = inferSchema(samples)
+		}
+		result[mediaType] = mt
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"testing"
+)
+
+func TestInferFromTraffic(t *testing.T) {
+	var entries, err = ParseTrafficLog([]byte(`
+{"method": "GET", "url": "https://api.example.com/users/42?verbose=true", "status": 200, "responseHeaders": {"Content-Type": "application/json"}, "responseBody": {"id": 42, "name": "Ann", "email": "ann@example.com"}}
+{"method": "GET", "url": "https://api.example.com/users/7", "status": 200, "responseHeaders": {"Content-Type": "application/json"}, "responseBody": {"id": 7, "name": "Bob", "score": 1.5}}
+{"method": "GET", "url": "https://api.example.com/users/9", "status": 404, "responseHeaders": {"Content-Type": "text/plain"}, "responseBody": "not found"}
+
+{"method": "POST", "url": "https://api.example.com/users", "requestHeaders": {"Content-Type": "application/json", "X-Request-Id": "a1"}, "requestBody": {"name": "Cy"}, "status": 201}
+{"method": "PROPFIND", "url": "https://api.example.com/users/1", "status": 207}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	har, err := ParseHAR([]byte(`{"log": {"entries": [{
+		"request": {"method": "GET", "url": "https://api.example.com/users/3f2504e0-4f89-11d3-9a0c-0305e82c3301/posts/12", "headers": []},
+		"response": {"status": 200, "headers": [], "content": {"mimeType": "application/json", "text": "W10=", "encoding": "base64"}}
+	}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, notes, err := InferFromTraffic("Users", "1.0", append(entries, har...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || notes[0].String() != "#/4: unsupported method 'PROPFIND'; entry skipped" {
+		t.Errorf("unexpected notes %v", notes)
+	}
+	var (
+		user  = doc.Paths.Items["/users/{userId}"]
+		users = doc.Paths.Items["/users"]
+		posts = doc.Paths.Items["/users/{userId}/posts/{postId}"]
+	)
+	if user == nil || users == nil || posts == nil {
+		t.Fatalf("unexpected paths %v", doc.Paths.Keys())
+	}
+	var checks = []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"servers", doc.Servers, `[{"url":"https://api.example.com"}]`},
+		{"user params", user.Parameters, `[{"name":"userId","in":"path","required":true,"schema":{"type":"integer"}}]`},
+		{"get user", user.Get, `{"operationId":"getUsersByUserId",` +
+			`"parameters":[{"name":"verbose","in":"query","schema":{"type":"boolean"}}],` +
+			`"responses":{"200":{"description":"OK","content":{"application/json":{"schema":{"properties":{` +
+			`"email":{"type":"string","format":"email"},"id":{"type":"integer"},"name":{"type":"string"},"score":{"type":"number"}},` +
+			`"type":"object","required":["id","name"]}}}},` +
+			`"404":{"description":"Not Found","content":{"text/plain":{}}}}}`},
+		{"post users", users.Post, `{"operationId":"postUsers",` +
+			`"parameters":[{"name":"X-Request-Id","in":"header","required":true,"schema":{"type":"string"}}],` +
+			`"requestBody":{"content":{"application/json":{"schema":{"properties":{"name":{"type":"string"}},` +
+			`"type":"object","required":["name"]}}},"required":true},` +
+			`"responses":{"201":{"description":"Created"}}}`},
+		{"posts params", posts.Parameters, `[{"name":"userId","in":"path","required":true,"schema":{"type":"string","format":"uuid"}},` +
+			`{"name":"postId","in":"path","required":true,"schema":{"type":"integer"}}]`},
+		{"posts response", posts.Get.Responses.Codes["200"], `{"description":"OK","content":{"application/json":{"schema":{"type":"array"}}}}`},
+	}
+	for _, check := range checks {
+		if got := jsonString(t, check.v); got != check.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", check.name, got, check.want)
+		}
+	}
+}
@@ -0,0 +1,1196 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// generatedTSHeader is the first line of generated TypeScript files.
+const generatedTSHeader = "// Code generated by openapi. DO NOT EDIT.\n\n"
+
+// GenerateTypeScriptTypes generates a TypeScript module that exports a type
+// for each schema in doc Components.Schemas.
+//
+// Schemas are mapped to TypeScript types as follows:
+//
+//   - Objects with properties are interfaces with JSDoc comments from their
+//     descriptions. Properties that are not required are optional.
+//   - Objects without properties are Records of additionalProperties.
+//   - oneOf and anyOf schemas are union types. Variants of a oneOf schema
+//     with a Discriminator are intersected with an object type of their
+//     discriminator property value, forming a discriminated union.
+//   - allOf schemas are intersection types.
+//   - Enums and consts are unions of literal types.
+//   - Nullable schemas and schemas with a "null" type include null.
+func GenerateTypeScriptTypes(doc *OpenAPI) ([]byte, error) {
+	var g = newTSGenerator(doc)
+	if err := g.declareComponents(); err != nil {
+		return nil, err
+	}
+	return g.source(), nil
+}
+
+// GenerateTypeScriptClient generates a TypeScript module that exports the
+// types of GenerateTypeScriptTypes and a fetch based client of operations
+// defined in doc.
+//
+// The module exports:
+//
+//   - A <Operation>Params interface for each operation with parameters,
+//     keyed by parameter name.
+//   - A <Operation>Response union of ApiResponse types of each response
+//     status code, holding the decoded body in data.
+//   - A Client class with a method for each operation named from its
+//     OperationID that serializes parameters according to their style and
+//     explode values, applies credentials of the first satisfied security
+//     requirement and throws an ApiError for undefined responses.
+//   - Servers defined by the document and serverURL which expands server
+//     variables.
+//   - A Credentials interface of security schemes in Components.
+func GenerateTypeScriptClient(doc *OpenAPI) ([]byte, error) {
+	var g = newTSGenerator(doc,
+		"ApiError", "ApiResponse", "Client", "ClientOptions", "Credentials",
+		"Server", "ServerVariable", "ParamSpec", "RequestSpec", "SecuritySchemeInfo")
+	if err := g.declareComponents(); err != nil {
+		return nil, err
+	}
+	ops, err := g.operations()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		if err = g.declareOperationTypes(op); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.Method, op.Path, err)
+		}
+	}
+	g.declareServers()
+	if err = g.declareSecurity(); err != nil {
+		return nil, err
+	}
+	g.printf("%s", tsRuntime)
+	for _, op := range ops {
+		g.declareClientMethod(op)
+	}
+	g.printf("}\n")
+	return g.source(), nil
+}
+
+// tsGenerator generates TypeScript source code for schemas and operations
+// of a document.
+type tsGenerator struct {
+	doc *OpenAPI
+	out strings.Builder
+	// types maps component schema names to TypeScript type names.
+	types map[string]string
+	// idents are declared module level identifiers.
+	idents map[string]bool
+}
+
+// Returns a new tsGenerator for doc. Reserved identifiers are not used for
+// generated types.
+func newTSGenerator(doc *OpenAPI, reserved ...string) *tsGenerator {
+	var g = &tsGenerator{
+		doc:    doc,
+		types:  make(map[string]string),
+		idents: make(map[string]bool),
+	}
+	for _, ident := range tsGlobals {
+		g.idents[ident] = true
+	}
+	for _, ident := range reserved {
+		g.idents[ident] = true
+	}
+	return g
+}
+
+// tsGlobals are global TypeScript names used by generated code that
+// generated types must not shadow.
+var tsGlobals = []string{
+	"Array", "Blob", "BodyInit", "Error", "FormData", "Headers", "Promise",
+	"Record", "RequestInit", "Response", "URLSearchParams",
+}
+
+// Returns a unique module level identifier derived from name. Names of
+// TypeScript globals are suffixed with "Model".
+func (g *tsGenerator) ident(name string) string {
+	var base = goIdent(name)
+	if containsString(tsGlobals, base) {
+		base += "Model"
+	}
+	var result = uniqueName(base, g.idents)
+	g.idents[result] = true
+	return result
+}
+
+// Writes formatted code to the output.
+func (g *tsGenerator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.out, format, args...)
+}
+
+// Returns the generated source.
+func (g *tsGenerator) source() []byte {
+	return []byte(generatedTSHeader + strings.TrimRight(g.out.String(), "\n") + "\n")
+}
+
+// Returns component schema names in order.
+func (g *tsGenerator) componentNames() []string {
+	if g.doc.Components == nil {
+		return nil
+	}
+	var names = make([]string, 0, len(g.doc.Components.Schemas))
+	for name := range g.doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Declares types of all component schemas in name order.
+func (g *tsGenerator) declareComponents() error {
+	var names = g.componentNames()
+	for _, name := range names {
+		g.types[name] = g.ident(name)
+	}
+	for _, name := range names {
+		var s, err = g.schema(g.doc.Components.Schemas[name])
+		if err != nil {
+			return fmt.Errorf("schema '%s': %w", name, err)
+		}
+		if s == nil {
+			s = &Schema{}
+		}
+		if err = g.declareSchema(g.types[name], s); err != nil {
+			return fmt.Errorf("schema '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Converts v holding Schema Object | Reference Object to a Schema without
+// following references. Returns nil if v is nil.
+func (g *tsGenerator) schema(v interface{}) (*Schema, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case *Schema:
+		return t, nil
+	}
+	var result = &Schema{}
+	if err := remarshal(v, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Declares a type named name for s, an interface if s is an object with
+// properties or a type alias otherwise.
+func (g *tsGenerator) declareSchema(name string, s *Schema) error {
+	var text = s.Description
+	if text == "" {
+		text = s.Title
+	}
+	var doc = tsComment(text, s.Deprecated, "")
+	if g.isInterface(s) {
+		var body, err = g.objectBody(s)
+		if err != nil {
+			return err
+		}
+		g.printf("%sexport interface %s {\n%s}\n\n", doc, name, body)
+		return nil
+	}
+	var t, err = g.typeOf(s)
+	if err != nil {
+		return err
+	}
+	g.printf("%sexport type %s = %s;\n\n", doc, name, t)
+	return nil
+}
+
+// Returns true if s is declared as an interface.
+func (g *tsGenerator) isInterface(s *Schema) bool {
+	if s.IsNullable() || s.Ref != "" || len(s.AllOf) > 0 || len(s.OneOf) > 0 ||
+		len(s.AnyOf) > 0 || len(s.Enum) > 0 || s.Const != nil {
+		return false
+	}
+	var kind = schemaGoKind(s)
+	return (kind == TypeObject || kind == "") && len(s.Properties) > 0
+}
+
+// Returns the TypeScript type of schema v which may be a Schema Object or a
+// Reference Object.
+func (g *tsGenerator) typeOf(v interface{}) (string, error) {
+	var s, err = g.schema(v)
+	if err != nil || s == nil {
+		return "unknown", err
+	}
+	if s.Ref != "" {
+		if name := componentName(s.Ref, "schemas"); name != "" {
+			if t, ok := g.types[name]; ok {
+				return t, nil
+			}
+		}
+		var target *Schema
+		if err = g.doc.resolve(s, &target); err != nil {
+			return "", err
+		}
+		return g.typeOf(target)
+	}
+	var (
+		variants = unionVariants(s)
+		nullable = s.IsNullable() || len(variants) < len(s.OneOf)
+		t        string
+	)
+	switch {
+	case s.Const != nil:
+		t, err = tsLiteral(s.Const)
+	case len(s.Enum) > 0:
+		var literals []string
+		for _, v := range s.Enum {
+			if v == nil {
+				nullable = true
+				continue
+			}
+			var l, err = tsLiteral(v)
+			if err != nil {
+				return "", err
+			}
+			literals = appendUnique(literals, l)
+		}
+		t = strings.Join(literals, " | ")
+	case len(variants) > 0:
+		t, err = g.unionType(s, variants)
+	case len(s.AnyOf) > 0:
+		t, err = g.unionType(s, s.AnyOf)
+	case len(s.AllOf) > 0:
+		var parts []string
+		for _, sub := range s.AllOf {
+			var part, err = g.typeOf(sub)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, tsParen(part))
+		}
+		if len(s.Properties) > 0 {
+			var body, err = g.objectBody(s)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, "{\n"+body+"}")
+		}
+		t = strings.Join(parts, " & ")
+	default:
+		t, err = g.kindType(s)
+	}
+	if err != nil {
+		return "", err
+	}
+	if t == "" {
+		t = "never"
+	}
+	if nullable && t != "unknown" && !strings.HasSuffix(t, " | null") && t != "null" {
+		t += " | null"
+	}
+	return t, nil
+}
+
+// Returns the union type of variants of s. If s has a Discriminator each
+// referenced variant is intersected with its discriminator property values.
+func (g *tsGenerator) unionType(s *Schema, variants []*Schema) (string, error) {
+	var values map[string][]string
+	if d := s.Discriminator; d != nil && d.PropertyName != "" && len(s.OneOf) > 0 {
+		values = make(map[string][]string)
+		var keys = make([]string, 0, len(d.Mapping))
+		for value := range d.Mapping {
+			keys = append(keys, value)
+		}
+		sort.Strings(keys)
+		for _, value := range keys {
+			var ref = d.Mapping[value]
+			if !strings.Contains(ref, "#") && !strings.Contains(ref, "/") {
+				ref = componentsPrefix + "schemas/" + escapePointer(ref)
+			}
+			values[ref] = append(values[ref], value)
+		}
+	}
+	var types []string
+	for _, variant := range variants {
+		var t, err = g.typeOf(variant)
+		if err != nil {
+			return "", err
+		}
+		if values != nil && variant != nil && variant.Ref != "" {
+			var literals = values[variant.Ref]
+			if literals == nil {
+				literals = []string{componentName(variant.Ref, "schemas")}
+			}
+			var quoted []string
+			for _, l := range literals {
+				if l != "" {
+					var literal, _ = tsLiteral(l)
+					quoted = append(quoted, literal)
+				}
+			}
+			if len(quoted) > 0 {
+				t = fmt.Sprintf("(%s & { %s: %s })", tsParen(t), tsPropertyName(s.Discriminator.PropertyName), strings.Join(quoted, " | "))
+			}
+		}
+		types = appendUnique(types, t)
+	}
+	return strings.Join(types, " | "), nil
+}
+
+// Returns the type of s by its JSON types.
+func (g *tsGenerator) kindType(s *Schema) (string, error) {
+	var kinds = s.Types()
+	if len(kinds) == 0 {
+		if kind := schemaGoKind(s); kind != "" {
+			kinds = []string{kind}
+		} else if len(s.Properties) > 0 {
+			kinds = []string{TypeObject}
+		}
+	}
+	if len(kinds) == 0 {
+		return "unknown", nil
+	}
+	var types []string
+	for _, kind := range kinds {
+		var t string
+		switch kind {
+		case TypeString:
+			t = "string"
+		case TypeInteger, TypeNumber:
+			t = "number"
+		case TypeBoolean:
+			t = "boolean"
+		case TypeNull:
+			t = "null"
+		case TypeArray:
+			if len(s.PrefixItems) > 0 {
+				var items []string
+				for _, item := range s.PrefixItems {
+					var it, err = g.typeOf(item)
+					if err != nil {
+						return "", err
+					}
+					items = append(items, it)
+				}
+				t = "[" + strings.Join(items, ", ") + "]"
+				break
+			}
+			var item, err = g.typeOf(s.Items)
+			if err != nil {
+				return "", err
+			}
+			t = tsParen(item) + "[]"
+		case TypeObject:
+			if len(s.Properties) == 0 {
+				var value, allowed = s.AdditionalPropertiesSchema()
+				if !allowed {
+					t = "Record<string, never>"
+					break
+				}
+				var vt, err = g.typeOf(value)
+				if err != nil {
+					return "", err
+				}
+				t = "Record<string, " + vt + ">"
+				break
+			}
+			var body, err = g.objectBody(s)
+			if err != nil {
+				return "", err
+			}
+			t = "{\n" + body + "}"
+		default:
+			t = "unknown"
+		}
+		types = appendUnique(types, t)
+	}
+	return strings.Join(types, " | "), nil
+}
+
+// Returns indented members of an object type of s. Properties that are not
+// required are optional.
+func (g *tsGenerator) objectBody(s *Schema) (string, error) {
+	var (
+		required = make(map[string]bool)
+		keys     = make([]string, 0, len(s.Properties))
+		sb       strings.Builder
+	)
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	for key := range s.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var prop = s.Properties[key]
+		var t, err = g.typeOf(prop)
+		if err != nil {
+			return "", fmt.Errorf("property '%s': %w", key, err)
+		}
+		var optional = "?"
+		if required[key] {
+			optional = ""
+		}
+		var readonly string
+		if prop != nil {
+			sb.WriteString(tsComment(prop.Description, prop.Deprecated, "  "))
+			if prop.ReadOnly {
+				readonly = "readonly "
+			}
+		}
+		fmt.Fprintf(&sb, "  %s%s%s: %s;\n", readonly, tsPropertyName(key), optional, tsIndent(t, "  "))
+	}
+	if value, allowed := s.AdditionalPropertiesSchema(); allowed && value != nil {
+		sb.WriteString("  [key: string]: unknown;\n")
+	}
+	return sb.String(), nil
+}
+
+// Returns t with lines after the first prefixed with indent.
+func tsIndent(t, indent string) string {
+	return strings.ReplaceAll(t, "\n", "\n"+indent)
+}
+
+// Returns t in parentheses if it is a union or intersection type.
+func tsParen(t string) string {
+	if strings.Contains(t, " | ") || strings.Contains(t, " & ") {
+		return "(" + t + ")"
+	}
+	return t
+}
+
+// Returns the TypeScript literal type of a JSON value.
+func tsLiteral(v interface{}) (string, error) {
+	var b, err = json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// tsIdentifier matches TypeScript identifiers that need not be quoted.
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// Returns name as an object type property name, quoted if needed.
+func tsPropertyName(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("%q", name)
+}
+
+// Returns text as a JSDoc comment with each line prefixed with indent, or an
+// empty string if text is empty and not deprecated.
+func tsComment(text string, deprecated bool, indent string) string {
+	var lines []string
+	if text = strings.TrimSpace(text); text != "" {
+		lines = strings.Split(strings.ReplaceAll(text, "*/", "*\\/"), "\n")
+	}
+	if deprecated {
+		lines = append(lines, "@deprecated")
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	if len(lines) == 1 {
+		return indent + "/** " + lines[0] + " */\n"
+	}
+	var sb strings.Builder
+	sb.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			sb.WriteString(indent + " *\n")
+			continue
+		}
+		sb.WriteString(indent + " * " + line + "\n")
+	}
+	sb.WriteString(indent + " */\n")
+	return sb.String()
+}
+
+// Returns an array literal of strings a.
+func tsStrings(a []string) string {
+	var literals = make([]string, len(a))
+	for i, s := range a {
+		literals[i], _ = tsLiteral(s)
+	}
+	return "[" + strings.Join(literals, ", ") + "]"
+}
+
+// Returns a with s appended if a does not contain s.
+func appendUnique(a []string, s string) []string {
+	if containsString(a, s) {
+		return a
+	}
+	return append(a, s)
+}
+
+// tsOperation describes an operation for which a client method is
+// generated.
+type tsOperation struct {
+	// Name is the client method name.
+	Name      string
+	Method    string
+	Path      string
+	Operation *Operation
+	Params    []*tsParam
+	// ParamsType is the name of the params interface or an empty string if
+	// the operation has no parameters.
+	ParamsType string
+	// Body is the request body or nil if none.
+	Body *tsBody
+	// Responses are responses in key order, with default last.
+	Responses []*tsResponse
+	// ResponseType is the name of the response union type.
+	ResponseType string
+	// Security are requirements of the operation as lists of scheme names.
+	Security [][]string
+}
+
+// tsParam is a parameter of a tsOperation.
+type tsParam struct {
+	*Parameter
+	// Type is the TypeScript type of the parameter.
+	Type string
+	// Style is the parameter style, or "content" if the parameter is
+	// serialized as content of a media type.
+	Style   string
+	Explode bool
+	// Required is true for required and path parameters.
+	Required bool
+}
+
+// tsBody is a request body of a tsOperation.
+type tsBody struct {
+	ContentType string
+	Type        string
+	Required    bool
+}
+
+// tsResponse is a response of a tsOperation.
+type tsResponse struct {
+	// Code is the status code, a range such as "2XX" or "default".
+	Code string
+	// Kind is how the body is read: "json", "text", "blob" or an empty
+	// string if the response has no body.
+	Kind string
+	Type string
+}
+
+// Returns operations of the document in path and Methods order.
+func (g *tsGenerator) operations() ([]*tsOperation, error) {
+	if g.doc.Paths == nil {
+		return nil, nil
+	}
+	var (
+		result []*tsOperation
+		names  = make(map[string]bool)
+	)
+	for _, member := range tsClientMembers {
+		names[member] = true
+	}
+	for _, path := range g.doc.Paths.Keys() {
+		var item = g.doc.Paths.Items[path]
+		for _, method := range Methods {
+			var op = item.Operation(method)
+			if op == nil {
+				continue
+			}
+			var name = op.OperationID
+			if name == "" {
+				name = operationName(method, path)
+			}
+			if !tsIdentifier.MatchString(name) {
+				name = lowerIdent(name)
+			}
+			var top = &tsOperation{
+				Name:      uniqueName(name, names),
+				Method:    method,
+				Path:      path,
+				Operation: op,
+			}
+			names[top.Name] = true
+			if err := g.operation(top, item); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			result = append(result, top)
+		}
+	}
+	return result, nil
+}
+
+// Fills params, body, responses and security of op.
+func (g *tsGenerator) operation(op *tsOperation, item *PathItem) error {
+	var params, err = g.doc.operationParameters(item, op.Operation)
+	if err != nil {
+		return err
+	}
+	for _, p := range params {
+		var tp = &tsParam{Parameter: p, Required: p.Required || p.In == "path"}
+		tp.Style, tp.Explode = p.SerializationStyle()
+		var schema = p.Schema
+		if len(p.Content) > 0 {
+			var key = sortedContentKeys(p.Content)[0]
+			schema, tp.Style = p.Content[key].Schema, "content"
+		}
+		if tp.Type, err = g.typeOf(schema); err != nil {
+			return fmt.Errorf("parameter '%s': %w", p.Name, err)
+		}
+		op.Params = append(op.Params, tp)
+	}
+	if op.Operation.RequestBody != nil {
+		var rb *RequestBody
+		if err = g.doc.resolve(op.Operation.RequestBody, &rb); err != nil {
+			return err
+		}
+		if key, mt := preferredContent(rb.Content); mt != nil {
+			op.Body = &tsBody{ContentType: key, Required: rb.Required}
+			switch {
+			case isJSONMediaType(key):
+				if op.Body.Type, err = g.typeOf(mt.Schema); err != nil {
+					return fmt.Errorf("request body: %w", err)
+				}
+			case key == "application/x-www-form-urlencoded":
+				op.Body.Type = "Record<string, string> | URLSearchParams"
+			case strings.HasPrefix(key, "multipart/"):
+				op.Body.Type = "Record<string, string | Blob> | FormData"
+			default:
+				op.Body.Type = "BodyInit"
+			}
+		}
+	}
+	if op.Operation.Responses != nil {
+		var keys = op.Operation.Responses.Keys()
+		if op.Operation.Responses.Default != nil {
+			keys = append(keys, "default")
+		}
+		for _, key := range keys {
+			var v interface{}
+			if key == "default" {
+				v = op.Operation.Responses.Default
+			} else {
+				v = op.Operation.Responses.Codes[key]
+			}
+			var resp *Response
+			if err = g.doc.resolve(v, &resp); err != nil {
+				return err
+			}
+			var tr = &tsResponse{Code: strings.ToUpper(key), Type: "undefined"}
+			if key == "default" {
+				tr.Code = key
+			}
+			if ct, mt := preferredContent(resp.Content); mt != nil {
+				switch {
+				case isJSONMediaType(ct):
+					tr.Kind = "json"
+					if tr.Type, err = g.typeOf(mt.Schema); err != nil {
+						return fmt.Errorf("response '%s': %w", key, err)
+					}
+				case strings.HasPrefix(ct, "text/"):
+					tr.Kind, tr.Type = "text", "string"
+				default:
+					tr.Kind, tr.Type = "blob", "Blob"
+				}
+			}
+			op.Responses = append(op.Responses, tr)
+		}
+	}
+	var security = g.doc.Security
+	if op.Operation.Security != nil {
+		security = op.Operation.Security
+	}
+	for _, req := range security {
+		var schemes = make([]string, 0, len(req.Schemes))
+		for name := range req.Schemes {
+			schemes = append(schemes, name)
+		}
+		sort.Strings(schemes)
+		op.Security = append(op.Security, schemes)
+	}
+	return nil
+}
+
+// Declares the params interface and response type of op.
+func (g *tsGenerator) declareOperationTypes(op *tsOperation) error {
+	var base = goIdent(op.Name)
+	if len(op.Params) > 0 {
+		op.ParamsType = g.ident(base + "Params")
+		g.printf("/** Parameters of %s. */\nexport interface %s {\n", op.Name, op.ParamsType)
+		for _, p := range op.Params {
+			var optional = "?"
+			if p.Required {
+				optional = ""
+			}
+			var text = p.Description
+			if text == "" {
+				text = fmt.Sprintf("The '%s' %s parameter.", p.Name, p.In)
+			}
+			g.printf("%s  %s%s: %s;\n", tsComment(text, p.Deprecated, "  "), tsPropertyName(p.Name), optional, tsIndent(p.Type, "  "))
+		}
+		g.printf("}\n\n")
+	}
+	op.ResponseType = g.ident(base + "Response")
+	var types []string
+	for _, r := range op.Responses {
+		var status = "number"
+		if code := r.Code; len(code) == 3 && !strings.HasSuffix(code, "XX") {
+			status = code
+		}
+		types = append(types, fmt.Sprintf("ApiResponse<%s, %s>", status, tsIndent(r.Type, "  ")))
+	}
+	if len(types) == 0 {
+		types = append(types, "ApiResponse<number, unknown>")
+	}
+	g.printf("/** Responses of %s. */\nexport type %s =\n  | %s;\n\n", op.Name, op.ResponseType, strings.Join(types, "\n  | "))
+	return nil
+}
+
+// Declares servers of the document.
+func (g *tsGenerator) declareServers() {
+	g.printf("/** Servers of the API. */\nexport const servers: Server[] = [\n")
+	for _, server := range g.doc.Servers {
+		g.printf("  {\n    url: %q,\n", server.URL)
+		if server.Description != "" {
+			g.printf("    description: %q,\n", server.Description)
+		}
+		if len(server.Variables) > 0 {
+			var names = make([]string, 0, len(server.Variables))
+			for name := range server.Variables {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			g.printf("    variables: {\n")
+			for _, name := range names {
+				var v = server.Variables[name]
+				g.printf("      %s: { default: %q", tsPropertyName(name), v.Default)
+				if len(v.Enum) > 0 {
+					g.printf(", enum: %s", tsStrings(v.Enum))
+				}
+				g.printf(" },\n")
+			}
+			g.printf("    },\n")
+		}
+		g.printf("  },\n")
+	}
+	g.printf("];\n\n")
+}
+
+// Declares the Credentials interface and the securitySchemes table used by
+// the runtime to apply credentials.
+func (g *tsGenerator) declareSecurity() error {
+	var names []string
+	if g.doc.Components != nil {
+		for name := range g.doc.Components.SecuritySchemes {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var credentials, table strings.Builder
+	for _, name := range names {
+		var scheme *SecurityScheme
+		if err := g.doc.resolve(g.doc.Components.SecuritySchemes[name], &scheme); err != nil {
+			return fmt.Errorf("security scheme '%s': %w", name, err)
+		}
+		var (
+			t    = "string"
+			text string
+		)
+		switch scheme.Type {
+		case SecurityTypeAPIKey:
+			switch scheme.In {
+			case "header", "query", "cookie":
+			default:
+				return fmt.Errorf("security scheme '%s': invalid api key location '%s'", name, scheme.In)
+			}
+			text = fmt.Sprintf("API key sent in the '%s' %s.", scheme.Name, scheme.In)
+			fmt.Fprintf(&table, "  %s: { type: \"apiKey\", in: %q, name: %q },\n", tsPropertyName(name), scheme.In, scheme.Name)
+		case SecurityTypeHTTP:
+			if strings.EqualFold(scheme.Scheme, "basic") {
+				t, text = "{ username: string; password: string }", "Username and password of HTTP basic authentication."
+			} else {
+				text = fmt.Sprintf("Credentials of HTTP %s authentication.", scheme.Scheme)
+			}
+			fmt.Fprintf(&table, "  %s: { type: \"http\", scheme: %q },\n", tsPropertyName(name), scheme.Scheme)
+		case SecurityTypeOAuth2, SecurityTypeOpenIDConnect:
+			text = "Access token sent as a bearer token."
+			fmt.Fprintf(&table, "  %s: { type: \"http\", scheme: \"bearer\" },\n", tsPropertyName(name))
+		default:
+			continue
+		}
+		if scheme.Description != "" {
+			text = scheme.Description + "\n\n" + text
+		}
+		fmt.Fprintf(&credentials, "%s  %s?: %s;\n", tsComment(text, false, "  "), tsPropertyName(name), t)
+	}
+	g.printf("/** Credentials of security schemes. */\nexport interface Credentials {\n%s}\n\n", credentials.String())
+	g.printf("const securitySchemes: Record<string, SecuritySchemeInfo> = {\n%s};\n\n", table.String())
+	return nil
+}
+
+// Declares the client method of op.
+func (g *tsGenerator) declareClientMethod(op *tsOperation) {
+	var text = fmt.Sprintf("Sends a %s %s request.", op.Method, op.Path)
+	for _, s := range []string{op.Operation.Summary, op.Operation.Description} {
+		if s != "" {
+			text += "\n\n" + s
+		}
+	}
+	var (
+		args     []string
+		required = op.Body != nil && op.Body.Required
+	)
+	if op.ParamsType != "" {
+		var optional = " = {}"
+		for _, p := range op.Params {
+			if p.Required {
+				optional = ""
+			}
+		}
+		if required {
+			optional = ""
+		}
+		args = append(args, "params: "+op.ParamsType+optional)
+	}
+	if op.Body != nil {
+		var optional = "?"
+		if required {
+			optional = ""
+		}
+		args = append(args, "body"+optional+": "+tsIndent(op.Body.Type, "  "))
+	}
+	args = append(args, "init?: RequestInit")
+	g.printf("\n%s  async %s(%s): Promise<%s> {\n", tsComment(text, op.Operation.Deprecated, "  "), op.Name, strings.Join(args, ", "), op.ResponseType)
+	g.printf("    return (await this.request(\n      {\n        method: %q,\n        path: %q,\n        params: [", op.Method, op.Path)
+	for i, p := range op.Params {
+		if i == 0 {
+			g.printf("\n")
+		}
+		g.printf("          { name: %q, in: %q, style: %q, explode: %t, value: params[%q] },\n", p.Name, p.In, p.Style, p.Explode, p.Name)
+	}
+	if len(op.Params) > 0 {
+		g.printf("        ")
+	}
+	g.printf("],\n")
+	if op.Body != nil {
+		g.printf("        body,\n        contentType: %q,\n", op.Body.ContentType)
+	}
+	var security = make([]string, 0, len(op.Security))
+	for _, schemes := range op.Security {
+		security = append(security, tsStrings(schemes))
+	}
+	g.printf("        security: [%s],\n        responses: {", strings.Join(security, ", "))
+	for i, r := range op.Responses {
+		if i > 0 {
+			g.printf(",")
+		}
+		g.printf(" %q: %q", r.Code, r.Kind)
+	}
+	if len(op.Responses) > 0 {
+		g.printf(" ")
+	}
+	g.printf("},\n      },\n      init,\n    )) as %s;\n  }\n", op.ResponseType)
+}
+
+// tsClientMembers are members of the Client class of the runtime.
+var tsClientMembers = []string{
+	"constructor", "baseUrl", "credentials", "fetch", "init", "request", "applySecurity",
+}
+
+// tsRuntime is the runtime of generated TypeScript clients. It ends in the
+// body of the Client class that generated operation methods are added to.
+const tsRuntime = `/** A server variable. */
+export interface ServerVariable {
+  default: string;
+  enum?: string[];
+}
+
+/** A server of the API. */
+export interface Server {
+  url: string;
+  description?: string;
+  variables?: Record<string, ServerVariable>;
+}
+
+/**
+ * Returns the URL of server with variables substituted by values or their
+ * defaults. Throws if a value is not in the variable enum.
+ */
+export function serverURL(server: Server, values: Record<string, string> = {}): string {
+  return server.url.replace(/\{([^}]+)\}/g, (match: string, name: string) => {
+    const variable = server.variables?.[name];
+    if (variable === undefined) {
+      return match;
+    }
+    const value = values[name] ?? variable.default;
+    if (variable.enum !== undefined && !variable.enum.includes(value)) {
+      throw new Error(` + "`invalid value '${value}' of server variable '${name}'`" + `);
+    }
+    return value;
+  });
+}
+
+/** A response of an operation with status and decoded body. */
+export interface ApiResponse<S extends number, T> {
+  status: S;
+  data: T;
+  response: Response;
+}
+
+/** ApiError is thrown for responses the operation does not define. */
+export class ApiError extends Error {
+  constructor(
+    readonly response: Response,
+    readonly body: string,
+  ) {
+    super(` + "`unexpected response status ${response.status}`" + `);
+    this.name = "ApiError";
+  }
+}
+
+/** Options of a Client. */
+export interface ClientOptions {
+  /** Base URL of requests, the URL of the first server by default. */
+  baseUrl?: string;
+  /** Credentials of security schemes. */
+  credentials?: Credentials;
+  /** fetch implementation, globalThis.fetch by default. */
+  fetch?: typeof fetch;
+  /** Init merged into each request. */
+  init?: RequestInit;
+}
+
+interface SecuritySchemeInfo {
+  type: "apiKey" | "http";
+  in?: string;
+  name?: string;
+  scheme?: string;
+}
+
+interface ParamSpec {
+  name: string;
+  in: string;
+  style: string;
+  explode: boolean;
+  value: unknown;
+}
+
+interface RequestSpec {
+  method: string;
+  path: string;
+  params: ParamSpec[];
+  body?: unknown;
+  contentType?: string;
+  security: string[][];
+  responses: Record<string, string>;
+}
+
+function encode(value: unknown): string {
+  return encodeURIComponent(String(value));
+}
+
+function isObject(value: unknown): value is Record<string, unknown> {
+  return typeof value === "object" && value !== null && !Array.isArray(value);
+}
+
+// Returns value in simple style using encoder for names and values.
+function simple(value: unknown, explode: boolean, encoder: (v: unknown) => string): string {
+  if (Array.isArray(value)) {
+    return value.map(encoder).join(",");
+  }
+  if (isObject(value)) {
+    return Object.entries(value)
+      .map(([k, v]) => encoder(k) + (explode ? "=" : ",") + encoder(v))
+      .join(",");
+  }
+  return encoder(value);
+}
+
+function serializePath(p: ParamSpec): string {
+  if (p.style === "content") {
+    return encode(JSON.stringify(p.value));
+  }
+  const value = p.value;
+  if (p.style === "label") {
+    const sep = p.explode ? "." : ",";
+    if (Array.isArray(value)) {
+      return "." + value.map(encode).join(sep);
+    }
+    if (isObject(value)) {
+      const pairs = Object.entries(value).map(([k, v]) => encode(k) + (p.explode ? "=" : ",") + encode(v));
+      return "." + pairs.join(sep);
+    }
+    return "." + encode(value);
+  }
+  if (p.style === "matrix") {
+    const name = encode(p.name);
+    if (Array.isArray(value)) {
+      return p.explode
+        ? value.map((v) => ";" + name + "=" + encode(v)).join("")
+        : ";" + name + "=" + value.map(encode).join(",");
+    }
+    if (isObject(value)) {
+      return p.explode
+        ? Object.entries(value).map(([k, v]) => ";" + encode(k) + "=" + encode(v)).join("")
+        : ";" + name + "=" + simple(value, false, encode);
+    }
+    return ";" + name + "=" + encode(value);
+  }
+  return simple(value, p.explode, encode);
+}
+
+function serializeQuery(p: ParamSpec, query: string[]): void {
+  const name = encode(p.name);
+  const value = p.value;
+  if (p.style === "content") {
+    query.push(name + "=" + encode(JSON.stringify(value)));
+  } else if (Array.isArray(value)) {
+    if (p.explode) {
+      value.forEach((v) => query.push(name + "=" + encode(v)));
+    } else {
+      const sep = p.style === "spaceDelimited" ? "%20" : p.style === "pipeDelimited" ? "%7C" : ",";
+      query.push(name + "=" + value.map(encode).join(sep));
+    }
+  } else if (isObject(value)) {
+    if (p.style === "deepObject") {
+      Object.entries(value).forEach(([k, v]) => query.push(name + "%5B" + encode(k) + "%5D=" + encode(v)));
+    } else if (p.explode) {
+      Object.entries(value).forEach(([k, v]) => query.push(encode(k) + "=" + encode(v)));
+    } else {
+      query.push(name + "=" + simple(value, false, encode));
+    }
+  } else {
+    query.push(name + "=" + encode(value));
+  }
+}
+
+/** Client of the API. */
+export class Client {
+  private readonly baseUrl: string;
+  private readonly credentials: Credentials;
+  private readonly fetch: typeof fetch;
+  private readonly init: RequestInit;
+
+  constructor(options: ClientOptions = {}) {
+    this.baseUrl = (options.baseUrl ?? (servers.length > 0 ? serverURL(servers[0]) : "")).replace(/\/$/, "");
+    this.credentials = options.credentials ?? {};
+    this.fetch = options.fetch ?? globalThis.fetch.bind(globalThis);
+    this.init = options.init ?? {};
+  }
+
+  protected async request(spec: RequestSpec, init?: RequestInit): Promise<ApiResponse<number, unknown>> {
+    let path = spec.path;
+    const query: string[] = [];
+    const cookies: string[] = [];
+    const headers = new Headers(this.init.headers);
+    new Headers(init?.headers).forEach((value, key) => headers.set(key, value));
+    for (const p of spec.params) {
+      if (p.value === undefined) {
+        continue;
+      }
+      switch (p.in) {
+        case "path":
+          path = path.split("{" + p.name + "}").join(serializePath(p));
+          break;
+        case "query":
+          serializeQuery(p, query);
+          break;
+        case "header":
+          headers.set(p.name, p.style === "content" ? JSON.stringify(p.value) : simple(p.value, p.explode, String));
+          break;
+        case "cookie":
+          cookies.push(
+            p.name + "=" + (p.style === "content" ? encode(JSON.stringify(p.value)) : simple(p.value, p.explode, encode)),
+          );
+          break;
+      }
+    }
+    this.applySecurity(spec.security, query, headers, cookies);
+    if (cookies.length > 0) {
+      headers.set("Cookie", cookies.join("; "));
+    }
+    let body: BodyInit | undefined;
+    if (spec.body !== undefined && spec.contentType !== undefined) {
+      const ct = spec.contentType;
+      if (/^application\/(.+\+)?json$/.test(ct)) {
+        body = JSON.stringify(spec.body);
+        headers.set("Content-Type", ct);
+      } else if (ct === "application/x-www-form-urlencoded") {
+        body = spec.body instanceof URLSearchParams ? spec.body : new URLSearchParams(spec.body as Record<string, string>);
+      } else if (ct.startsWith("multipart/")) {
+        if (spec.body instanceof FormData) {
+          body = spec.body;
+        } else {
+          const form = new FormData();
+          Object.entries(spec.body as Record<string, string | Blob>).forEach(([k, v]) => form.append(k, v));
+          body = form;
+        }
+      } else {
+        body = spec.body as BodyInit;
+        headers.set("Content-Type", ct);
+      }
+    }
+    const url = this.baseUrl + path + (query.length > 0 ? "?" + query.join("&") : "");
+    const response = await this.fetch(url, { ...this.init, ...init, method: spec.method, headers, body });
+    const status = String(response.status);
+    const range = status[0] + "XX";
+    const key =
+      status in spec.responses ? status : range in spec.responses ? range : "default" in spec.responses ? "default" : undefined;
+    if (key === undefined) {
+      throw new ApiError(response, await response.text());
+    }
+    let data: unknown;
+    switch (spec.responses[key]) {
+      case "json":
+        data = await response.json();
+        break;
+      case "text":
+        data = await response.text();
+        break;
+      case "blob":
+        data = await response.blob();
+        break;
+    }
+    return { status: response.status, data, response };
+  }
+
+  // Applies credentials of the first requirement whose schemes all have
+  // credentials.
+  private applySecurity(security: string[][], query: string[], headers: Headers, cookies: string[]): void {
+    const credentials = this.credentials as Record<string, unknown>;
+    const requirement = security.find((schemes) => schemes.every((name) => credentials[name] !== undefined));
+    for (const name of requirement ?? []) {
+      const scheme = securitySchemes[name];
+      const value = credentials[name];
+      if (scheme === undefined) {
+        continue;
+      }
+      if (scheme.type === "apiKey") {
+        const key = String(value);
+        if (scheme.in === "header") {
+          headers.set(scheme.name ?? "", key);
+        } else if (scheme.in === "query") {
+          query.push(encode(scheme.name) + "=" + encode(key));
+        } else {
+          cookies.push(scheme.name + "=" + encode(key));
+        }
+      } else if (scheme.scheme?.toLowerCase() === "basic") {
+        const basic = value as { username: string; password: string };
+        headers.set("Authorization", "Basic " + btoa(basic.username + ":" + basic.password));
+      } else {
+        const prefix = scheme.scheme?.toLowerCase() === "bearer" ? "Bearer" : scheme.scheme;
+        headers.set("Authorization", prefix + " " + String(value));
+      }
+    }
+  }
+`
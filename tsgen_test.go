@@ -0,0 +1,92 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypeScriptTypes(t *testing.T) {
+	var doc, err = FromJSON([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Pets", "version": "1.0"},
+		"components": {
+			"schemas": {
+				"Pet": {
+					"description": "Pet is a cat or a dog.",
+					"oneOf": [{"$ref": "#/components/schemas/Cat"}, {"$ref": "#/components/schemas/Dog"}],
+					"discriminator": {"propertyName": "kind", "mapping": {"cat": "#/components/schemas/Cat"}}
+				},
+				"Cat": {
+					"type": "object",
+					"required": ["kind"],
+					"properties": {
+						"kind": {"type": "string"},
+						"color": {"$ref": "#/components/schemas/Color"},
+						"birth-date": {"type": "string", "format": "date-time", "deprecated": true}
+					}
+				},
+				"Dog": {
+					"type": "object",
+					"required": ["kind"],
+					"properties": {
+						"kind": {"type": "string"},
+						"owner": {"type": ["string", "null"]},
+						"tags": {"type": "array", "items": {"type": ["string", "integer"]}}
+					}
+				},
+				"Color": {"type": "string", "enum": ["black", "white"]},
+				"Labels": {"type": "object", "additionalProperties": {"type": "integer"}},
+				"Error": {"type": "object", "properties": {"message": {"type": "string"}}}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := GenerateTypeScriptTypes(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"export interface Cat {\n  /** @deprecated */\n  \"birth-date\"?: string;\n  color?: Color;\n  kind: string;\n}\n",
+		"export type Color = \"black\" | \"white\";\n",
+		"  owner?: string | null;\n  tags?: (string | number)[];\n",
+		"export interface ErrorModel {\n",
+		"export type Labels = Record<string, number>;\n",
+		"/** Pet is a cat or a dog. */\nexport type Pet = (Cat & { kind: \"cat\" }) | (Dog & { kind: \"Dog\" });\n",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Fatalf("missing %q in:\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateTypeScriptClient(t *testing.T) {
+	var doc, err = FromJSON([]byte(petStore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := GenerateTypeScriptClient(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"export interface GetPetParams {\n",
+		"  \"X-Request-ID\"?: string;\n",
+		"export type GetPetResponse =\n  | ApiResponse<200, Pet>\n  | ApiResponse<404, undefined>;\n",
+		"  async listPets(params: ListPetsParams = {}, init?: RequestInit): Promise<ListPetsResponse> {\n",
+		"{ name: \"ids\", in: \"query\", style: \"form\", explode: false, value: params[\"ids\"] },\n",
+		"  async createPet(body: NewPet, init?: RequestInit): Promise<CreatePetResponse> {\n",
+		"        security: [[\"api_key\"], [\"bearer\"]],\n",
+		"  api_key: { type: \"apiKey\", in: \"header\", name: \"X-API-Key\" },\n",
+		"      env: { default: \"api\", enum: [\"api\", \"staging\"] },\n",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Fatalf("missing %q in:\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,510 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRecursiveType is returned by SchemaFromType for recursive types when no
+// Components are available to hold them.
+var ErrRecursiveType = errors.New("recursive type")
+
+// SchemaProvider is implemented by types that provide their own Schema to
+// SchemaFromType.
+type SchemaProvider interface {
+	OpenAPISchema() *Schema
+}
+
+// SchemaOptions configure SchemaFromType.
+//
+// Options should be reused across calls that register types to the same
+// Components so that types are registered only once.
+type SchemaOptions struct {
+	// Components, if not nil, receives schemas of named types which are
+	// referenced instead of being defined inline.
+	Components *Components
+	// TagName is the name of the struct tag holding schema constraints.
+	// If empty "openapi" is used.
+	TagName string
+	// Namer, if not nil, returns the component name for a named type.
+	// Names are sanitized to match the allowed component key pattern.
+	// If nil the type name is used, qualified with the package name on
+	// collision.
+	Namer func(t reflect.Type) string
+
+	// types maps registered types to component names.
+	types map[reflect.Type]string
+	// inline marks unregistered types being generated, to detect recursion.
+	inline map[reflect.Type]bool
+}
+
+// Returns a Schema describing values of t as encoded by encoding/json.
+//
+// Struct fields are named after their json tags and fields without the
+// omitempty option are required. Pointers are nullable. Embedded structs are
+// composed using allOf. time.Time is a string of date-time format, maps are
+// objects with additionalProperties and []byte is a base64 encoded string.
+// Types implementing encoding.TextMarshaler are strings and types
+// implementing SchemaProvider provide their own schema. Types implementing
+// json.Marshaler have an empty schema allowing any value, as their encoding
+// can not be derived from the type; they should implement SchemaProvider to
+// be described. Interface types, including those embedding SchemaProvider,
+// have an empty schema.
+//
+// Constraints are set using a struct tag, by default "openapi", holding a
+// comma separated list of key=value pairs or flags, e.g.
+// `openapi:"minLength=3,format=email"`. Values containing commas can be
+// enclosed in single quotes. Supported keys are: title, description, format,
+// pattern, minLength, maxLength, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, multipleOf, minItems, maxItems, minProperties,
+// maxProperties, enum (values separated by "|"), default, example, const and
+// flags uniqueItems, deprecated, readOnly, writeOnly, required, optional and
+// nullable.
+//
+// If opts specifies Components, named types are added to Components.Schemas
+// and referenced. opts may be nil.
+func SchemaFromType(t reflect.Type, opts *SchemaOptions) (*Schema, error) {
+	if opts == nil {
+		opts = &SchemaOptions{}
+	}
+	if opts.types == nil {
+		opts.types = make(map[reflect.Type]string)
+	}
+	opts.inline = make(map[reflect.Type]bool)
+	return opts.schema(t)
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	schemaProvider    = reflect.TypeOf((*SchemaProvider)(nil)).Elem()
+	jsonMarshaler     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler     = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	invalidComponentR = regexp.MustCompile(`[^a-zA-Z0-9\.\-_]+`)
+)
+
+// Returns the schema for t, referencing it if it is registrable.
+func (opts *SchemaOptions) schema(t reflect.Type) (*Schema, error) {
+	if t.Kind() == reflect.Ptr {
+		var elem, err = opts.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return nullable(elem), nil
+	}
+	if opts.Components == nil || !registrable(t) {
+		if opts.inline[t] {
+			return nil, fmt.Errorf("%w: %s", ErrRecursiveType, t)
+		}
+		if t.Name() != "" {
+			opts.inline[t] = true
+			defer delete(opts.inline, t)
+		}
+		return opts.define(t)
+	}
+	if name, ok := opts.types[t]; ok {
+		return &Schema{Ref: componentsPrefix + "schemas/" + escapePointer(name)}, nil
+	}
+	var name = opts.componentName(t)
+	opts.types[t] = name
+	if opts.Components.Schemas == nil {
+		opts.Components.Schemas = make(map[string]interface{})
+	}
+	// Reserve the name for recursive references.
+	opts.Components.Schemas[name] = &Schema{}
+	var s, err = opts.define(t)
+	if err != nil {
+		delete(opts.types, t)
+		delete(opts.Components.Schemas, name)
+		return nil, err
+	}
+	opts.Components.Schemas[name] = s
+	return &Schema{Ref: componentsPrefix + "schemas/" + escapePointer(name)}, nil
+}
+
+// Returns true if t is a named type that should be registered as a
+// component.
+func registrable(t reflect.Type) bool {
+	if t.Name() == "" || t.PkgPath() == "" || t == timeType || t == rawMessageType {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	}
+	// Named primitives are registered only if they provide a schema.
+	return t.Implements(schemaProvider) || reflect.PtrTo(t).Implements(schemaProvider)
+}
+
+// Returns a unique component name for t.
+func (opts *SchemaOptions) componentName(t reflect.Type) string {
+	var name string
+	if opts.Namer != nil {
+		name = opts.Namer(t)
+	} else {
+		name = t.Name()
+	}
+	name = invalidComponentR.ReplaceAllString(name, "_")
+	var taken = func(n string) bool {
+		_, exists := opts.Components.Schemas[n]
+		return exists
+	}
+	if !taken(name) {
+		return name
+	}
+	if pkg := t.PkgPath(); pkg != "" {
+		var qualified = invalidComponentR.ReplaceAllString(pkg[strings.LastIndex(pkg, "/")+1:], "_") + "." + name
+		if !taken(qualified) {
+			return qualified
+		}
+		name = qualified
+	}
+	for i := 2; ; i++ {
+		if n := name + strconv.Itoa(i); !taken(n) {
+			return n
+		}
+	}
+}
+
+// Returns the schema definition of t.
+func (opts *SchemaOptions) define(t reflect.Type) (*Schema, error) {
+	if t.Kind() != reflect.Interface && t.Implements(schemaProvider) {
+		return reflect.Zero(t).Interface().(SchemaProvider).OpenAPISchema(), nil
+	}
+	if reflect.PtrTo(t).Implements(schemaProvider) {
+		return reflect.New(t).Interface().(SchemaProvider).OpenAPISchema(), nil
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: TypeString, Format: "date-time"}, nil
+	case rawMessageType:
+		return &Schema{}, nil
+	}
+	if t.Implements(jsonMarshaler) || reflect.PtrTo(t).Implements(jsonMarshaler) {
+		return &Schema{}, nil
+	}
+	if t.Implements(textMarshaler) || reflect.PtrTo(t).Implements(textMarshaler) {
+		return &Schema{Type: TypeString}, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: TypeInteger, Format: "int32"}, nil
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: TypeInteger, Format: "int64"}, nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: TypeInteger, Format: "int32", Minimum: float64Ptr(0)}, nil
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: TypeInteger, Format: "int64", Minimum: float64Ptr(0)}, nil
+	case reflect.Float32:
+		return &Schema{Type: TypeNumber, Format: "float"}, nil
+	case reflect.Float64:
+		return &Schema{Type: TypeNumber, Format: "double"}, nil
+	case reflect.String:
+		return &Schema{Type: TypeString}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 && !reflect.PtrTo(t.Elem()).Implements(textMarshaler) {
+			return &Schema{Type: TypeString, Format: "byte", ContentEncoding: "base64"}, nil
+		}
+		var items, err = opts.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: TypeArray, Items: items}, nil
+	case reflect.Array:
+		var items, err = opts.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: TypeArray, Items: items, MinItems: intPtr(t.Len()), MaxItems: intPtr(t.Len())}, nil
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			if !reflect.PtrTo(t.Key()).Implements(textMarshaler) {
+				return nil, fmt.Errorf("unsupported map key type: %s", t.Key())
+			}
+		}
+		var values, err = opts.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: TypeObject, AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return opts.structSchema(t)
+	}
+	return nil, fmt.Errorf("unsupported type: %s", t)
+}
+
+// Returns the schema of a struct type.
+func (opts *SchemaOptions) structSchema(t reflect.Type) (*Schema, error) {
+	var (
+		result = &Schema{Type: TypeObject, Properties: make(map[string]*Schema)}
+		allOf  []*Schema
+	)
+	for i := 0; i < t.NumField(); i++ {
+		var field = t.Field(i)
+		var tag = field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		var name, options = parseJSONTag(tag)
+		if field.Anonymous && name == "" {
+			var ft = field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				var s, err = opts.schema(ft)
+				if err != nil {
+					return nil, err
+				}
+				allOf = append(allOf, s)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		var s, err = opts.schema(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t, field.Name, err)
+		}
+		if options["string"] {
+			s = stringOption(s)
+		}
+		var required = !options["omitempty"]
+		if s, required, err = opts.applyTag(s, field.Tag.Get(opts.tagName()), required); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t, field.Name, err)
+		}
+		result.Properties[name] = s
+		if required {
+			result.Required = append(result.Required, name)
+		}
+	}
+	if len(allOf) == 0 {
+		return result, nil
+	}
+	if len(result.Properties) > 0 {
+		allOf = append(allOf, result)
+	}
+	return &Schema{AllOf: allOf}, nil
+}
+
+// Returns the struct tag name.
+func (opts *SchemaOptions) tagName() string {
+	if opts.TagName != "" {
+		return opts.TagName
+	}
+	return "openapi"
+}
+
+// Parses a json struct tag into name and options.
+func parseJSONTag(tag string) (name string, options map[string]bool) {
+	options = make(map[string]bool)
+	var parts = strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		options[opt] = true
+	}
+	return parts[0], options
+}
+
+// Returns the schema of a value encoded with the json ",string" option.
+func stringOption(s *Schema) *Schema {
+	switch s.PrimaryType() {
+	case TypeInteger, TypeNumber, TypeBoolean:
+		var result = &Schema{Type: TypeString, Format: s.Format}
+		if s.HasType(TypeNull) {
+			result.Type = []string{TypeString, TypeNull}
+		}
+		return result
+	}
+	return s
+}
+
+// Returns a copy of s that also allows null.
+func nullable(s *Schema) *Schema {
+	if s.Ref != "" || len(s.AllOf) > 0 {
+		return &Schema{OneOf: []*Schema{s, {Type: TypeNull}}}
+	}
+	var types = s.Types()
+	if len(types) == 0 {
+		return s
+	}
+	for _, t := range types {
+		if t == TypeNull {
+			return s
+		}
+	}
+	var result = *s
+	result.Type = append(append([]string{}, types...), TypeNull)
+	return &result
+}
+
+// Applies constraints from an openapi struct tag to a property schema and
+// returns the modified schema and required flag.
+func (opts *SchemaOptions) applyTag(s *Schema, tag string, required bool) (*Schema, bool, error) {
+	if tag == "" {
+		return s, required, nil
+	}
+	var pairs, err = splitTag(tag)
+	if err != nil {
+		return nil, false, err
+	}
+	// Schemas may be shared, i.e. returned by a SchemaProvider.
+	var copied = *s
+	s = &copied
+	for _, kv := range pairs {
+		var key, value = kv[0], kv[1]
+		switch key {
+		case "required":
+			required = true
+		case "optional":
+			required = false
+		case "nullable":
+			s = nullable(s)
+		case "title":
+			s.Title = value
+		case "description":
+			s.Description = value
+		case "format":
+			s.Format = value
+		case "pattern":
+			if _, err = regexp.Compile(value); err != nil {
+				return nil, false, err
+			}
+			s.Pattern = value
+		case "minLength", "maxLength", "minItems", "maxItems", "minProperties", "maxProperties":
+			var n, err = strconv.Atoi(value)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			*intField(s, key) = &n
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf":
+			var f, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			*floatField(s, key) = &f
+		case "uniqueItems":
+			s.UniqueItems = true
+		case "deprecated":
+			s.Deprecated = true
+		case "readOnly":
+			s.ReadOnly = true
+		case "writeOnly":
+			s.WriteOnly = true
+		case "enum":
+			for _, v := range strings.Split(value, "|") {
+				s.Enum = append(s.Enum, tagValue(s, v))
+			}
+		case "default":
+			s.Default = tagValue(s, value)
+		case "example":
+			s.Examples = append(s.Examples, tagValue(s, value))
+		case "const":
+			s.Const = tagValue(s, value)
+		default:
+			return nil, false, fmt.Errorf("unknown %s tag key '%s'", opts.tagName(), key)
+		}
+	}
+	return s, required, nil
+}
+
+// Splits an openapi tag into key value pairs. Values may be quoted with
+// single quotes to include commas.
+func splitTag(tag string) (result [][2]string, err error) {
+	for len(tag) > 0 {
+		var key, value string
+		var i = strings.IndexAny(tag, "=,")
+		if i < 0 {
+			result = append(result, [2]string{strings.TrimSpace(tag), ""})
+			break
+		}
+		key = strings.TrimSpace(tag[:i])
+		if tag[i] == ',' {
+			result = append(result, [2]string{key, ""})
+			tag = tag[i+1:]
+			continue
+		}
+		tag = tag[i+1:]
+		if strings.HasPrefix(tag, "'") {
+			var end = strings.IndexByte(tag[1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in tag value of '%s'", key)
+			}
+			value = tag[1 : end+1]
+			tag = strings.TrimPrefix(tag[end+2:], ",")
+		} else if end := strings.IndexByte(tag, ','); end >= 0 {
+			value, tag = tag[:end], tag[end+1:]
+		} else {
+			value, tag = tag, ""
+		}
+		result = append(result, [2]string{key, value})
+	}
+	return
+}
+
+// Converts a tag value to a value of the type of s.
+func tagValue(s *Schema, value string) interface{} {
+	if v, err := coerceString(&Schema{Type: s.PrimaryType()}, value); err == nil && v != nil {
+		return v
+	}
+	return value
+}
+
+// Returns a pointer to the integer constraint field of s named key.
+func intField(s *Schema, key string) **int {
+	switch key {
+	case "minLength":
+		return &s.MinLength
+	case "maxLength":
+		return &s.MaxLength
+	case "minItems":
+		return &s.MinItems
+	case "maxItems":
+		return &s.MaxItems
+	case "minProperties":
+		return &s.MinProperties
+	}
+	return &s.MaxProperties
+}
+
+// Returns a pointer to the numeric constraint field of s named key.
+func floatField(s *Schema, key string) **float64 {
+	switch key {
+	case "minimum":
+		return &s.Minimum
+	case "maximum":
+		return &s.Maximum
+	case "exclusiveMinimum":
+		return &s.ExclusiveMinimum
+	case "exclusiveMaximum":
+		return &s.ExclusiveMaximum
+	}
+	return &s.MultipleOf
+}
+
+// Returns a pointer to v.
+func float64Ptr(v float64) *float64 { return &v }
+
+// Returns a pointer to v.
+func intPtr(v int) *int { return &v }
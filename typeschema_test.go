@@ -0,0 +1,140 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testSchemaBase struct {
+	ID int64 `json:"id"`
+}
+
+type testSchemaAddress struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip,omitempty" openapi:"pattern=^[0-9]+$,minLength=5"`
+}
+
+type testSchemaRaw struct{}
+
+func (testSchemaRaw) MarshalJSON() ([]byte, error) { return []byte(`"raw"`), nil }
+
+type testSchemaColor struct{ R, G, B uint8 }
+
+func (testSchemaColor) MarshalJSON() ([]byte, error) { return []byte(`"#000000"`), nil }
+
+func (testSchemaColor) OpenAPISchema() *Schema {
+	return &Schema{Type: TypeString, Pattern: "^#[0-9a-f]{6}$"}
+}
+
+type testSchemaShape interface {
+	SchemaProvider
+	Area() float64
+}
+
+type testSchemaDrawing struct {
+	Shape testSchemaShape `json:"shape"`
+}
+
+type testSchemaPerson struct {
+	testSchemaBase
+	Name    string              `json:"name" openapi:"minLength=1,description='Full name, as given'"`
+	Nick    string              `json:"nick,omitempty" openapi:"required,enum=a|b"`
+	Email   string              `json:"email" openapi:"format=email,optional"`
+	Age     *int                `json:"age" openapi:"minimum=0,example=42"`
+	Secret  string              `json:"-"`
+	Tags    []string            `json:"tags,omitempty" openapi:"uniqueItems,minItems=1"`
+	Address *testSchemaAddress  `json:"address"`
+	Friends []*testSchemaPerson `json:"friends,omitempty"`
+	Raw     testSchemaRaw       `json:"raw"`
+	Count   int                 `json:"count,string"`
+	Plain   bool
+	hidden  int
+}
+
+func TestSchemaFromType(t *testing.T) {
+	var tests = []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"time", time.Time{}, `{"type":"string","format":"date-time"}`},
+		{"map", map[string]float64{}, `{"additionalProperties":{"type":"number","format":"double"},"type":"object"}`},
+		{"slice", []uint8{}, `{"type":"string","format":"byte","contentEncoding":"base64"}`},
+		{"strings", []string{}, `{"items":{"type":"string"},"type":"array"}`},
+		{"array", [2]int{}, `{"items":{"type":"integer","format":"int64"},"type":"array","maxItems":2,"minItems":2}`},
+		{"pointer", new(int32), `{"type":["integer","null"],"format":"int32"}`},
+		{"marshaler", testSchemaRaw{}, `{}`},
+		{"provider", testSchemaColor{}, `{"type":"string","pattern":"^#[0-9a-f]{6}$"}`},
+		{"provider interface", testSchemaDrawing{}, `{"properties":{"shape":{}},"type":"object","required":["shape"]}`},
+		{"struct", testSchemaAddress{}, `{"properties":{"street":{"type":"string"},"zip":{"type":"string","minLength":5,"pattern":"^[0-9]+$"}},` +
+			`"type":"object","required":["street"]}`},
+	}
+	for _, test := range tests {
+		var s, err = SchemaFromType(reflect.TypeOf(test.v), nil)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := jsonString(t, s); got != test.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSchemaFromTypeComponents(t *testing.T) {
+	var opts = &SchemaOptions{Components: &Components{}}
+	var s, err = SchemaFromType(reflect.TypeOf(testSchemaPerson{}), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var checks = []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"ref", s, `{"$ref":"#/components/schemas/testSchemaPerson"}`},
+		{"person", opts.Components.Schemas["testSchemaPerson"], `{"allOf":[{"$ref":"#/components/schemas/testSchemaBase"},{"properties":{"Plain":{"type":"boolean"},` +
+			`"address":{"oneOf":[{"$ref":"#/components/schemas/testSchemaAddress"},{"type":"null"}]},` +
+			`"age":{"type":["integer","null"],"minimum":0,"format":"int64","examples":[42]},"count":{"type":"string",` +
+			`"format":"int64"},"email":{"type":"string","format":"email"},` +
+			`"friends":{"items":{"oneOf":[{"$ref":"#/components/schemas/testSchemaPerson"},{"type":"null"}]},` +
+			`"type":"array"},"name":{"type":"string","minLength":1,"description":"Full name, as given"},` +
+			`"nick":{"type":"string","enum":["a","b"]},"raw":{"$ref":"#/components/schemas/testSchemaRaw"},` +
+			`"tags":{"items":{"type":"string"},"type":"array","minItems":1,"uniqueItems":true}},"type":"object",` +
+			`"required":["name","nick","age","address","raw","count","Plain"]}]}`},
+		{"address", opts.Components.Schemas["testSchemaAddress"], `{"properties":{"street":{"type":"string"},"zip":{"type":"string","minLength":5,"pattern":"^[0-9]+$"}},` +
+			`"type":"object","required":["street"]}`},
+		{"base", opts.Components.Schemas["testSchemaBase"], `{"properties":{"id":{"type":"integer","format":"int64"}},"type":"object","required":["id"]}`},
+		{"raw", opts.Components.Schemas["testSchemaRaw"], `{}`},
+	}
+	for _, check := range checks {
+		if got := jsonString(t, check.v); got != check.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", check.name, got, check.want)
+		}
+	}
+
+	if _, err = SchemaFromType(reflect.TypeOf(testSchemaPerson{}), nil); !errors.Is(err, ErrRecursiveType) {
+		t.Errorf("got error %v, want ErrRecursiveType", err)
+	}
+	for _, v := range []interface{}{
+		struct {
+			A string `openapi:"unknown=1"`
+		}{},
+		struct {
+			A string `openapi:"minLength=x"`
+		}{},
+		struct {
+			A string `openapi:"description='open"`
+		}{},
+		map[struct{}]string{},
+	} {
+		if _, err = SchemaFromType(reflect.TypeOf(v), nil); err == nil {
+			t.Errorf("%T: expected error", v)
+		}
+	}
+}
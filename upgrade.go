@@ -0,0 +1,269 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// UpgradeV30 upgrades an OpenAPI 3.0 document in JSON or YAML to OpenAPI 3.1
+// and returns it with a change log of each rewrite.
+//
+// Schemas are rewritten to JSON Schema 2020-12:
+//
+//   - nullable adds "null" to the type, or wraps a schema without a type in
+//     an anyOf with a null schema.
+//   - Boolean exclusiveMinimum and exclusiveMaximum are numbers.
+//   - example is an examples list.
+//   - format binary is contentMediaType application/octet-stream and format
+//     byte is contentEncoding base64. Binary schemas of non-JSON media types
+//     are removed as their content is already binary.
+//   - Assertion keywords next to $ref, which 3.0 ignores, are removed.
+//
+// x-webhooks of the document are webhooks and the openapi field is set to
+// Version. The SourceVersion of the result is the version of data.
+func UpgradeV30(data []byte) (*OpenAPI, []*ConversionNote, error) {
+	var src, err = decodeRaw(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var version, minor, _ = documentVersion(src["openapi"], nil)
+	if version == "" || minor != 0 {
+		return nil, nil, fmt.Errorf("unsupported openapi version '%v'", src["openapi"])
+	}
+	var c = &converter{}
+	src["openapi"] = version
+	c.upgradeV30(src)
+	if data, err = json.Marshal(src); err != nil {
+		return nil, nil, err
+	}
+	var doc *OpenAPI
+	if doc, err = FromJSON(data); err != nil {
+		return nil, nil, err
+	}
+	doc.SourceVersion = version
+	return doc, c.notes, nil
+}
+
+// Upgrades raw 3.0 document doc in place.
+func (c *converter) upgradeV30(doc map[string]interface{}) {
+	c.note("/openapi", "openapi %v upgraded to %s", doc["openapi"], Version)
+	doc["openapi"] = Version
+	if hooks, ok := doc["x-webhooks"]; ok {
+		if _, exists := doc["webhooks"]; exists {
+			c.note("/x-webhooks", "x-webhooks ignored in favor of webhooks")
+		} else {
+			doc["webhooks"] = hooks
+			c.note("/x-webhooks", "x-webhooks rewritten as webhooks")
+		}
+		delete(doc, "x-webhooks")
+	}
+	walkSchemas(doc, c.upgradeSchema, c.upgradeMediaType)
+}
+
+// schemaWalker visits schemas at their structural positions in a raw
+// document: component schemas and the schema of each Parameter, Header and
+// Media Type Object.
+type schemaWalker struct {
+	// schema is called for each schema, which is replaced with the result.
+	schema func(pointer string, v interface{}) interface{}
+	// media is called for each Media Type Object before its schema.
+	media func(pointer, contentType string, mt map[string]interface{})
+}
+
+// Calls schema for each schema of raw document doc and replaces the schema
+// with the result. media is called for each Media Type Object before its
+// schema.
+func walkSchemas(doc map[string]interface{}, schema func(pointer string, v interface{}) interface{},
+	media func(pointer, contentType string, mt map[string]interface{})) {
+	var (
+		w          = &schemaWalker{schema: schema, media: media}
+		components = rawObject(doc["components"])
+		schemas    = rawObject(components["schemas"])
+	)
+	for _, name := range sortedKeys(schemas) {
+		schemas[name] = schema("/components/schemas/"+escapePointer(name), schemas[name])
+	}
+	w.each("/components/parameters", components["parameters"], false, w.parameter)
+	w.each("/components/headers", components["headers"], false, w.parameter)
+	w.each("/components/requestBodies", components["requestBodies"], false, w.requestBody)
+	w.each("/components/responses", components["responses"], false, w.response)
+	w.each("/components/callbacks", components["callbacks"], false, w.callback)
+	w.each("/components/pathItems", components["pathItems"], false, w.pathItem)
+	w.each("/paths", doc["paths"], true, w.pathItem)
+	w.each("/webhooks", doc["webhooks"], false, w.pathItem)
+}
+
+// Calls fn with each object of map v at pointer, skipping specification
+// extensions if extensible.
+func (w *schemaWalker) each(pointer string, v interface{}, extensible bool, fn func(string, map[string]interface{})) {
+	var m = rawObject(v)
+	for _, key := range sortedKeys(m) {
+		if extensible && strings.HasPrefix(key, "x-") {
+			continue
+		}
+		if obj := rawObject(m[key]); obj != nil {
+			fn(pointer+"/"+escapePointer(key), obj)
+		}
+	}
+}
+
+// Visits a Path Item Object.
+func (w *schemaWalker) pathItem(pointer string, item map[string]interface{}) {
+	w.parameters(pointer+"/parameters", item["parameters"])
+	for _, method := range Methods {
+		var key = strings.ToLower(method)
+		if op := rawObject(item[key]); op != nil {
+			w.operation(pointer+"/"+key, op)
+		}
+	}
+}
+
+// Visits an Operation Object.
+func (w *schemaWalker) operation(pointer string, op map[string]interface{}) {
+	w.parameters(pointer+"/parameters", op["parameters"])
+	if body := rawObject(op["requestBody"]); body != nil {
+		w.requestBody(pointer+"/requestBody", body)
+	}
+	w.each(pointer+"/responses", op["responses"], true, w.response)
+	w.each(pointer+"/callbacks", op["callbacks"], false, w.callback)
+}
+
+// Visits a list of Parameter Objects.
+func (w *schemaWalker) parameters(pointer string, v interface{}) {
+	for i, p := range rawList(v) {
+		if obj := rawObject(p); obj != nil {
+			w.parameter(fmt.Sprintf("%s/%d", pointer, i), obj)
+		}
+	}
+}
+
+// Visits a Parameter or Header Object.
+func (w *schemaWalker) parameter(pointer string, p map[string]interface{}) {
+	if _, ok := p["schema"]; ok {
+		p["schema"] = w.schema(pointer+"/schema", p["schema"])
+	}
+	w.content(pointer+"/content", p["content"])
+}
+
+// Visits a Request Body Object.
+func (w *schemaWalker) requestBody(pointer string, body map[string]interface{}) {
+	w.content(pointer+"/content", body["content"])
+}
+
+// Visits a Response Object.
+func (w *schemaWalker) response(pointer string, resp map[string]interface{}) {
+	w.each(pointer+"/headers", resp["headers"], false, w.parameter)
+	w.content(pointer+"/content", resp["content"])
+}
+
+// Visits a Callback Object.
+func (w *schemaWalker) callback(pointer string, cb map[string]interface{}) {
+	w.each(pointer, cb, true, w.pathItem)
+}
+
+// Visits a content map of Media Type Objects.
+func (w *schemaWalker) content(pointer string, v interface{}) {
+	w.each(pointer, v, false, func(pointer string, mt map[string]interface{}) {
+		w.media(pointer, unescapePointer(pointer[strings.LastIndex(pointer, "/")+1:]), mt)
+		if _, ok := mt["schema"]; ok {
+			mt["schema"] = w.schema(pointer+"/schema", mt["schema"])
+		}
+		w.each(pointer+"/encoding", mt["encoding"], false, func(pointer string, enc map[string]interface{}) {
+			w.each(pointer+"/headers", enc["headers"], false, w.parameter)
+		})
+	})
+}
+
+// Removes the schema of a Media Type Object for a non-JSON, non-form content
+// type if it only describes binary content.
+func (c *converter) upgradeMediaType(pointer, contentType string, mt map[string]interface{}) {
+	if !isJSONMediaType(contentType) && contentType != "application/x-www-form-urlencoded" &&
+		!strings.HasPrefix(contentType, "multipart/") && isBinarySchema(mt["schema"]) {
+		delete(mt, "schema")
+		c.note(pointer+"/schema", "binary schema of media type removed")
+	}
+}
+
+// Returns true if raw schema v only describes a binary string.
+func isBinarySchema(v interface{}) bool {
+	var s = rawObject(v)
+	if s["type"] != "string" || s["format"] != "binary" {
+		return false
+	}
+	for key := range s {
+		switch key {
+		case "type", "format", "description", "title":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// schemaAnnotations are keywords that do not assert anything about an
+// instance.
+var schemaAnnotations = map[string]bool{
+	"title": true, "description": true, "default": true, "deprecated": true,
+	"readOnly": true, "writeOnly": true, "example": true, "examples": true,
+	"externalDocs": true, "xml": true, "nullable": true,
+}
+
+// Returns raw 3.0 schema v at pointer upgraded to JSON Schema 2020-12.
+func (c *converter) upgradeSchema(pointer string, v interface{}) interface{} {
+	var s = rawObject(v)
+	if s == nil {
+		return v
+	}
+	if _, ok := s["$ref"]; ok {
+		for _, key := range sortedKeys(s) {
+			if key != "$ref" && !schemaAnnotations[key] && !strings.HasPrefix(key, "x-") {
+				delete(s, key)
+				c.note(pointer+"/"+escapePointer(key), "%s next to $ref removed", key)
+			}
+		}
+	}
+	for _, key := range sortedKeys(s) {
+		var kp = pointer + "/" + escapePointer(key)
+		switch key {
+		case "properties", "patternProperties":
+			var props = rawObject(s[key])
+			for _, name := range sortedKeys(props) {
+				props[name] = c.upgradeSchema(kp+"/"+escapePointer(name), props[name])
+			}
+		case "allOf", "anyOf", "oneOf":
+			for i, sub := range rawList(s[key]) {
+				rawList(s[key])[i] = c.upgradeSchema(fmt.Sprintf("%s/%d", kp, i), sub)
+			}
+		case "items", "additionalProperties", "not":
+			s[key] = c.upgradeSchema(kp, s[key])
+		}
+	}
+	for _, change := range upgradeSchemaKeywords(s) {
+		c.note(pointer, "%s", change)
+	}
+	switch s["format"] {
+	case "binary":
+		delete(s, "format")
+		s["contentMediaType"] = "application/octet-stream"
+		c.note(pointer+"/format", "format binary rewritten as contentMediaType application/octet-stream")
+	case "byte":
+		delete(s, "format")
+		s["contentEncoding"] = "base64"
+		c.note(pointer+"/format", "format byte rewritten as contentEncoding base64")
+	}
+	switch s["nullable"] {
+	case true:
+		delete(s, "nullable")
+		c.note(pointer+"/nullable", "nullable rewritten as a null type")
+		return nullableSchema(s)
+	case false:
+		delete(s, "nullable")
+		c.note(pointer+"/nullable", "nullable false removed")
+	}
+	return s
+}
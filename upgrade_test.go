@@ -0,0 +1,139 @@
+// Copyright 2021 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"testing"
+)
+
+func TestUpgradeV30(t *testing.T) {
+	var doc, notes, err = UpgradeV30([]byte(`{
+		"openapi": "3.0.3",
+		"info": {"title": "Pets", "version": "1.0"},
+		"paths": {
+			"/pets/{petId}/photo": {
+				"put": {
+					"requestBody": {
+						"content": {
+							"image/png": {"schema": {"type": "string", "format": "binary"}},
+							"multipart/form-data": {
+								"schema": {
+									"type": "object",
+									"properties": {"file": {"type": "string", "format": "binary"}}
+								}
+							}
+						}
+					},
+					"responses": {"204": {"description": "Uploaded"}}
+				}
+			}
+		},
+		"x-webhooks": {
+			"newPet": {"post": {"responses": {"200": {"description": "OK"}}}}
+		},
+		"components": {
+			"schemas": {
+				"Pet": {
+					"type": "object",
+					"properties": {
+						"name": {"type": "string", "nullable": true, "example": "Rex"},
+						"age": {"type": "integer", "minimum": 0, "exclusiveMinimum": true},
+						"owner": {"$ref": "#/components/schemas/Owner", "nullable": true, "maxLength": 3}
+					}
+				},
+				"Owner": {"type": "object"}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != Version {
+		t.Errorf("got version %s, want %s", doc.OpenAPI, Version)
+	}
+	if _, ok := doc.WebHooks["newPet"]; !ok {
+		t.Error("x-webhooks not rewritten")
+	}
+	var checks = []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"body", doc.Paths.Items["/pets/{petId}/photo"].Put.RequestBody,
+			`{"content":{"image/png":{},"multipart/form-data":{"schema":{"properties":{` +
+				`"file":{"contentMediaType":"application/octet-stream","type":"string"}},"type":"object"}}}}`},
+		{"pet", doc.Components.Schemas["Pet"],
+			`{"properties":{` +
+				`"age":{"exclusiveMinimum":0,"type":"integer"},` +
+				`"name":{"examples":["Rex"],"type":["string","null"]},` +
+				`"owner":{"anyOf":[{"$ref":"#/components/schemas/Owner"},{"type":"null"}]}},"type":"object"}`},
+	}
+	for _, check := range checks {
+		if got := jsonString(t, check.v); got != check.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", check.name, got, check.want)
+		}
+	}
+	var log []string
+	for _, note := range notes {
+		log = append(log, note.String())
+	}
+	var want = `["#/openapi: openapi 3.0.3 upgraded to 3.1.0",` +
+		`"#/x-webhooks: x-webhooks rewritten as webhooks",` +
+		`"#/components/schemas/Pet/properties/age: exclusiveMinimum true and minimum 0 rewritten as exclusiveMinimum 0",` +
+		`"#/components/schemas/Pet/properties/name: example rewritten as examples",` +
+		`"#/components/schemas/Pet/properties/name/nullable: nullable rewritten as a null type",` +
+		`"#/components/schemas/Pet/properties/owner/maxLength: maxLength next to $ref removed",` +
+		`"#/components/schemas/Pet/properties/owner/nullable: nullable rewritten as a null type",` +
+		`"#/paths/~1pets~1{petId}~1photo/put/requestBody/content/image~1png/schema: binary schema of media type removed",` +
+		`"#/paths/~1pets~1{petId}~1photo/put/requestBody/content/multipart~1form-data/schema/properties/file/format: ` +
+		`format binary rewritten as contentMediaType application/octet-stream"]`
+	if got := jsonString(t, log); got != want {
+		t.Errorf("log:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestUpgradeV30Structure(t *testing.T) {
+	var doc, notes, err = UpgradeV30([]byte(`{
+		"openapi": "3.0.3",
+		"info": {"title": "Pets", "version": "1.0"},
+		"paths": {},
+		"components": {
+			"schemas": {
+				"Page": {"type": "object", "properties": {"content": {"type": "object", "properties": {
+					"schema": {"type": "string", "example": "a"}
+				}}}}
+			},
+			"parameters": {
+				"schema": {"name": "schema", "in": "query", "schema": {"type": "string", "nullable": true}}
+			},
+			"headers": {
+				"content": {"content": {"text/plain": {"schema": {"type": "integer", "nullable": true}}}}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var checks = []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"page", doc.Components.Schemas["Page"],
+			`{"properties":{"content":{"properties":{"schema":{"examples":["a"],"type":"string"}},"type":"object"}},"type":"object"}`},
+		{"parameter", doc.Components.Parameters["schema"],
+			`{"in":"query","name":"schema","schema":{"type":["string","null"]}}`},
+		{"header", doc.Components.Headers["content"],
+			`{"content":{"text/plain":{"schema":{"type":["integer","null"]}}}}`},
+	}
+	for _, check := range checks {
+		if got := jsonString(t, check.v); got != check.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", check.name, got, check.want)
+		}
+	}
+	if len(notes) != 4 {
+		t.Errorf("got %d notes, want 4: %v", len(notes), notes)
+	}
+}
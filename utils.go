@@ -6,24 +6,104 @@ package openapi
 
 import (
 	"encoding/json"
-
-	"gopkg.in/yaml.v2"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 )
 
+// ErrUnsupportedVersion is returned when decoding a document of an OpenAPI
+// version other than 3.x.
+var ErrUnsupportedVersion = errors.New("unsupported openapi version")
+
 // Returns an OpenAPI instance from JSON data or an error.
+//
+// The document version is read from the openapi field. OpenAPI 3.0
+// documents are upgraded to 3.1 as by UpgradeV30 before decoding, so that
+// version specific fields such as boolean exclusiveMaximum or $ref siblings
+// are interpreted as their 3.1 equivalents. The OpenAPI field of an upgraded
+// document is Version and its SourceVersion is the version it was decoded
+// from, so that encoding the result yields a 3.1 document. Use DowngradeV30
+// to write a 3.0 document back. Documents without an openapi field are
+// decoded as is. Documents of other major versions, including Swagger 2.0
+// documents which can be converted with ConvertV2, are rejected with
+// ErrUnsupportedVersion.
 func FromJSON(data []byte) (result *OpenAPI, err error) {
+	var header struct {
+		OpenAPI interface{} `json:"openapi"`
+		Swagger interface{} `json:"swagger"`
+	}
+	if err = json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	var (
+		version string
+		minor   int
+	)
+	if version, minor, err = documentVersion(header.OpenAPI, header.Swagger); err != nil {
+		return nil, err
+	}
+	var upgrade = version != "" && minor == 0
+	if _, numeric := header.OpenAPI.(float64); upgrade || numeric {
+		var src map[string]interface{}
+		if err = json.Unmarshal(data, &src); err != nil {
+			return nil, err
+		}
+		src["openapi"] = version
+		if upgrade {
+			new(converter).upgradeV30(src)
+		}
+		if data, err = json.Marshal(src); err != nil {
+			return nil, err
+		}
+	}
 	result = &OpenAPI{}
 	if err = json.Unmarshal(data, result); err != nil {
-		result = nil
+		return nil, err
+	}
+	if upgrade {
+		result.SourceVersion = version
 	}
 	return
 }
 
-// Returns an OpenAPI instance from YAML data or an error.
+// Returns an OpenAPI instance from YAML data or an error. The document is
+// decoded and 3.0 documents are upgraded as by FromJSON.
 func FromYAML(data []byte) (result *OpenAPI, err error) {
-	result = &OpenAPI{}
-	if err = yaml.Unmarshal(data, result); err != nil {
-		result = nil
+	var src map[string]interface{}
+	if src, err = decodeRaw(data); err != nil {
+		return nil, err
 	}
-	return
-}
\ No newline at end of file
+	if data, err = json.Marshal(src); err != nil {
+		return nil, err
+	}
+	return FromJSON(data)
+}
+
+// Returns the version and minor version of an OpenAPI 3 document with
+// openapi and swagger field values or an error if the version is not
+// supported. The version is empty if the document has no openapi field.
+// A numeric version such as YAML 3.0 is formatted with its minor version.
+func documentVersion(openapi, swagger interface{}) (string, int, error) {
+	if openapi == nil {
+		if swagger != nil {
+			return "", 0, fmt.Errorf("%w: swagger %v, use ConvertV2", ErrUnsupportedVersion, swagger)
+		}
+		return "", 0, nil
+	}
+	var version = fmt.Sprint(openapi)
+	if f, ok := openapi.(float64); ok {
+		if version = strconv.FormatFloat(f, 'f', -1, 64); !strings.Contains(version, ".") {
+			version += ".0"
+		}
+	}
+	var parts = strings.SplitN(version, ".", 3)
+	if parts[0] != "3" || len(parts) < 2 {
+		return "", 0, fmt.Errorf("%w: %s", ErrUnsupportedVersion, version)
+	}
+	var minor, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid openapi version '%s'", version)
+	}
+	return version, minor, nil
+}
@@ -5,6 +5,7 @@
 package openapi
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 )
@@ -182,4 +183,64 @@ func TestFromJSON(t *testing.T) {
 		t.Fatal(err)
 	}
 	fmt.Println(oa)
-}
\ No newline at end of file
+}
+
+func TestFromJSONVersions(t *testing.T) {
+	var doc, err = FromJSON([]byte(`{
+		"openapi": "3.0.1",
+		"info": {"title": "Pets", "version": "1.0"},
+		"components": {
+			"schemas": {
+				"Age": {"type": "integer", "maximum": 30, "exclusiveMaximum": true},
+				"Owner": {"$ref": "#/components/schemas/Person", "type": "string", "description": "The owner."}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != Version || doc.SourceVersion != "3.0.1" {
+		t.Errorf("got version %s from %s, want %s from 3.0.1", doc.OpenAPI, doc.SourceVersion, Version)
+	}
+	var age = doc.Components.Schemas["Age"].(map[string]interface{})
+	if age["exclusiveMaximum"] != 30.0 || age["maximum"] != nil {
+		t.Errorf("3.0 exclusiveMaximum misread: %v", age)
+	}
+	var owner = doc.Components.Schemas["Owner"].(map[string]interface{})
+	if owner["type"] != nil || owner["description"] != "The owner." {
+		t.Errorf("3.0 $ref siblings misread: %v", owner)
+	}
+
+	for _, data := range []string{
+		`{"swagger": "2.0", "info": {"title": "Pets", "version": "1.0"}}`,
+		`{"openapi": "4.0.0", "info": {"title": "Pets", "version": "1.0"}}`,
+	} {
+		if _, err = FromJSON([]byte(data)); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("%s: got error %v, want ErrUnsupportedVersion", data, err)
+		}
+	}
+
+	if doc, err = FromYAML([]byte("openapi: 3.1.0\ninfo:\n  title: Pets\n  version: '1.0'\n")); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Info.Title != "Pets" {
+		t.Errorf("got title %q", doc.Info.Title)
+	}
+
+	var tests = []struct {
+		data, version, source string
+	}{
+		{"info: {title: Pets, version: '1.0'}\n", "", ""},
+		{"openapi: 3.0\ninfo: {title: Pets, version: '1.0'}\n", Version, "3.0"},
+		{"openapi: 3.1\ninfo: {title: Pets, version: '1.0'}\n", "3.1", ""},
+	}
+	for _, test := range tests {
+		if doc, err = FromYAML([]byte(test.data)); err != nil {
+			t.Errorf("%q: %v", test.data, err)
+			continue
+		}
+		if doc.OpenAPI != test.version || doc.SourceVersion != test.source {
+			t.Errorf("%q: got version %q from %q, want %q from %q", test.data, doc.OpenAPI, doc.SourceVersion, test.version, test.source)
+		}
+	}
+}